@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/kugouming/mcpservers/tools/elasticsearch"
+	"github.com/kugouming/mcpservers/tools/grpc"
 	"github.com/kugouming/mcpservers/tools/httprequest"
 	"github.com/kugouming/mcpservers/tools/prompt"
 	"github.com/kugouming/mcpservers/tools/switchhosts"
@@ -35,6 +36,7 @@ func NewMCPServer() *MCPServer {
 func (s *MCPServer) WithTools() *MCPServer {
 	httprequest.RegisterTool(s.server)
 	elasticsearch.RegisterTool(s.server)
+	grpc.RegisterTool(s.server)
 	// think.RegisterTool(s.server)
 	thinkplan.RegisterTool(s.server)
 	switchhosts.RegisterTool(s.server)
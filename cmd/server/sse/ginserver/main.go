@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kugouming/mcpservers/helper"
 	"github.com/kugouming/mcpservers/tools/elasticsearch"
 	"github.com/kugouming/mcpservers/tools/httprequest"
 	"github.com/kugouming/mcpservers/tools/thinkplan"
@@ -107,10 +108,10 @@ func LogMiddleware() gin.HandlerFunc {
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
 
-		// 记录请求信息
+		// 记录请求信息，请求头和请求体中的敏感信息脱敏后输出
 		log.Printf("\n\n[请求] %s %s\n请求头: %v\n请求体: %s\n\n",
 			c.Request.Method, c.Request.URL.Path,
-			c.Request.Header, string(requestBody))
+			helper.RedactHeader(c.Request.Header), helper.RedactBody(requestBody))
 
 		// 捕获响应
 		responseBody := &bytes.Buffer{}
@@ -123,8 +124,8 @@ func LogMiddleware() gin.HandlerFunc {
 		// 记录响应信息
 		log.Printf("\n\n[响应] %s %s\n状态码: %d\n响应头: %v\n响应体: %s\n处理时间: %v\n\n",
 			c.Request.Method, c.Request.URL.Path,
-			c.Writer.Status(), c.Writer.Header(),
-			responseBody.String(), time.Since(startTime))
+			c.Writer.Status(), helper.RedactHeader(c.Writer.Header()),
+			helper.RedactBody(responseBody.Bytes()), time.Since(startTime))
 	}
 }
 
@@ -203,7 +204,7 @@ func (s *MCPServer) WithHttpRequestTool() *MCPServer {
 		method := cast.ToString(request.GetArguments()["method"])
 		url := cast.ToString(request.GetArguments()["url"])
 
-		log.Printf("request: [%T: %v]\n", request.GetArguments()["headers"], request.GetArguments()["headers"])
+		log.Printf("request: [%T: %v]\n", request.GetArguments()["headers"], helper.RedactText(cast.ToString(request.GetArguments()["headers"])))
 		// 尝试解析headers参数
 		// headers := make(map[string]any)
 		// if h, ok := request.GetArguments()["headers"].(map[string]any); ok {
@@ -234,7 +235,11 @@ func (s *MCPServer) WithHttpRequestTool() *MCPServer {
 			body = b
 		}
 
-		log.Printf("Tool input: \n%s %s\n%v\n\n %v\n\n", strings.ToUpper(method), url, headers, body)
+		logHeaders := make(http.Header, len(headers))
+		for k, v := range headers {
+			logHeaders.Set(k, v)
+		}
+		log.Printf("Tool input: \n%s %s\n%v\n\n %v\n\n", strings.ToUpper(method), helper.RedactURL(url), helper.RedactHeader(logHeaders), helper.RedactBody([]byte(body)))
 
 		statusCode, responseBody, err := HttpRequest(ctx, method, url, headers, body)
 		if err != nil {
@@ -263,8 +268,7 @@ func HttpRequest(ctx context.Context, method, url string, headers map[string]str
 		req.Header.Add(k, v)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := helper.DefaultHttpClient().Do(req)
 	if err != nil {
 		return 0, nil, err
 	}
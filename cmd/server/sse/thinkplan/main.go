@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kugouming/mcpservers/helper"
 	"github.com/kugouming/mcpservers/tools/thinkplan"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -93,10 +94,10 @@ func LogMiddleware() gin.HandlerFunc {
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
 
-		// 记录请求信息
+		// 记录请求信息，请求头和请求体中的敏感信息脱敏后输出
 		log.Printf("\n\n[请求] %s %s\n请求头: %v\n请求体: %s\n\n",
 			c.Request.Method, c.Request.URL.Path,
-			c.Request.Header, string(requestBody))
+			helper.RedactHeader(c.Request.Header), helper.RedactBody(requestBody))
 
 		// 捕获响应
 		responseBody := &bytes.Buffer{}
@@ -109,8 +110,8 @@ func LogMiddleware() gin.HandlerFunc {
 		// 记录响应信息
 		log.Printf("\n\n[响应] %s %s\n状态码: %d\n响应头: %v\n响应体: %s\n处理时间: %v\n\n",
 			c.Request.Method, c.Request.URL.Path,
-			c.Writer.Status(), c.Writer.Header(),
-			responseBody.String(), time.Since(startTime))
+			c.Writer.Status(), helper.RedactHeader(c.Writer.Header()),
+			helper.RedactBody(responseBody.Bytes()), time.Since(startTime))
 	}
 }
 
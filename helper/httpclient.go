@@ -0,0 +1,439 @@
+package helper
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// HttpClientConfig HTTP客户端配置
+type HttpClientConfig struct {
+	Timeout            int      `mapstructure:"timeout" json:"timeout" yaml:"timeout"`                                        // 请求超时时间（秒），0 表示不限制
+	RetryCount         int      `mapstructure:"retry_count" json:"retry_count" yaml:"retry_count"`                            // 幂等请求的重试次数
+	RetryWaitMs        int      `mapstructure:"retry_wait_ms" json:"retry_wait_ms" yaml:"retry_wait_ms"`                      // 首次重试等待时间（毫秒），之后按指数退避
+	MaxRedirects       int      `mapstructure:"max_redirects" json:"max_redirects" yaml:"max_redirects"`                      // 最大重定向次数，0 表示不跟随重定向
+	Proxy              string   `mapstructure:"proxy" json:"proxy" yaml:"proxy"`                                              // HTTP 代理地址，为空时使用 HTTP_PROXY 等环境变量
+	CACert             string   `mapstructure:"ca_cert" json:"ca_cert" yaml:"ca_cert"`                                        // 自定义 CA 证书文件
+	ClientCert         string   `mapstructure:"client_cert" json:"client_cert" yaml:"client_cert"`                            // mTLS 客户端证书文件
+	ClientKey          string   `mapstructure:"client_key" json:"client_key" yaml:"client_key"`                               // mTLS 客户端私钥文件
+	InsecureSkipVerify []string `mapstructure:"insecure_skip_verify" json:"insecure_skip_verify" yaml:"insecure_skip_verify"` // 跳过证书校验的主机，支持 *.example.com 和 *
+}
+
+// DefaultHttpClientConfig 返回默认的HTTP客户端配置
+func DefaultHttpClientConfig() *HttpClientConfig {
+	return &HttpClientConfig{
+		Timeout:      30,
+		RetryCount:   2,
+		RetryWaitMs:  200,
+		MaxRedirects: 10,
+	}
+}
+
+var (
+	httpConfigOnce sync.Once
+	httpConfig     *viper.Viper
+
+	defaultHttpClient     *http.Client
+	defaultHttpClientLock sync.Mutex
+)
+
+// HttpConfig 返回 HTTP 相关工具共享的配置
+//
+// 配置文件为 http.yaml，查找路径依次为当前目录、config 目录、config/http 目录和 $HOME/.mcpservers，
+// 环境变量前缀为 HTTP，如 HTTP_CLIENT_TIMEOUT 会覆盖 client.timeout。
+func HttpConfig() *viper.Viper {
+	httpConfigOnce.Do(func() {
+		v := viper.New()
+		v.SetConfigName("http")
+		v.SetConfigType("yaml")
+		v.AddConfigPath(".")
+		v.AddConfigPath(GetConfigDir(""))
+		v.AddConfigPath(GetConfigDir("http"))
+		v.AddConfigPath("$HOME/.mcpservers")
+		v.SetEnvPrefix("HTTP")
+		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		v.AutomaticEnv()
+
+		defaults := DefaultHttpClientConfig()
+		v.SetDefault("client.timeout", defaults.Timeout)
+		v.SetDefault("client.retry_count", defaults.RetryCount)
+		v.SetDefault("client.retry_wait_ms", defaults.RetryWaitMs)
+		v.SetDefault("client.max_redirects", defaults.MaxRedirects)
+		v.SetDefault("client.proxy", defaults.Proxy)
+		v.SetDefault("client.ca_cert", defaults.CACert)
+		v.SetDefault("client.client_cert", defaults.ClientCert)
+		v.SetDefault("client.client_key", defaults.ClientKey)
+
+		if err := v.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				log.Printf("读取 HTTP 配置文件失败: %v", err)
+			}
+		}
+		httpConfig = v
+	})
+	return httpConfig
+}
+
+// LoadHttpClientConfig 从 HTTP 配置的 client 区块读取客户端配置
+func LoadHttpClientConfig() (*HttpClientConfig, error) {
+	cfg := DefaultHttpClientConfig()
+	if err := HttpConfig().UnmarshalKey("client", cfg); err != nil {
+		return nil, fmt.Errorf("解析 HTTP 客户端配置失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// DefaultHttpClient 返回按 HTTP 配置创建的共享客户端，配置无效时退回到只设置超时的客户端
+func DefaultHttpClient() *http.Client {
+	defaultHttpClientLock.Lock()
+	defer defaultHttpClientLock.Unlock()
+
+	if defaultHttpClient == nil {
+		cfg, err := LoadHttpClientConfig()
+		if err == nil {
+			defaultHttpClient, err = NewHttpClient(cfg)
+		}
+		if err != nil {
+			log.Printf("创建 HTTP 客户端失败，使用默认配置: %v", err)
+			defaultHttpClient = &http.Client{Timeout: time.Duration(DefaultHttpClientConfig().Timeout) * time.Second}
+		}
+	}
+	return defaultHttpClient
+}
+
+// SetDefaultHttpClient 替换共享客户端，传入 nil 时下次使用会按配置重新创建
+func SetDefaultHttpClient(client *http.Client) {
+	defaultHttpClientLock.Lock()
+	defer defaultHttpClientLock.Unlock()
+	defaultHttpClient = client
+}
+
+// DialContextFunc 建立网络连接的函数
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// HttpClientOption 创建HTTP客户端的可选项
+type HttpClientOption func(*httpClientOptions)
+
+type httpClientOptions struct {
+	wrapTransport func(http.RoundTripper) http.RoundTripper
+	wrapDial      func(DialContextFunc) DialContextFunc
+}
+
+// WithTransportWrapper 包装客户端的 RoundTripper，每次请求（包括每次重定向）都会经过包装后的 RoundTripper
+func WithTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) HttpClientOption {
+	return func(o *httpClientOptions) {
+		o.wrapTransport = wrap
+	}
+}
+
+// WithDialWrapper 包装建立连接的函数，可用于在连接前检查目标地址
+func WithDialWrapper(wrap func(DialContextFunc) DialContextFunc) HttpClientOption {
+	return func(o *httpClientOptions) {
+		o.wrapDial = wrap
+	}
+}
+
+// NewHttpClient 按配置创建HTTP客户端
+func NewHttpClient(cfg *HttpClientConfig, opts ...HttpClientOption) (*http.Client, error) {
+	if cfg == nil {
+		cfg = DefaultHttpClientConfig()
+	}
+	var options httpClientOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.wrapDial != nil {
+		transport.DialContext = options.wrapDial(transport.DialContext)
+	}
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址无效: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	var base http.RoundTripper = transport
+	if len(cfg.InsecureSkipVerify) > 0 {
+		insecure := transport.Clone()
+		insecure.TLSClientConfig.InsecureSkipVerify = true
+		base = &hostTransport{
+			secure:   transport,
+			insecure: insecure,
+			hosts:    cfg.InsecureSkipVerify,
+		}
+	}
+
+	var rt http.RoundTripper = &retryTransport{
+		base:    base,
+		retries: cfg.RetryCount,
+		wait:    time.Duration(cfg.RetryWaitMs) * time.Millisecond,
+	}
+	if options.wrapTransport != nil {
+		rt = options.wrapTransport(rt)
+	}
+
+	maxRedirects := cfg.MaxRedirects
+	return &http.Client{
+		Timeout:   time.Duration(cfg.Timeout) * time.Second,
+		Transport: rt,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			// 超过最大重定向次数时返回最后一次的重定向响应，而不是报错
+			if len(via) > maxRedirects {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}, nil
+}
+
+// newTLSConfig 加载自定义 CA 和 mTLS 客户端证书
+func newTLSConfig(cfg *HttpClientConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if cfg.CACert != "" {
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		caCert, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("CA 证书 %s 中没有有效的 PEM 证书", cfg.CACert)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// DialURL 按客户端配置建立到 target 的连接，用于 WebSocket 等不经过 http.Client 的协议
+//
+// 配置或环境变量中设置了代理时通过 HTTP CONNECT 隧道连接目标；https 目标使用与 NewHttpClient 相同的
+// ca_cert、client_cert/client_key 和 insecure_skip_verify 配置完成 TLS 握手。dial 用于建立 TCP 连接。
+func DialURL(ctx context.Context, cfg *HttpClientConfig, target *url.URL, dial DialContextFunc) (net.Conn, error) {
+	host := target.Hostname()
+	port := target.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[target.Scheme]
+	}
+	addr := net.JoinHostPort(host, port)
+
+	proxyURL, err := http.ProxyFromEnvironment(&http.Request{URL: target})
+	if cfg.Proxy != "" {
+		proxyURL, err = url.Parse(cfg.Proxy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("代理地址无效: %w", err)
+	}
+
+	var conn net.Conn
+	if proxyURL != nil {
+		conn, err = dialConnect(ctx, proxyURL, addr, dial)
+	} else {
+		conn, err = dial(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "https" {
+		return conn, nil
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConfig.ServerName = host
+	tlsConfig.InsecureSkipVerify = MatchHost(host, cfg.InsecureSkipVerify)
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// dialConnect 通过 HTTP 代理的 CONNECT 方法建立到 addr 的隧道
+func dialConnect(ctx context.Context, proxyURL *url.URL, addr string, dial DialContextFunc) (net.Conn, error) {
+	if proxyURL.Scheme != "http" {
+		return nil, fmt.Errorf("不支持的代理协议 %s，只支持 http 代理", proxyURL.Scheme)
+	}
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := dial(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		req.SetBasicAuth(u.Username(), password)
+		req.Header["Proxy-Authorization"] = req.Header["Authorization"]
+		req.Header.Del("Authorization")
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// 隧道建立前代理不会发送响应之外的数据，读取响应后可以直接使用原始连接
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("读取代理响应失败: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理拒绝建立隧道: %s", resp.Status)
+	}
+	return conn, nil
+}
+
+// hostTransport 对跳过列表中的主机使用不校验证书的连接，其余主机正常校验
+type hostTransport struct {
+	secure   http.RoundTripper
+	insecure http.RoundTripper
+	hosts    []string
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if MatchHost(req.URL.Hostname(), t.hosts) {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.secure.RoundTrip(req)
+}
+
+// MatchHost 判断主机名是否匹配列表中的任一规则，规则支持 *、*.example.com 和精确匹配
+func MatchHost(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "*" || p == host:
+			return true
+		case strings.HasPrefix(p, "*.") && strings.HasSuffix(host, p[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+// noRetryKey 上下文中标记请求不重试
+type noRetryKey struct{}
+
+// WithoutRetry 返回不重试的上下文，用于压测等需要如实反映每次请求结果的场景
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// retryTransport 对幂等请求在网络错误或 429/502/503/504 时按指数退避重试
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	wait    time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 请求体无法重放时不重试
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	noRetry, _ := req.Context().Value(noRetryKey{}).(bool)
+	if t.retries <= 0 || noRetry || !isIdempotent(req.Method) || !replayable {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			r = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if attempt >= t.retries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		log.Printf("HTTP %s %s 第 %d 次重试", req.Method, RedactURL(req.URL.String()), attempt+1)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.wait << attempt):
+		}
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package helper
+
+import (
+	"bufio"
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHttpClient_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client, err := NewHttpClient(&HttpClientConfig{RetryCount: 2, RetryWaitMs: 1, MaxRedirects: 10})
+	require.NoError(t, err)
+
+	t.Run("幂等请求重试直到成功", func(t *testing.T) {
+		calls.Store(0)
+		resp, err := DoHttpRequestWithClient(context.Background(), client, http.MethodGet, srv.URL, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "ok", string(resp.Body))
+		assert.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("带请求体的PUT重试时重放请求体", func(t *testing.T) {
+		calls.Store(0)
+		resp, err := DoHttpRequestWithClient(context.Background(), client, http.MethodPut, srv.URL, nil, strings.NewReader("data"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("POST不重试", func(t *testing.T) {
+		calls.Store(0)
+		resp, err := DoHttpRequestWithClient(context.Background(), client, http.MethodPost, srv.URL, nil, strings.NewReader("data"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("WithoutRetry不重试", func(t *testing.T) {
+		calls.Store(0)
+		resp, err := DoHttpRequestWithClient(WithoutRetry(context.Background()), client, http.MethodGet, srv.URL, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+}
+
+func TestNewHttpClient_MaxRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/final" {
+			w.Write([]byte("final"))
+			return
+		}
+		http.Redirect(w, r, "/final", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	t.Run("跟随重定向", func(t *testing.T) {
+		client, err := NewHttpClient(&HttpClientConfig{MaxRedirects: 1})
+		require.NoError(t, err)
+		resp, err := DoHttpRequestWithClient(context.Background(), client, http.MethodGet, srv.URL+"/start", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "final", string(resp.Body))
+	})
+
+	t.Run("不跟随重定向时返回重定向响应", func(t *testing.T) {
+		client, err := NewHttpClient(&HttpClientConfig{MaxRedirects: 0})
+		require.NoError(t, err)
+		resp, err := DoHttpRequestWithClient(context.Background(), client, http.MethodGet, srv.URL+"/start", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/final", resp.Header.Get("Location"))
+	})
+}
+
+func TestNewHttpClient_InsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secure"))
+	}))
+	defer srv.Close()
+
+	t.Run("未配置时校验失败", func(t *testing.T) {
+		client, err := NewHttpClient(DefaultHttpClientConfig())
+		require.NoError(t, err)
+		_, err = DoHttpRequestWithClient(context.Background(), client, http.MethodGet, srv.URL, nil, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("跳过列表中的主机不校验证书", func(t *testing.T) {
+		client, err := NewHttpClient(&HttpClientConfig{InsecureSkipVerify: []string{"127.0.0.1"}})
+		require.NoError(t, err)
+		resp, err := DoHttpRequestWithClient(context.Background(), client, http.MethodGet, srv.URL, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "secure", string(resp.Body))
+	})
+
+	t.Run("不在跳过列表中的主机仍然校验证书", func(t *testing.T) {
+		client, err := NewHttpClient(&HttpClientConfig{InsecureSkipVerify: []string{"*.internal"}})
+		require.NoError(t, err)
+		_, err = DoHttpRequestWithClient(context.Background(), client, http.MethodGet, srv.URL, nil, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestDialURL(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secure"))
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600))
+
+	// get 通过 conn 发送 GET 请求并返回响应体
+	get := func(t *testing.T, conn net.Conn) string {
+		t.Helper()
+		defer conn.Close()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.NoError(t, req.Write(conn))
+		resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	dial := (&net.Dialer{}).DialContext
+
+	t.Run("未配置 CA 时校验失败", func(t *testing.T) {
+		_, err := DialURL(context.Background(), &HttpClientConfig{}, target, dial)
+		require.Error(t, err)
+	})
+
+	t.Run("使用配置的 CA 证书", func(t *testing.T) {
+		conn, err := DialURL(context.Background(), &HttpClientConfig{CACert: caFile}, target, dial)
+		require.NoError(t, err)
+		assert.Equal(t, "secure", get(t, conn))
+	})
+
+	t.Run("通过 HTTP 代理建立隧道", func(t *testing.T) {
+		var connects atomic.Int32
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodConnect || r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNz" {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+			connects.Add(1)
+			upstream, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+			go func() {
+				io.Copy(upstream, conn)
+				upstream.Close()
+			}()
+			io.Copy(conn, upstream)
+			conn.Close()
+		}))
+		defer proxy.Close()
+
+		cfg := &HttpClientConfig{Proxy: "http://user:pass@" + strings.TrimPrefix(proxy.URL, "http://"), InsecureSkipVerify: []string{"127.0.0.1"}}
+		conn, err := DialURL(context.Background(), cfg, target, dial)
+		require.NoError(t, err)
+		assert.Equal(t, "secure", get(t, conn))
+		assert.Equal(t, int32(1), connects.Load())
+
+		cfg.Proxy = proxy.URL
+		_, err = DialURL(context.Background(), cfg, target, dial)
+		assert.ErrorContains(t, err, "代理拒绝建立隧道: 407")
+	})
+}
+
+func TestMatchHost(t *testing.T) {
+	patterns := []string{"dev.example.com", "*.test.local"}
+	assert.True(t, MatchHost("dev.example.com", patterns))
+	assert.True(t, MatchHost("DEV.example.com", patterns))
+	assert.True(t, MatchHost("api.test.local", patterns))
+	assert.False(t, MatchHost("test.local", patterns))
+	assert.False(t, MatchHost("example.com", patterns))
+	assert.True(t, MatchHost("anything", []string{"*"}))
+}
@@ -0,0 +1,243 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// URLPolicyConfig 出站请求策略配置，对应 http.yaml 的 policy 区块
+type URLPolicyConfig struct {
+	AllowHosts          []string `mapstructure:"allow_hosts" json:"allow_hosts" yaml:"allow_hosts"`                               // 非空时只允许访问这些主机，支持 *.example.com
+	DenyHosts           []string `mapstructure:"deny_hosts" json:"deny_hosts" yaml:"deny_hosts"`                                  // 禁止访问的主机
+	AllowCIDRs          []string `mapstructure:"allow_cidrs" json:"allow_cidrs" yaml:"allow_cidrs"`                               // 允许访问的地址段，优先于默认禁止规则
+	DenyCIDRs           []string `mapstructure:"deny_cidrs" json:"deny_cidrs" yaml:"deny_cidrs"`                                  // 禁止访问的地址段
+	BlockPrivate        bool     `mapstructure:"block_private" json:"block_private" yaml:"block_private"`                         // 是否同时禁止内网地址（10/8、172.16/12、192.168/16、fc00::/7）
+	DisableDefaultBlock bool     `mapstructure:"disable_default_block" json:"disable_default_block" yaml:"disable_default_block"` // 关闭对回环、链路本地和云元数据地址的默认禁止
+}
+
+// URLPolicy 出站请求策略，在 DNS 解析后、每次重定向以及建立连接时检查目标地址
+type URLPolicy struct {
+	allowHosts   []string
+	denyHosts    []string
+	allowCIDRs   []netip.Prefix
+	denyCIDRs    []netip.Prefix
+	blockPrivate bool
+	defaultBlock bool
+
+	// Lookup 解析主机名，默认使用系统解析器，测试中可替换
+	Lookup func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+// PolicyError 请求被策略拒绝
+type PolicyError struct {
+	Rule   string // 命中的规则
+	Target string // 被拒绝的主机或地址
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("请求被出站策略拒绝: 规则 %s 禁止访问 %s", e.Rule, e.Target)
+}
+
+// defaultBlockedCIDRs 默认禁止的地址段
+var defaultBlockedCIDRs = []struct {
+	rule   string
+	prefix netip.Prefix
+}{
+	{"default:metadata", netip.MustParsePrefix("169.254.169.254/32")},
+	{"default:metadata", netip.MustParsePrefix("100.100.100.200/32")},
+	{"default:metadata", netip.MustParsePrefix("fd00:ec2::254/128")},
+	{"default:loopback", netip.MustParsePrefix("127.0.0.0/8")},
+	{"default:loopback", netip.MustParsePrefix("::1/128")},
+	{"default:link-local", netip.MustParsePrefix("169.254.0.0/16")},
+	{"default:link-local", netip.MustParsePrefix("fe80::/10")},
+	{"default:unspecified", netip.MustParsePrefix("0.0.0.0/8")},
+	{"default:unspecified", netip.MustParsePrefix("::/128")},
+}
+
+// defaultBlockedHosts 默认禁止的云元数据主机名
+var defaultBlockedHosts = []string{"metadata.google.internal", "metadata.goog"}
+
+// NewURLPolicy 根据配置创建出站策略
+func NewURLPolicy(cfg *URLPolicyConfig) (*URLPolicy, error) {
+	if cfg == nil {
+		cfg = &URLPolicyConfig{}
+	}
+	p := &URLPolicy{
+		allowHosts:   cfg.AllowHosts,
+		denyHosts:    cfg.DenyHosts,
+		blockPrivate: cfg.BlockPrivate,
+		defaultBlock: !cfg.DisableDefaultBlock,
+		Lookup: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+	var err error
+	if p.allowCIDRs, err = parsePrefixes(cfg.AllowCIDRs); err != nil {
+		return nil, err
+	}
+	if p.denyCIDRs, err = parsePrefixes(cfg.DenyCIDRs); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetURLPolicy 返回 http.yaml 中配置的出站策略
+var GetURLPolicy = sync.OnceValue(func() *URLPolicy {
+	cfg := &URLPolicyConfig{}
+	if err := HttpConfig().UnmarshalKey("policy", cfg); err != nil {
+		log.Printf("解析出站策略配置失败，使用默认策略: %v", err)
+		cfg = &URLPolicyConfig{}
+	}
+	policy, err := NewURLPolicy(cfg)
+	if err != nil {
+		log.Printf("出站策略配置无效，使用默认策略: %v", err)
+		policy, _ = NewURLPolicy(nil)
+	}
+	return policy
+})
+
+// CheckURL 检查 URL 的协议和主机，并在 DNS 解析后检查所有地址
+func (p *URLPolicy) CheckURL(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &PolicyError{Rule: "scheme", Target: u.Scheme + "://"}
+	}
+	return p.CheckHost(ctx, u.Hostname())
+}
+
+// CheckHost 检查主机名，并在 DNS 解析后检查所有地址
+func (p *URLPolicy) CheckHost(ctx context.Context, host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if err := p.checkHostname(host); err != nil {
+		return err
+	}
+
+	addrs, err := p.resolve(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := p.CheckAddr(addr); err != nil {
+			err.(*PolicyError).Target = fmt.Sprintf("%s (%s)", addr, host)
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckAddr 检查单个 IP 地址
+func (p *URLPolicy) CheckAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range p.denyCIDRs {
+		if prefix.Contains(addr) {
+			return &PolicyError{Rule: "deny_cidrs:" + prefix.String(), Target: addr.String()}
+		}
+	}
+	for _, prefix := range p.allowCIDRs {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if p.defaultBlock {
+		for _, blocked := range defaultBlockedCIDRs {
+			if blocked.prefix.Contains(addr) {
+				return &PolicyError{Rule: blocked.rule, Target: addr.String()}
+			}
+		}
+	}
+	if p.blockPrivate && addr.IsPrivate() {
+		return &PolicyError{Rule: "block_private", Target: addr.String()}
+	}
+	return nil
+}
+
+// checkHostname 按主机名检查 deny_hosts、allow_hosts 和默认禁止的元数据主机
+func (p *URLPolicy) checkHostname(host string) error {
+	for _, pattern := range p.denyHosts {
+		if MatchHost(host, []string{pattern}) {
+			return &PolicyError{Rule: "deny_hosts:" + pattern, Target: host}
+		}
+	}
+	if len(p.allowHosts) > 0 && !MatchHost(host, p.allowHosts) {
+		return &PolicyError{Rule: "allow_hosts", Target: host}
+	}
+	if p.defaultBlock && MatchHost(host, defaultBlockedHosts) {
+		return &PolicyError{Rule: "default:metadata", Target: host}
+	}
+	return nil
+}
+
+// resolve 返回主机对应的地址，IP 字面量直接返回
+func (p *URLPolicy) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+	addrs, err := p.Lookup(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("解析主机 %s 失败: %w", host, err)
+	}
+	return addrs, nil
+}
+
+// PolicyDialer 在建立连接前重新解析并检查地址，连接到检查过的地址，防止 DNS 重绑定；代理地址不做检查
+func PolicyDialer(policy func() *URLPolicy, proxies map[string]bool) func(DialContextFunc) DialContextFunc {
+	return func(dial DialContextFunc) DialContextFunc {
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if proxies[addr] {
+				return dial(ctx, network, addr)
+			}
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			p := policy()
+			addrs, err := p.resolve(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			var lastErr error
+			for _, ip := range addrs {
+				if err := p.CheckAddr(ip); err != nil {
+					lastErr = err
+					continue
+				}
+				conn, err := dial(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			if lastErr == nil {
+				lastErr = fmt.Errorf("主机 %s 没有可用地址", host)
+			}
+			return nil, lastErr
+		}
+	}
+}
+
+// parsePrefixes 解析地址段列表，单个地址视为只包含该地址的地址段
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("无效的地址段 %q: %w", s, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的地址段 %q: %w", s, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPolicy 创建出站策略，hosts 不为空时用固定的解析结果代替 DNS
+func newTestPolicy(t *testing.T, cfg *URLPolicyConfig, hosts map[string][]string) *URLPolicy {
+	t.Helper()
+	policy, err := NewURLPolicy(cfg)
+	require.NoError(t, err)
+	if hosts != nil {
+		policy.Lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+			var addrs []netip.Addr
+			for _, ip := range hosts[host] {
+				addrs = append(addrs, netip.MustParseAddr(ip))
+			}
+			return addrs, nil
+		}
+	}
+	return policy
+}
+
+func TestURLPolicy_CheckURL(t *testing.T) {
+	hosts := map[string][]string{
+		"api.example.com":      {"93.184.216.34"},
+		"internal.example.com": {"10.0.0.5"},
+		"rebind.example.com":   {"93.184.216.34", "169.254.169.254"},
+		"localhost":            {"127.0.0.1", "::1"},
+		"db.corp.com":          {"10.1.2.3"},
+	}
+
+	tests := []struct {
+		name string
+		cfg  *URLPolicyConfig
+		url  string
+		rule string // 为空表示放行
+	}{
+		{"公网地址放行", nil, "https://api.example.com/v1", ""},
+		{"内网地址默认放行", nil, "http://internal.example.com", ""},
+		{"元数据地址", nil, "http://169.254.169.254/latest/meta-data/", "default:metadata"},
+		{"元数据主机名", nil, "http://metadata.google.internal/computeMetadata/v1/", "default:metadata"},
+		{"回环地址", nil, "http://127.0.0.1:8080/admin", "default:loopback"},
+		{"IPv6 回环地址", nil, "http://[::1]:8080/", "default:loopback"},
+		{"解析到回环地址的主机名", nil, "http://localhost:9200/", "default:loopback"},
+		{"任一解析地址被禁止即拒绝", nil, "http://rebind.example.com/", "default:metadata"},
+		{"链路本地地址", nil, "http://169.254.1.1/", "default:link-local"},
+		{"IPv4 映射的 IPv6 地址", nil, "http://[::ffff:127.0.0.1]/", "default:loopback"},
+		{"非 HTTP 协议", nil, "file:///etc/passwd", "scheme"},
+		{"禁止内网", &URLPolicyConfig{BlockPrivate: true}, "http://internal.example.com", "block_private"},
+		{"allow_cidrs 优先于默认规则", &URLPolicyConfig{AllowCIDRs: []string{"127.0.0.1/32"}}, "http://127.0.0.1/", ""},
+		{"deny_cidrs", &URLPolicyConfig{DenyCIDRs: []string{"10.0.0.0/8"}}, "http://internal.example.com", "deny_cidrs:10.0.0.0/8"},
+		{"deny_hosts", &URLPolicyConfig{DenyHosts: []string{"*.corp.com"}}, "http://db.corp.com", "deny_hosts:*.corp.com"},
+		{"不在 allow_hosts 中", &URLPolicyConfig{AllowHosts: []string{"api.example.com"}}, "http://internal.example.com", "allow_hosts"},
+		{"在 allow_hosts 中", &URLPolicyConfig{AllowHosts: []string{"*.example.com"}}, "https://api.example.com", ""},
+		{"关闭默认规则", &URLPolicyConfig{DisableDefaultBlock: true}, "http://127.0.0.1/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newTestPolicy(t, tt.cfg, hosts)
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			err = policy.CheckURL(context.Background(), u)
+			if tt.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var policyErr *PolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, tt.rule, policyErr.Rule)
+		})
+	}
+}
+
+func TestPolicyDialer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	// 绕过 URL 检查，模拟 DNS 重绑定：连接时重新解析到被禁止的地址
+	policy := newTestPolicy(t, nil, map[string][]string{"rebind.test": {"127.0.0.1"}})
+	client, err := NewHttpClient(&HttpClientConfig{},
+		WithDialWrapper(PolicyDialer(func() *URLPolicy { return policy }, nil)))
+	require.NoError(t, err)
+
+	_, err = client.Get("http://rebind.test:" + u.Port() + "/")
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, "default:loopback", policyErr.Rule)
+
+	// 代理地址不受限制
+	client, err = NewHttpClient(&HttpClientConfig{},
+		WithDialWrapper(PolicyDialer(func() *URLPolicy { return policy }, map[string]bool{u.Host: true})))
+	require.NoError(t, err)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
@@ -0,0 +1,41 @@
+package helper
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// NotifyProgress 向客户端发送 notifications/progress 进度通知
+//
+// 参数:
+//
+//	ctx:      context.Context      工具处理函数的上下文
+//	request:  mcp.CallToolRequest  工具调用请求，用于获取 progressToken
+//	progress: float64              当前进度
+//	total:    float64              总量，未知时传 0
+//	message:  string               进度描述，可为空
+//
+// 请求未携带 progressToken 或不在 MCP 会话中时不发送任何通知
+func NotifyProgress(ctx context.Context, request mcp.CallToolRequest, progress, total float64, message string) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+
+	params := map[string]any{
+		"progressToken": request.Params.Meta.ProgressToken,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	_ = srv.SendNotificationToClient(ctx, "notifications/progress", params)
+}
@@ -0,0 +1,288 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactMask 替换敏感值的掩码
+const RedactMask = "***"
+
+// RedactConfig 日志脱敏配置，配置的名称会追加到默认列表中，名称不区分大小写
+type RedactConfig struct {
+	Headers     []string `mapstructure:"headers" json:"headers" yaml:"headers"`                // 需要脱敏的请求头/响应头
+	QueryParams []string `mapstructure:"query_params" json:"query_params" yaml:"query_params"` // 需要脱敏的 URL 查询参数
+	JSONKeys    []string `mapstructure:"json_keys" json:"json_keys" yaml:"json_keys"`          // 需要脱敏的 JSON 字段
+}
+
+// DefaultRedactConfig 返回默认的脱敏配置
+func DefaultRedactConfig() *RedactConfig {
+	return &RedactConfig{
+		Headers: []string{
+			"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+			"X-Api-Key", "X-Auth-Token", "X-Access-Token", "X-Signature", "X-Csrf-Token",
+		},
+		QueryParams: []string{
+			"token", "access_token", "refresh_token", "id_token", "api_key", "apikey",
+			"key", "secret", "client_secret", "password", "signature", "sig",
+		},
+		JSONKeys: []string{
+			"token", "access_token", "refresh_token", "id_token", "api_key", "apikey",
+			"secret", "client_secret", "password", "passwd", "authorization", "cookie",
+			"private_key", "credentials",
+		},
+	}
+}
+
+// Redactor 对日志中的请求头、URL 和请求体做脱敏
+type Redactor struct {
+	headers     map[string]bool
+	queryParams map[string]bool
+	jsonKeys    map[string]bool // 包含 JSON 字段和请求头名称，JSON 中的请求头对象同样需要脱敏
+
+	textPatterns []*regexp.Regexp
+}
+
+var (
+	defaultRedactor     *Redactor
+	defaultRedactorLock sync.Mutex
+	redactExtra         RedactConfig // AddRedactNames 追加的名称
+)
+
+// NewRedactor 根据配置创建脱敏器，配置项追加到默认列表中
+func NewRedactor(cfg *RedactConfig) *Redactor {
+	defaults := DefaultRedactConfig()
+	if cfg == nil {
+		cfg = &RedactConfig{}
+	}
+
+	r := &Redactor{
+		headers:     toLowerSet(defaults.Headers, cfg.Headers),
+		queryParams: toLowerSet(defaults.QueryParams, cfg.QueryParams),
+		jsonKeys:    toLowerSet(defaults.JSONKeys, cfg.JSONKeys, defaults.Headers, cfg.Headers),
+	}
+
+	// 文本中的 "Header: value"、"?param=value" 和 "\"key\": \"value\"" 三种形式，
+	// 同时兼容 JSON 字符串中转义后的 \n 和 \" 。
+	r.textPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)((?:^|\\[nr]|[^\w-])(?:` + quoteNames(r.headers) + `)\s*:\s*)[^\r\n'"\\]+`),
+		regexp.MustCompile(`(?i)((?:^|[?&\s])(?:` + quoteNames(r.queryParams) + `)=)[^&#\s'"\\]+`),
+		regexp.MustCompile(`(?i)(\\?"(?:` + quoteNames(r.jsonKeys) + `)\\?"\s*:\s*)(\\?"(?:[^"\\]|\\[^"])*\\?"|[^,}\]\s\\]+)`),
+	}
+	return r
+}
+
+// DefaultRedactor 返回按 HTTP 配置 redact 区块创建的共享脱敏器，包含 AddRedactNames 追加的名称
+func DefaultRedactor() *Redactor {
+	defaultRedactorLock.Lock()
+	defer defaultRedactorLock.Unlock()
+
+	if defaultRedactor == nil {
+		cfg := &RedactConfig{}
+		if err := HttpConfig().UnmarshalKey("redact", cfg); err != nil {
+			log.Printf("解析脱敏配置失败，使用默认配置: %v", err)
+			cfg = &RedactConfig{}
+		}
+		cfg.Headers = append(cfg.Headers, redactExtra.Headers...)
+		cfg.QueryParams = append(cfg.QueryParams, redactExtra.QueryParams...)
+		cfg.JSONKeys = append(cfg.JSONKeys, redactExtra.JSONKeys...)
+		defaultRedactor = NewRedactor(cfg)
+	}
+	return defaultRedactor
+}
+
+// AddRedactNames 向共享脱敏器追加名称，用于认证配置等运行时才确定的请求头和查询参数
+//
+// 名称都已在脱敏列表中时直接返回，否则下次使用时重新创建脱敏器。
+func AddRedactNames(cfg *RedactConfig) {
+	if DefaultRedactor().covers(cfg) {
+		return
+	}
+	defaultRedactorLock.Lock()
+	defer defaultRedactorLock.Unlock()
+	redactExtra.Headers = append(redactExtra.Headers, cfg.Headers...)
+	redactExtra.QueryParams = append(redactExtra.QueryParams, cfg.QueryParams...)
+	redactExtra.JSONKeys = append(redactExtra.JSONKeys, cfg.JSONKeys...)
+	defaultRedactor = nil
+}
+
+// covers 判断配置中的名称是否都已在脱敏列表中
+func (r *Redactor) covers(cfg *RedactConfig) bool {
+	for _, check := range []struct {
+		set   map[string]bool
+		names []string
+	}{
+		{r.headers, cfg.Headers},
+		{r.queryParams, cfg.QueryParams},
+		{r.jsonKeys, cfg.JSONKeys},
+	} {
+		for name := range toLowerSet(check.names) {
+			if !check.set[name] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// RedactHeader 返回脱敏后的请求头副本
+func (r *Redactor) RedactHeader(header http.Header) http.Header {
+	out := header.Clone()
+	for k, v := range out {
+		if r.headers[strings.ToLower(k)] {
+			masked := make([]string, len(v))
+			for i := range v {
+				masked[i] = RedactMask
+			}
+			out[k] = masked
+		}
+	}
+	return out
+}
+
+// RedactURL 返回查询参数和用户密码脱敏后的 URL，无法解析时按文本脱敏
+func (r *Redactor) RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return r.RedactText(rawURL)
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = r.redactQuery(u.RawQuery)
+	}
+	return u.String()
+}
+
+// RedactBody 对请求体或响应体脱敏，JSON 按字段脱敏，其他内容按文本脱敏
+func (r *Redactor) RedactBody(body []byte) string {
+	// 数字保留为 json.Number，避免日志中的大整数丢失精度
+	var data any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err == nil && dec.InputOffset() == int64(len(bytes.TrimRight(body, " \t\r\n"))) {
+		if _, ok := data.(string); !ok {
+			out, err := json.Marshal(r.redactJSON(data))
+			if err == nil {
+				return string(out)
+			}
+		}
+	}
+	return r.RedactText(string(body))
+}
+
+// RedactText 对文本中的请求头、查询参数和 JSON 字段脱敏，用于 curl 命令、SSE 消息等非结构化内容
+func (r *Redactor) RedactText(s string) string {
+	for _, re := range r.textPatterns {
+		s = re.ReplaceAllString(s, "${1}"+RedactMask)
+	}
+	return s
+}
+
+// RedactError 对 *url.Error 中的 URL 脱敏，避免错误信息带出查询参数中的令牌
+func (r *Redactor) RedactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = r.RedactURL(urlErr.URL)
+	}
+	return err
+}
+
+// redactQuery 对查询字符串脱敏，保持参数顺序
+func (r *Redactor) redactQuery(rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		key, _, ok := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if ok && r.queryParams[strings.ToLower(name)] {
+			parts[i] = key + "=" + RedactMask
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func (r *Redactor) redactJSON(data any) any {
+	switch v := data.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if r.jsonKeys[strings.ToLower(k)] {
+				v[k] = RedactMask
+				continue
+			}
+			v[k] = r.redactJSON(val)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = r.redactJSON(item)
+		}
+		return v
+	case string:
+		return r.RedactText(v)
+	}
+	return data
+}
+
+// RedactHeader 使用默认脱敏器对请求头脱敏
+func RedactHeader(header http.Header) http.Header {
+	return DefaultRedactor().RedactHeader(header)
+}
+
+// RedactURL 使用默认脱敏器对 URL 脱敏
+func RedactURL(rawURL string) string {
+	return DefaultRedactor().RedactURL(rawURL)
+}
+
+// RedactBody 使用默认脱敏器对请求体或响应体脱敏
+func RedactBody(body []byte) string {
+	return DefaultRedactor().RedactBody(body)
+}
+
+// RedactText 使用默认脱敏器对文本脱敏
+func RedactText(s string) string {
+	return DefaultRedactor().RedactText(s)
+}
+
+// RedactError 使用默认脱敏器对错误中的 URL 脱敏
+func RedactError(err error) error {
+	return DefaultRedactor().RedactError(err)
+}
+
+func toLowerSet(lists ...[]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, name := range list {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				set[name] = true
+			}
+		}
+	}
+	return set
+}
+
+// quoteNames 将名称集合转为正则分支，长名称优先匹配
+func quoteNames(set map[string]bool) string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return strings.Join(names, "|")
+}
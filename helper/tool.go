@@ -12,6 +12,13 @@ import (
 	"strings"
 )
 
+// HttpResponse 表示 HTTP 响应结果
+type HttpResponse struct {
+	StatusCode int         // HTTP响应的状态码
+	Header     http.Header // HTTP响应头
+	Body       []byte      // HTTP响应的内容
+}
+
 // HttpRequest 函数用于发起HTTP请求
 //
 // 参数:
@@ -28,32 +35,100 @@ import (
 //	[]byte HTTP响应的内容
 //	error  可能发生的错误
 func HttpRequest(ctx context.Context, method, url string, headers map[string]string, body string) (int, []byte, error) {
-	log.Printf("\n\n\tMethod: %s \n\tUrl: %s \n\tHeaders: %v \n\tBody: %s\n\n", method, url, headers, body)
-	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	header := make(http.Header, len(headers))
+	for k, v := range headers {
+		header.Add(k, v)
+	}
+
+	resp, err := DoHttpRequest(ctx, method, url, header, strings.NewReader(body))
 	if err != nil {
 		return 0, nil, err
 	}
+	return resp.StatusCode, resp.Body, nil
+}
 
-	// 添加请求头
-	for k, v := range headers {
-		req.Header.Add(k, v)
-	}
+// DoHttpRequest 函数用于发起HTTP请求，支持多值请求头并返回响应头
+//
+// 参数:
+//
+//	ctx:    context.Context 用于控制请求的上下文
+//	method: string          HTTP请求的方法（如GET, POST等）
+//	url:    string          请求的URL
+//	header: http.Header     请求的HTTP头信息，同名请求头可以有多个值
+//	body:   io.Reader       请求体内容，可为 nil
+//
+// 返回值:
+//
+//	*HttpResponse HTTP响应结果
+//	error         可能发生的错误
+func DoHttpRequest(ctx context.Context, method, url string, header http.Header, body io.Reader) (*HttpResponse, error) {
+	return DoHttpRequestWithClient(ctx, DefaultHttpClient(), method, url, header, body)
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// DoHttpRequestWithClient 使用指定的客户端发起HTTP请求，参数与 DoHttpRequest 相同
+func DoHttpRequestWithClient(ctx context.Context, client *http.Client, method, url string, header http.Header, body io.Reader) (*HttpResponse, error) {
+	resp, err := OpenHttpStream(ctx, client, method, url, header, body)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
+	}
+
+	log.Printf("Code: %d Body: %s\n", resp.StatusCode, RedactBody(responseBody))
+
+	return &HttpResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       responseBody,
+	}, nil
+}
+
+// OpenHttpStream 使用指定的客户端发起HTTP请求，返回未读取响应体的响应，参数与 DoHttpRequest 相同
+//
+// 用于 Server-Sent Events、分块传输等不会很快结束的响应，调用方负责读取并关闭 resp.Body。
+func OpenHttpStream(ctx context.Context, client *http.Client, method, url string, header http.Header, body io.Reader) (*http.Response, error) {
+	log.Printf("\n\n\tMethod: %s \n\tUrl: %s \n\tHeaders: %v \n\tBody: %s\n\n", method, RedactURL(url), RedactHeader(header), logBody(body))
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 添加请求头
+	for k, v := range header {
+		for _, vv := range v {
+			req.Header.Add(k, vv)
+		}
+	}
+	if host := header.Get("Host"); host != "" {
+		req.Host = host
 	}
 
-	log.Printf("Code: %d Body: %s\n", resp.StatusCode, string(responseBody))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, RedactError(err)
+	}
+	return resp, nil
+}
 
-	return resp.StatusCode, responseBody, nil
+// logBody 返回脱敏后的请求体用于日志；只有 strings.Reader 和 bytes.Reader 可以在不消费内容的情况下读取，
+// 其他类型（如 multipart 管道、文件）只记录类型
+func logBody(body io.Reader) string {
+	switch r := body.(type) {
+	case nil:
+		return ""
+	case interface {
+		io.ReaderAt
+		Size() int64
+	}:
+		data, _ := io.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+		return RedactBody(data)
+	default:
+		return fmt.Sprintf("(%T)", body)
+	}
 }
 
 // GetConfigDir 根据工具名称获取配置目录路径
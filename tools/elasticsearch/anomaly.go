@@ -0,0 +1,292 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cast"
+)
+
+// sparkTicks 用于绘制 sparkline 的字符，从低到高
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// VolumeBucket 表示 date_histogram 中的一个时间桶
+type VolumeBucket struct {
+	Time     string     `json:"time"`
+	Count    int64      `json:"count"`
+	Score    float64    `json:"score"`              // 修正 z-score，基于 median/MAD
+	TopTerms []TermStat `json:"topTerms,omitempty"` // 异常桶中的高频词项
+}
+
+// TermStat 表示 terms 聚合中的一个词项
+type TermStat struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// VolumeAnomaliesTool 基于 date_histogram 检测日志量突变
+func VolumeAnomaliesTool(s *server.MCPServer) {
+	tool := mcp.NewTool("es_volume_anomalies",
+		mcp.WithDescription(`Detect volume spikes and drops of an index over a time window. Runs a date_histogram, computes a median/MAD baseline, flags deviating buckets and returns a sparkline summary plus the top terms of a chosen field in the anomalous buckets.`),
+		mcp.WithString("index",
+			mcp.Required(),
+			mcp.MinLength(1),
+			mcp.Description("Name or pattern of the Elasticsearch index to analyze"),
+		),
+		mcp.WithString("timeField",
+			mcp.DefaultString("@timestamp"),
+			mcp.Description("Date field used by the histogram"),
+		),
+		mcp.WithString("interval",
+			mcp.DefaultString("1m"),
+			mcp.Description("Fixed bucket interval, e.g. 30s, 1m, 5m, 1h"),
+		),
+		mcp.WithString("from",
+			mcp.DefaultString("now-1h"),
+			mcp.Description("Start of the time window, date math is supported"),
+		),
+		mcp.WithString("to",
+			mcp.DefaultString("now"),
+			mcp.Description("End of the time window, date math is supported"),
+		),
+		mcp.WithObject("filter",
+			mcp.Description("Optional query DSL clause used as an additional filter, e.g. {\"term\": {\"level\": \"error\"}}"),
+		),
+		mcp.WithString("termsField",
+			mcp.Description("Optional keyword field whose top terms are reported for anomalous buckets"),
+		),
+		mcp.WithNumber("threshold",
+			mcp.DefaultNumber(3.5),
+			mcp.Description("Modified z-score above which a bucket is flagged"),
+		),
+	)
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultErrorFromErr("client is not initialized", nil), nil
+		}
+
+		args := request.GetArguments()
+		index := cast.ToString(args["index"])
+		timeField := stringOr(args["timeField"], "@timestamp")
+		interval := stringOr(args["interval"], "1m")
+		from := stringOr(args["from"], "now-1h")
+		to := stringOr(args["to"], "now")
+		termsField := cast.ToString(args["termsField"])
+		threshold := cast.ToFloat64(args["threshold"])
+		if threshold <= 0 {
+			threshold = 3.5
+		}
+		filter, _ := args["filter"].(map[string]any)
+
+		query := buildVolumeQuery(timeField, interval, from, to, filter, termsField)
+		aggs, err := client.Aggregate(index, query)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("es_volume_anomalies tool failed", err), nil
+		}
+
+		buckets := parseVolumeBuckets(aggs)
+		if len(buckets) == 0 {
+			return mcp.NewToolResultText("No documents found in the time window"), nil
+		}
+
+		median, mad := scoreBuckets(buckets)
+		anomalies := make([]VolumeBucket, 0)
+		for _, b := range buckets {
+			if math.Abs(b.Score) >= threshold {
+				anomalies = append(anomalies, b)
+			}
+		}
+
+		return mcp.NewToolResultText(formatVolumeSummary(index, interval, buckets, anomalies, median, mad)), nil
+	}
+	s.AddTool(tool, handler)
+}
+
+// buildVolumeQuery 构建 date_histogram 聚合查询
+func buildVolumeQuery(timeField, interval, from, to string, filter map[string]any, termsField string) map[string]any {
+	filters := []any{
+		map[string]any{
+			"range": map[string]any{
+				timeField: map[string]any{"gte": from, "lte": to},
+			},
+		},
+	}
+	if len(filter) > 0 {
+		filters = append(filters, filter)
+	}
+
+	histogram := map[string]any{
+		"date_histogram": map[string]any{
+			"field":           timeField,
+			"fixed_interval":  interval,
+			"min_doc_count":   0,
+			"extended_bounds": map[string]any{"min": from, "max": to},
+		},
+	}
+	if termsField != "" {
+		histogram["aggs"] = map[string]any{
+			"top_terms": map[string]any{
+				"terms": map[string]any{"field": termsField, "size": 5},
+			},
+		}
+	}
+
+	return map[string]any{
+		"size":  0,
+		"query": map[string]any{"bool": map[string]any{"filter": filters}},
+		"aggs":  map[string]any{"volume": histogram},
+	}
+}
+
+// parseVolumeBuckets 解析 volume 聚合结果
+func parseVolumeBuckets(aggs map[string]any) []VolumeBucket {
+	volume, _ := aggs["volume"].(map[string]any)
+	raw, _ := volume["buckets"].([]any)
+
+	buckets := make([]VolumeBucket, 0, len(raw))
+	for _, r := range raw {
+		b, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		bucket := VolumeBucket{
+			Time:  cast.ToString(b["key_as_string"]),
+			Count: cast.ToInt64(b["doc_count"]),
+		}
+		if bucket.Time == "" {
+			bucket.Time = time.UnixMilli(cast.ToInt64(b["key"])).UTC().Format(time.RFC3339)
+		}
+		if terms, ok := b["top_terms"].(map[string]any); ok {
+			termBuckets, _ := terms["buckets"].([]any)
+			for _, tb := range termBuckets {
+				if t, ok := tb.(map[string]any); ok {
+					bucket.TopTerms = append(bucket.TopTerms, TermStat{
+						Key:   cast.ToString(t["key"]),
+						Count: cast.ToInt64(t["doc_count"]),
+					})
+				}
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// scoreBuckets 计算基线（median/MAD）并为每个桶打分
+//
+// 使用修正 z-score: 0.6745 * (x - median) / MAD。
+// MAD 为 0 时（超过一半的桶等于中位数，如大部分为 0 的稀疏错误日志）改用平均绝对偏差：(x - median) / (1.2533 * MeanAD)，
+// 平均绝对偏差也为 0 时基线完全平稳，任何偏离基线的桶都视为异常。
+func scoreBuckets(buckets []VolumeBucket) (median, mad float64) {
+	counts := make([]float64, len(buckets))
+	for i, b := range buckets {
+		counts[i] = float64(b.Count)
+	}
+	median = medianOf(counts)
+
+	deviations := make([]float64, len(counts))
+	var meanAD float64
+	for i, c := range counts {
+		deviations[i] = math.Abs(c - median)
+		meanAD += deviations[i]
+	}
+	mad = medianOf(deviations)
+	if len(deviations) > 0 {
+		meanAD /= float64(len(deviations))
+	}
+
+	for i := range buckets {
+		diff := counts[i] - median
+		switch {
+		case diff == 0:
+			buckets[i].Score = 0
+		case mad != 0:
+			buckets[i].Score = 0.6745 * diff / mad
+		case meanAD != 0:
+			buckets[i].Score = diff / (1.2533 * meanAD)
+		default:
+			buckets[i].Score = math.Copysign(math.Inf(1), diff)
+		}
+	}
+	return median, mad
+}
+
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// sparkline 将桶计数绘制为一行字符
+func sparkline(buckets []VolumeBucket) string {
+	var max int64
+	for _, b := range buckets {
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+
+	var sb strings.Builder
+	for _, b := range buckets {
+		idx := 0
+		if max > 0 {
+			idx = int(float64(b.Count) / float64(max) * float64(len(sparkTicks)-1))
+		}
+		sb.WriteRune(sparkTicks[idx])
+	}
+	return sb.String()
+}
+
+func formatVolumeSummary(index, interval string, buckets, anomalies []VolumeBucket, median, mad float64) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Index %s: %d buckets of %s from %s to %s\n", index, len(buckets), interval, buckets[0].Time, buckets[len(buckets)-1].Time)
+	fmt.Fprintf(&sb, "Baseline: median=%.1f MAD=%.1f\n", median, mad)
+	fmt.Fprintf(&sb, "Volume: %s\n", sparkline(buckets))
+
+	if len(anomalies) == 0 {
+		sb.WriteString("\nNo anomalous buckets found")
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "\nFound %d anomalous buckets:\n", len(anomalies))
+	for _, a := range anomalies {
+		direction := "spike"
+		if a.Score < 0 {
+			direction = "drop"
+		}
+		score := fmt.Sprintf("%+.1f", a.Score)
+		if math.IsInf(a.Score, 0) {
+			score = "inf"
+		}
+		fmt.Fprintf(&sb, "  %s count=%d score=%s %s\n", a.Time, a.Count, score, direction)
+		if direction == "spike" && len(a.TopTerms) > 0 {
+			terms := make([]string, 0, len(a.TopTerms))
+			for _, t := range a.TopTerms {
+				terms = append(terms, fmt.Sprintf("%s(%d)", t.Key, t.Count))
+			}
+			fmt.Fprintf(&sb, "    top terms: %s\n", strings.Join(terms, ", "))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// stringOr 返回参数的字符串值，为空时返回默认值
+func stringOr(v any, def string) string {
+	if s := cast.ToString(v); s != "" {
+		return s
+	}
+	return def
+}
@@ -0,0 +1,79 @@
+package elasticsearch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreBuckets(t *testing.T) {
+	buckets := []VolumeBucket{
+		{Count: 10}, {Count: 12}, {Count: 11}, {Count: 9}, {Count: 10}, {Count: 240}, {Count: 11},
+	}
+
+	median, mad := scoreBuckets(buckets)
+	assert.Equal(t, 11.0, median)
+	assert.Equal(t, 1.0, mad)
+	assert.Greater(t, buckets[5].Score, 3.5)
+	assert.Less(t, math.Abs(buckets[0].Score), 3.5)
+}
+
+func TestScoreBucketsFlatBaseline(t *testing.T) {
+	buckets := []VolumeBucket{{Count: 0}, {Count: 0}, {Count: 0}, {Count: 7}}
+
+	_, mad := scoreBuckets(buckets)
+	assert.Equal(t, 0.0, mad)
+	assert.Equal(t, 0.0, buckets[0].Score)
+	assert.InDelta(t, 7/(1.2533*1.75), buckets[3].Score, 1e-9, "MAD 为 0 时使用平均绝对偏差")
+	assert.False(t, math.IsInf(buckets[3].Score, 0))
+}
+
+func TestScoreBucketsSparseSeries(t *testing.T) {
+	// 大部分桶为 0 的稀疏序列，只有 30 是真正的突增
+	counts := []int64{0, 0, 0, 1, 0, 0, 2, 0, 0, 0, 1, 0, 0, 30, 0, 0}
+	buckets := make([]VolumeBucket, len(counts))
+	for i, c := range counts {
+		buckets[i].Count = c
+	}
+
+	median, mad := scoreBuckets(buckets)
+	assert.Equal(t, 0.0, median)
+	assert.Equal(t, 0.0, mad)
+	for i, b := range buckets {
+		assert.False(t, math.IsInf(b.Score, 0), "bucket %d", i)
+		if i == 13 {
+			assert.Greater(t, b.Score, 3.5)
+		} else {
+			assert.Less(t, b.Score, 3.5, "bucket %d", i)
+		}
+	}
+}
+
+func TestParseVolumeBuckets(t *testing.T) {
+	aggs := map[string]any{
+		"volume": map[string]any{
+			"buckets": []any{
+				map[string]any{"key_as_string": "2025-01-01T00:00:00.000Z", "key": float64(1735689600000), "doc_count": float64(3)},
+				map[string]any{
+					"key":       float64(1735689660000),
+					"doc_count": float64(50),
+					"top_terms": map[string]any{
+						"buckets": []any{map[string]any{"key": "ERROR", "doc_count": float64(45)}},
+					},
+				},
+			},
+		},
+	}
+
+	buckets := parseVolumeBuckets(aggs)
+	assert.Len(t, buckets, 2)
+	assert.Equal(t, "2025-01-01T00:00:00.000Z", buckets[0].Time)
+	assert.Equal(t, "2025-01-01T00:01:00Z", buckets[1].Time)
+	assert.Equal(t, []TermStat{{Key: "ERROR", Count: 45}}, buckets[1].TopTerms)
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", sparkline([]VolumeBucket{{Count: 0}, {Count: 5}, {Count: 10}}))
+	assert.Equal(t, "▁▁", sparkline([]VolumeBucket{{Count: 0}, {Count: 0}}))
+}
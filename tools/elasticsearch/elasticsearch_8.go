@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	es8 "github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
@@ -155,3 +156,180 @@ func (c *es8Client) GetShards(index string) ([]map[string]any, error) {
 
 	return shards, nil
 }
+
+func (c *es8Client) GetFieldMapping(pattern, field string) (map[string]any, error) {
+	res, err := c.client.Indices.GetFieldMapping(
+		[]string{field},
+		c.client.Indices.GetFieldMapping.WithIndex(pattern),
+		c.client.Indices.GetFieldMapping.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get field mapping failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("%v %v", res.StatusCode, string(body))
+	}
+
+	var response map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("parse response failed: %w", err)
+	}
+
+	return response, nil
+}
+
+func (c *es8Client) Aggregate(index string, query map[string]any) (map[string]any, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("params to json failed: %w", err)
+	}
+
+	res, err := c.client.Search(
+		c.client.Search.WithIndex(index),
+		c.client.Search.WithBody(strings.NewReader(string(queryJSON))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate run failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	var searchResponse map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&searchResponse); err != nil {
+		return nil, fmt.Errorf("aggregate parse response failed: %w", err)
+	}
+
+	if res.IsError() {
+		err := searchResponse["error"].(map[string]any)
+		return nil, fmt.Errorf("%v %v", err["type"], err["reason"])
+	}
+
+	aggs, _ := searchResponse["aggregations"].(map[string]any)
+	return aggs, nil
+}
+
+func (c *es8Client) Scroll(index string, query map[string]any, batchSize int, fn ScrollFunc) error {
+	body := make(map[string]any, len(query)+1)
+	for k, v := range query {
+		body[k] = v
+	}
+	body["size"] = batchSize
+
+	queryJSON, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("params to json failed: %w", err)
+	}
+
+	res, err := c.client.Search(
+		c.client.Search.WithIndex(index),
+		c.client.Search.WithBody(strings.NewReader(string(queryJSON))),
+		c.client.Search.WithScroll(time.Minute),
+	)
+
+	var scrollID string
+	defer func() {
+		if scrollID != "" {
+			if res, err := c.client.ClearScroll(c.client.ClearScroll.WithScrollID(scrollID)); err == nil {
+				res.Body.Close()
+			}
+		}
+	}()
+
+	for {
+		if err != nil {
+			return fmt.Errorf("scroll run failed: %w", err)
+		}
+
+		var scrollResponse map[string]any
+		decodeErr := json.NewDecoder(res.Body).Decode(&scrollResponse)
+		res.Body.Close()
+		if decodeErr != nil {
+			return fmt.Errorf("scroll parse response failed: %w", decodeErr)
+		}
+		if res.IsError() {
+			err := scrollResponse["error"].(map[string]any)
+			return fmt.Errorf("%v %v", err["type"], err["reason"])
+		}
+
+		if id, ok := scrollResponse["_scroll_id"].(string); ok {
+			scrollID = id
+		}
+		hits, _ := scrollResponse["hits"].(map[string]any)
+		hitsArray, _ := hits["hits"].([]any)
+		if len(hitsArray) == 0 {
+			return nil
+		}
+
+		next, fnErr := fn(totalHits(hits), hitsArray)
+		if fnErr != nil || !next {
+			return fnErr
+		}
+
+		res, err = c.client.Scroll(
+			c.client.Scroll.WithScrollID(scrollID),
+			c.client.Scroll.WithScroll(time.Minute),
+		)
+	}
+}
+
+func (c *es8Client) GetILMPolicies(policy string) (map[string]any, error) {
+	opts := []func(*esapi.ILMGetLifecycleRequest){}
+	if policy != "" {
+		opts = append(opts, c.client.ILM.GetLifecycle.WithPolicy(policy))
+	}
+	res, err := c.client.ILM.GetLifecycle(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("get ilm policies failed: %w", err)
+	}
+	return decodeES8Response(res)
+}
+
+func (c *es8Client) ExplainILM(pattern string, onlyErrors bool) (map[string]any, error) {
+	res, err := c.client.ILM.ExplainLifecycle(
+		pattern,
+		c.client.ILM.ExplainLifecycle.WithOnlyErrors(onlyErrors),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("explain ilm failed: %w", err)
+	}
+	return decodeES8Response(res)
+}
+
+func (c *es8Client) GetSnapshotRepositories() (map[string]any, error) {
+	res, err := c.client.Snapshot.GetRepository()
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot repositories failed: %w", err)
+	}
+	return decodeES8Response(res)
+}
+
+func (c *es8Client) GetSnapshots(repository, snapshot string) (map[string]any, error) {
+	res, err := c.client.Snapshot.Get(
+		repository,
+		[]string{snapshot},
+		c.client.Snapshot.Get.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshots failed: %w", err)
+	}
+	return decodeES8Response(res)
+}
+
+// decodeES8Response 解析 JSON 响应体，非 2xx 响应返回错误
+func decodeES8Response(res *esapi.Response) (map[string]any, error) {
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("%v %v", res.StatusCode, string(body))
+	}
+
+	var response map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("parse response failed: %w", err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,327 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cast"
+)
+
+const (
+	defaultExportMaxDocs = 10000  // 单次导出默认文档上限
+	exportHardMaxDocs    = 500000 // 单次导出文档硬上限
+	exportBatchSize      = 1000   // 每批 scroll 拉取的文档数
+)
+
+// getExportDir 获取导出文件目录，可通过 ES_EXPORT_DIR 环境变量配置
+var getExportDir = func() string {
+	if dir := os.Getenv("ES_EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "es_export")
+}
+
+// ExportTool 将查询结果通过 scroll 导出为本地 NDJSON 或 CSV 文件
+func ExportTool(s *server.MCPServer) {
+	tool := mcp.NewTool("es_export",
+		mcp.WithDescription(`Export all hits of an Elasticsearch query to a local NDJSON or CSV file under the configured export directory (ES_EXPORT_DIR). Documents are streamed with scroll, so use this instead of search when the full result set is needed for offline analysis.`),
+		mcp.WithString("index",
+			mcp.Required(),
+			mcp.MinLength(1),
+			mcp.Description("Name or pattern of the Elasticsearch index to export from"),
+		),
+		mcp.WithObject("query",
+			mcp.Required(),
+			mcp.Description("Elasticsearch query DSL object that can include query and sort; size is controlled by the export"),
+		),
+		mcp.WithString("fileName",
+			mcp.Required(),
+			mcp.MinLength(1),
+			mcp.Description("Output file name inside the export directory, e.g. errors.ndjson; must not already exist"),
+		),
+		mcp.WithString("format",
+			mcp.DefaultString("ndjson"),
+			mcp.Enum("ndjson", "csv"),
+			mcp.Description("Output file format"),
+		),
+		mcp.WithArray("fields",
+			mcp.Items(map[string]any{"type": "string"}),
+			mcp.Description("Fields to export, dotted paths are supported; _id and _index are also accepted. All source fields are exported when omitted"),
+		),
+		mcp.WithNumber("maxDocs",
+			mcp.DefaultNumber(defaultExportMaxDocs),
+			mcp.Description(fmt.Sprintf("Maximum number of documents to write, capped at %d", exportHardMaxDocs)),
+		),
+	)
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultErrorFromErr("client is not initialized", nil), nil
+		}
+
+		args := request.GetArguments()
+		index := cast.ToString(args["index"])
+		query, _ := args["query"].(map[string]any)
+		format := stringOr(args["format"], "ndjson")
+		fields := cast.ToStringSlice(args["fields"])
+		maxDocs := cast.ToInt(args["maxDocs"])
+		if maxDocs <= 0 {
+			maxDocs = defaultExportMaxDocs
+		}
+		if maxDocs > exportHardMaxDocs {
+			maxDocs = exportHardMaxDocs
+		}
+
+		filePath, err := exportFilePath(cast.ToString(args["fileName"]))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid fileName", err), nil
+		}
+
+		if len(fields) > 0 {
+			query = withSourceFields(query, fields)
+		}
+
+		written := 0
+		var total HitsTotal
+		err = writeExportFile(filePath, func(file io.Writer) error {
+			w := newExportWriter(format, fields, file)
+			err := client.Scroll(index, query, min(exportBatchSize, maxDocs), func(t HitsTotal, hits []any) (bool, error) {
+				total = t
+				for _, h := range hits {
+					if written >= maxDocs {
+						return false, nil
+					}
+					hit, ok := h.(map[string]any)
+					if !ok {
+						continue
+					}
+					if err := w.Write(hit); err != nil {
+						return false, err
+					}
+					written++
+				}
+				if err := ctx.Err(); err != nil {
+					return false, err
+				}
+				helper.NotifyProgress(ctx, request, float64(written), float64(min(total.Value, int64(maxDocs))), fmt.Sprintf("exported %d documents", written))
+				return written < maxDocs, nil
+			})
+			if flushErr := w.Flush(); err == nil {
+				err = flushErr
+			}
+			return err
+		})
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("es_export tool failed after %d documents", written), err), nil
+		}
+
+		result := fmt.Sprintf("Exported %d of %s matching documents to %s (%s)", written, total, filePath, format)
+		if written >= maxDocs && (!total.Exact() || total.Value > int64(written)) {
+			result += fmt.Sprintf("\n\nExport stopped at maxDocs=%d", maxDocs)
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+	s.AddTool(tool, handler)
+}
+
+// exportFilePath 返回导出目录下的文件路径，禁止跳出导出目录
+func exportFilePath(fileName string) (string, error) {
+	if fileName == "" || fileName != filepath.Base(fileName) || fileName == "." || fileName == ".." {
+		return "", fmt.Errorf("fileName %q must be a plain file name", fileName)
+	}
+	dir := getExportDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// writeExportFile 新建导出文件并交给 write 写入，文件已存在时报错，写入失败时删除不完整的文件
+func writeExportFile(filePath string, write func(w io.Writer) error) (err error) {
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("export file %s already exists, choose another fileName", filePath)
+		}
+		return fmt.Errorf("create export file failed: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(filePath)
+		}
+	}()
+	return write(file)
+}
+
+// withSourceFields 复制查询并限制 _source 为所选字段
+func withSourceFields(query map[string]any, fields []string) map[string]any {
+	result := make(map[string]any, len(query)+1)
+	for k, v := range query {
+		result[k] = v
+	}
+	source := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f != "_id" && f != "_index" {
+			source = append(source, f)
+		}
+	}
+	result["_source"] = source
+	return result
+}
+
+// totalHits 解析 hits.total，兼容 6.x 的数字格式和 7.x 之后的对象格式
+func totalHits(hits map[string]any) HitsTotal {
+	switch total := hits["total"].(type) {
+	case map[string]any:
+		return HitsTotal{Value: cast.ToInt64(total["value"]), Relation: cast.ToString(total["relation"])}
+	default:
+		return HitsTotal{Value: cast.ToInt64(total), Relation: "eq"}
+	}
+}
+
+// exportWriter 将命中结果写入文件
+type exportWriter struct {
+	format string
+	fields []string
+	buf    *bufio.Writer
+	csv    *csv.Writer
+	header bool // CSV 表头是否已写入
+}
+
+func newExportWriter(format string, fields []string, out io.Writer) *exportWriter {
+	w := &exportWriter{format: format, fields: fields, buf: bufio.NewWriter(out)}
+	if format == "csv" {
+		w.csv = csv.NewWriter(w.buf)
+	}
+	return w
+}
+
+func (w *exportWriter) Write(hit map[string]any) error {
+	if w.format == "csv" {
+		return w.writeCSV(hit)
+	}
+
+	doc := exportDocument(hit, w.fields)
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if _, err := w.buf.Write(line); err != nil {
+		return err
+	}
+	return w.buf.WriteByte('\n')
+}
+
+func (w *exportWriter) writeCSV(hit map[string]any) error {
+	if !w.header {
+		// 未指定字段时，使用第一条文档的扁平化字段作为表头
+		if len(w.fields) == 0 {
+			flat := make(map[string]any)
+			flattenSource("", hitSource(hit), flat)
+			for k := range flat {
+				w.fields = append(w.fields, k)
+			}
+			sort.Strings(w.fields)
+			w.fields = append([]string{"_id"}, w.fields...)
+		}
+		if err := w.csv.Write(w.fields); err != nil {
+			return err
+		}
+		w.header = true
+	}
+
+	row := make([]string, len(w.fields))
+	for i, f := range w.fields {
+		row[i] = csvValue(fieldValue(hit, f))
+	}
+	return w.csv.Write(row)
+}
+
+func (w *exportWriter) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return w.buf.Flush()
+}
+
+// exportDocument 生成 NDJSON 中的一行
+func exportDocument(hit map[string]any, fields []string) map[string]any {
+	if len(fields) == 0 {
+		return hitSource(hit)
+	}
+	doc := make(map[string]any, len(fields))
+	for _, f := range fields {
+		doc[f] = fieldValue(hit, f)
+	}
+	return doc
+}
+
+func hitSource(hit map[string]any) map[string]any {
+	source, _ := hit["_source"].(map[string]any)
+	return source
+}
+
+// fieldValue 按点分路径读取字段值
+func fieldValue(hit map[string]any, field string) any {
+	if field == "_id" || field == "_index" {
+		return hit[field]
+	}
+	source := hitSource(hit)
+	if v, ok := source[field]; ok {
+		return v
+	}
+
+	var current any = source
+	for _, part := range strings.Split(field, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return current
+}
+
+// flattenSource 将嵌套对象展开为点分路径
+func flattenSource(prefix string, source map[string]any, out map[string]any) {
+	for k, v := range source {
+		key := prefix + k
+		if m, ok := v.(map[string]any); ok {
+			flattenSource(key+".", m, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func csvValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]any, []any:
+		b, _ := json.Marshal(val)
+		return string(b)
+	default:
+		return cast.ToString(val)
+	}
+}
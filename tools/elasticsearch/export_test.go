@@ -0,0 +1,92 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportHits = []map[string]any{
+	{"_id": "1", "_index": "logs-a", "_source": map[string]any{"level": "error", "http": map[string]any{"status": float64(500)}}},
+	{"_id": "2", "_index": "logs-a", "_source": map[string]any{"level": "warn", "http": map[string]any{"status": float64(404)}, "tags": []any{"a", "b"}}},
+}
+
+func TestExportWriterNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	w := newExportWriter("ndjson", []string{"_id", "http.status"}, &out)
+	for _, hit := range exportHits {
+		require.NoError(t, w.Write(hit))
+	}
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, "{\"_id\":\"1\",\"http.status\":500}\n{\"_id\":\"2\",\"http.status\":404}\n", out.String())
+}
+
+func TestExportWriterCSV(t *testing.T) {
+	var out bytes.Buffer
+	w := newExportWriter("csv", nil, &out)
+	for _, hit := range exportHits {
+		require.NoError(t, w.Write(hit))
+	}
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, "_id,http.status,level\n1,500,error\n2,404,warn\n", out.String())
+}
+
+func TestExportFilePath(t *testing.T) {
+	dir := t.TempDir()
+	oldGetExportDir := getExportDir
+	getExportDir = func() string { return dir }
+	defer func() { getExportDir = oldGetExportDir }()
+
+	p, err := exportFilePath("errors.ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "errors.ndjson"), p)
+
+	_, err = exportFilePath("../errors.ndjson")
+	assert.Error(t, err)
+	_, err = exportFilePath("..")
+	assert.Error(t, err)
+}
+
+func TestWriteExportFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "errors.ndjson")
+
+	require.NoError(t, writeExportFile(filePath, func(w io.Writer) error {
+		_, err := io.WriteString(w, "first\n")
+		return err
+	}))
+
+	err := writeExportFile(filePath, func(w io.Writer) error { return nil })
+	assert.ErrorContains(t, err, "already exists")
+	data, _ := os.ReadFile(filePath)
+	assert.Equal(t, "first\n", string(data), "不覆盖已有文件")
+
+	failedPath := filepath.Join(filepath.Dir(filePath), "failed.ndjson")
+	err = writeExportFile(failedPath, func(w io.Writer) error {
+		io.WriteString(w, "partial\n")
+		return errors.New("scroll failed")
+	})
+	assert.EqualError(t, err, "scroll failed")
+	assert.NoFileExists(t, failedPath, "失败时删除不完整的文件")
+}
+
+func TestTotalHits(t *testing.T) {
+	total := totalHits(map[string]any{"total": map[string]any{"value": float64(42), "relation": "eq"}})
+	assert.Equal(t, HitsTotal{Value: 42, Relation: "eq"}, total)
+	assert.Equal(t, "42", total.String())
+
+	total = totalHits(map[string]any{"total": map[string]any{"value": float64(10000), "relation": "gte"}})
+	assert.False(t, total.Exact())
+	assert.Equal(t, "at least 10000", total.String())
+
+	total = totalHits(map[string]any{"total": float64(7)})
+	assert.True(t, total.Exact())
+	assert.Equal(t, int64(7), total.Value)
+}
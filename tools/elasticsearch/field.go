@@ -0,0 +1,210 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// FieldMatch 描述某个字段在各索引中的类型分布
+type FieldMatch struct {
+	Field    string              `json:"field"`    // 字段全名
+	Conflict bool                `json:"conflict"` // 不同索引中类型是否冲突
+	Types    map[string][]string `json:"types"`    // 类型 -> 索引列表
+}
+
+// FindFieldTool 查找包含指定字段的索引
+func FindFieldTool(s *server.MCPServer) {
+	tool := mcp.NewTool("es_find_field",
+		mcp.WithDescription(`Find indices whose mappings contain the given field, with the field type in each index. Type conflicts across indices are highlighted.`),
+		mcp.WithString("indexPattern",
+			mcp.Required(),
+			mcp.MinLength(1),
+			mcp.Description("Index pattern of Elasticsearch indices to scan, e.g. logs-*"),
+		),
+		mcp.WithString("field",
+			mcp.Required(),
+			mcp.MinLength(1),
+			mcp.Description("Full field name to look for, wildcards are supported, e.g. trace_id or http.*"),
+		),
+	)
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultErrorFromErr("client is not initialized", nil), nil
+		}
+
+		pattern := request.GetArguments()["indexPattern"].(string)
+		field := request.GetArguments()["field"].(string)
+
+		// 优先使用 _mapping/field/{field}，失败时回退到完整 mapping 扫描
+		var indexTypes map[string]map[string]string
+		fieldMappings, err := client.GetFieldMapping(pattern, field)
+		if err == nil {
+			indexTypes = fieldTypesFromFieldMapping(fieldMappings)
+		} else {
+			mappings, err := client.GetMapping(pattern)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("es_find_field tool failed", err), nil
+			}
+			indexTypes = fieldTypesFromMapping(mappings, field)
+		}
+
+		matches := groupFieldTypes(indexTypes)
+		if len(matches) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No indices matching %s contain field %s", pattern, field)), nil
+		}
+
+		var conflicts []string
+		for _, m := range matches {
+			if m.Conflict {
+				types := make([]string, 0, len(m.Types))
+				for t, indices := range m.Types {
+					types = append(types, fmt.Sprintf("%s (%d indices)", t, len(indices)))
+				}
+				sort.Strings(types)
+				conflicts = append(conflicts, fmt.Sprintf("  %s: %s", m.Field, strings.Join(types, ", ")))
+			}
+		}
+
+		result := fmt.Sprintf("Found %d fields in %d indices", len(matches), countIndices(indexTypes))
+		if len(conflicts) > 0 {
+			result += fmt.Sprintf("\n\nType conflicts:\n%s", strings.Join(conflicts, "\n"))
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s \n\nResult: \n%s", result, mapToText(matches))), nil
+	}
+	s.AddTool(tool, handler)
+}
+
+// fieldTypesFromFieldMapping 解析 _mapping/field 响应，返回 字段 -> 索引 -> 类型
+func fieldTypesFromFieldMapping(response map[string]any) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	for index, v := range response {
+		indexMap, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		fields, ok := indexMap["mappings"].(map[string]any)
+		if !ok {
+			continue
+		}
+		for name, fv := range fields {
+			fieldMap, ok := fv.(map[string]any)
+			if !ok {
+				continue
+			}
+			fullName, _ := fieldMap["full_name"].(string)
+			if fullName == "" {
+				fullName = name
+			}
+			mapping, _ := fieldMap["mapping"].(map[string]any)
+			// mapping 中的 key 为字段的末级名称，只有一个元素
+			for _, mv := range mapping {
+				if m, ok := mv.(map[string]any); ok {
+					addFieldType(result, fullName, index, fieldTypeOf(m))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// fieldTypesFromMapping 遍历完整 mapping，查找与 field 匹配的字段
+func fieldTypesFromMapping(response map[string]any, field string) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	for index, v := range response {
+		indexMap, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		mappings, ok := indexMap["mappings"].(map[string]any)
+		if !ok {
+			continue
+		}
+		walkProperties(mappings, "", func(fullName string, m map[string]any) {
+			if matched, _ := path.Match(field, fullName); matched {
+				addFieldType(result, fullName, index, fieldTypeOf(m))
+			}
+		})
+	}
+	return result
+}
+
+// walkProperties 递归遍历 properties 及 multi-fields
+func walkProperties(node map[string]any, prefix string, fn func(fullName string, m map[string]any)) {
+	props, ok := node["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	for name, v := range props {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		fullName := prefix + name
+		fn(fullName, m)
+		walkProperties(m, fullName+".", fn)
+		if subFields, ok := m["fields"].(map[string]any); ok {
+			for sub, sv := range subFields {
+				if sm, ok := sv.(map[string]any); ok {
+					fn(fullName+"."+sub, sm)
+				}
+			}
+		}
+	}
+}
+
+// fieldTypeOf 返回字段类型，object 类型字段没有 type 属性
+func fieldTypeOf(m map[string]any) string {
+	if t, ok := m["type"].(string); ok {
+		return t
+	}
+	if _, ok := m["properties"]; ok {
+		return "object"
+	}
+	return "unknown"
+}
+
+func addFieldType(result map[string]map[string]string, field, index, fieldType string) {
+	if _, ok := result[field]; !ok {
+		result[field] = make(map[string]string)
+	}
+	result[field][index] = fieldType
+}
+
+// groupFieldTypes 按字段汇总类型分布，并标记类型冲突
+func groupFieldTypes(indexTypes map[string]map[string]string) []FieldMatch {
+	matches := make([]FieldMatch, 0, len(indexTypes))
+	for field, indices := range indexTypes {
+		types := make(map[string][]string)
+		for index, t := range indices {
+			types[t] = append(types[t], index)
+		}
+		for _, list := range types {
+			sort.Strings(list)
+		}
+		matches = append(matches, FieldMatch{
+			Field:    field,
+			Conflict: len(types) > 1,
+			Types:    types,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Field < matches[j].Field
+	})
+	return matches
+}
+
+func countIndices(indexTypes map[string]map[string]string) int {
+	seen := make(map[string]struct{})
+	for _, indices := range indexTypes {
+		for index := range indices {
+			seen[index] = struct{}{}
+		}
+	}
+	return len(seen)
+}
@@ -0,0 +1,69 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldTypesFromFieldMapping(t *testing.T) {
+	response := map[string]any{
+		"logs-a": map[string]any{
+			"mappings": map[string]any{
+				"trace_id": map[string]any{
+					"full_name": "trace_id",
+					"mapping":   map[string]any{"trace_id": map[string]any{"type": "keyword"}},
+				},
+			},
+		},
+		"logs-b": map[string]any{
+			"mappings": map[string]any{
+				"trace_id": map[string]any{
+					"full_name": "trace_id",
+					"mapping":   map[string]any{"trace_id": map[string]any{"type": "text"}},
+				},
+			},
+		},
+		"logs-c": map[string]any{"mappings": map[string]any{}},
+	}
+
+	matches := groupFieldTypes(fieldTypesFromFieldMapping(response))
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "trace_id", matches[0].Field)
+	assert.True(t, matches[0].Conflict)
+	assert.Equal(t, []string{"logs-a"}, matches[0].Types["keyword"])
+	assert.Equal(t, []string{"logs-b"}, matches[0].Types["text"])
+}
+
+func TestFieldTypesFromMapping(t *testing.T) {
+	response := map[string]any{
+		"logs-a": map[string]any{
+			"mappings": map[string]any{
+				"properties": map[string]any{
+					"http": map[string]any{
+						"properties": map[string]any{
+							"status": map[string]any{"type": "long"},
+							"path": map[string]any{
+								"type":   "text",
+								"fields": map[string]any{"keyword": map[string]any{"type": "keyword"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	indexTypes := fieldTypesFromMapping(response, "http.*")
+	assert.Equal(t, "long", indexTypes["http.status"]["logs-a"])
+	assert.Equal(t, "text", indexTypes["http.path"]["logs-a"])
+	assert.NotContains(t, indexTypes, "http")
+
+	indexTypes = fieldTypesFromMapping(response, "http.path.keyword")
+	assert.Equal(t, "keyword", indexTypes["http.path.keyword"]["logs-a"])
+
+	matches := groupFieldTypes(fieldTypesFromMapping(response, "http"))
+	assert.Len(t, matches, 1)
+	assert.False(t, matches[0].Conflict)
+	assert.Equal(t, []string{"logs-a"}, matches[0].Types["object"])
+}
@@ -0,0 +1,239 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cast"
+)
+
+// ILMPoliciesTool 列出 ILM 生命周期策略
+func ILMPoliciesTool(s *server.MCPServer) {
+	tool := mcp.NewTool("es_ilm_policies",
+		mcp.WithDescription(`List Elasticsearch ILM policies with their phases, min_age and actions`),
+		mcp.WithString("policy",
+			mcp.Description("Optional policy name to show, all policies are listed when omitted"),
+		),
+	)
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultErrorFromErr("client is not initialized", nil), nil
+		}
+
+		policies, err := client.GetILMPolicies(cast.ToString(request.GetArguments()["policy"]))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("es_ilm_policies tool failed", err), nil
+		}
+
+		summary := summarizeILMPolicies(policies)
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d ILM policies \n\nResult: \n%s", len(summary), mapToText(summary))), nil
+	}
+	s.AddTool(tool, handler)
+}
+
+// ILMExplainTool 查看索引当前所处的生命周期阶段
+func ILMExplainTool(s *server.MCPServer) {
+	tool := mcp.NewTool("es_ilm_explain",
+		mcp.WithDescription(`Explain the ILM state of indices matching a pattern: policy, phase, action, step, age and errors`),
+		mcp.WithString("indexPattern",
+			mcp.Required(),
+			mcp.MinLength(1),
+			mcp.Description("Index pattern of Elasticsearch indices to explain"),
+		),
+		mcp.WithBoolean("onlyErrors",
+			mcp.DefaultBool(false),
+			mcp.Description("Only show indices whose ILM step failed"),
+		),
+	)
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultErrorFromErr("client is not initialized", nil), nil
+		}
+
+		pattern := cast.ToString(request.GetArguments()["indexPattern"])
+		explain, err := client.ExplainILM(pattern, cast.ToBool(request.GetArguments()["onlyErrors"]))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("es_ilm_explain tool failed", err), nil
+		}
+
+		rows, failed := summarizeILMExplain(explain)
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d indices, %d with ILM errors \n\nResult: \n%s", len(rows), failed, mapToText(rows))), nil
+	}
+	s.AddTool(tool, handler)
+}
+
+// SnapshotRepositoriesTool 列出快照仓库
+func SnapshotRepositoriesTool(s *server.MCPServer) {
+	tool := mcp.NewTool("es_snapshot_repositories",
+		mcp.WithDescription(`List Elasticsearch snapshot repositories with their type and settings`),
+	)
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultErrorFromErr("client is not initialized", nil), nil
+		}
+
+		repositories, err := client.GetSnapshotRepositories()
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("es_snapshot_repositories tool failed", err), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d snapshot repositories \n\nResult: \n%s", len(repositories), mapToText(repositories))), nil
+	}
+	s.AddTool(tool, handler)
+}
+
+// SnapshotsTool 列出仓库中的快照及其包含的索引和状态
+func SnapshotsTool(s *server.MCPServer) {
+	tool := mcp.NewTool("es_snapshots",
+		mcp.WithDescription(`List snapshots of a repository with their state, time range, shard stats and indices`),
+		mcp.WithString("repository",
+			mcp.Required(),
+			mcp.MinLength(1),
+			mcp.Description("Name of the snapshot repository"),
+		),
+		mcp.WithString("snapshot",
+			mcp.DefaultString("*"),
+			mcp.Description("Snapshot name or wildcard pattern"),
+		),
+		mcp.WithString("index",
+			mcp.Description("Optional index name or wildcard pattern; only snapshots containing a matching index are listed"),
+		),
+	)
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if client == nil {
+			return mcp.NewToolResultErrorFromErr("client is not initialized", nil), nil
+		}
+
+		args := request.GetArguments()
+		repository := cast.ToString(args["repository"])
+		snapshots, err := client.GetSnapshots(repository, stringOr(args["snapshot"], "*"))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("es_snapshots tool failed", err), nil
+		}
+
+		rows := summarizeSnapshots(snapshots, cast.ToString(args["index"]))
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d snapshots in repository %s \n\nResult: \n%s", len(rows), repository, mapToText(rows))), nil
+	}
+	s.AddTool(tool, handler)
+}
+
+// summarizeILMPolicies 提取策略中每个阶段的 min_age 和动作名称
+func summarizeILMPolicies(policies map[string]any) []map[string]any {
+	result := make([]map[string]any, 0, len(policies))
+	for name, v := range policies {
+		p, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		policy, _ := p["policy"].(map[string]any)
+		rawPhases, _ := policy["phases"].(map[string]any)
+
+		phases := make(map[string]any, len(rawPhases))
+		for phase, pv := range rawPhases {
+			pm, ok := pv.(map[string]any)
+			if !ok {
+				continue
+			}
+			actions := make([]string, 0)
+			if am, ok := pm["actions"].(map[string]any); ok {
+				for action := range am {
+					actions = append(actions, action)
+				}
+			}
+			sort.Strings(actions)
+			phases[phase] = map[string]any{
+				"minAge":  pm["min_age"],
+				"actions": actions,
+			}
+		}
+
+		result = append(result, map[string]any{
+			"policy":       name,
+			"version":      p["version"],
+			"modifiedDate": p["modified_date"],
+			"phases":       phases,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i]["policy"].(string) < result[j]["policy"].(string)
+	})
+	return result
+}
+
+// summarizeILMExplain 提取每个索引的 ILM 状态，返回结果及失败索引数
+func summarizeILMExplain(explain map[string]any) ([]map[string]any, int) {
+	indices, _ := explain["indices"].(map[string]any)
+	result := make([]map[string]any, 0, len(indices))
+	failed := 0
+	for name, v := range indices {
+		idx, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		row := map[string]any{
+			"index":   name,
+			"managed": idx["managed"],
+		}
+		if managed, _ := idx["managed"].(bool); managed {
+			for _, key := range []string{"policy", "phase", "action", "step", "age"} {
+				row[key] = idx[key]
+			}
+			if step, ok := idx["failed_step"].(string); ok && step != "" {
+				failed++
+				row["failedStep"] = step
+				if info, ok := idx["step_info"].(map[string]any); ok {
+					row["error"] = fmt.Sprintf("%v: %v", info["type"], info["reason"])
+				}
+			}
+		}
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i]["index"].(string) < result[j]["index"].(string)
+	})
+	return result, failed
+}
+
+// summarizeSnapshots 提取快照信息，indexPattern 非空时只保留包含匹配索引的快照
+func summarizeSnapshots(response map[string]any, indexPattern string) []map[string]any {
+	snapshots, _ := response["snapshots"].([]any)
+	result := make([]map[string]any, 0, len(snapshots))
+	for _, v := range snapshots {
+		snap, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		indices := cast.ToStringSlice(snap["indices"])
+		sort.Strings(indices)
+		if indexPattern != "" {
+			indices = filterIndices(indices, indexPattern)
+			if len(indices) == 0 {
+				continue
+			}
+		}
+		result = append(result, map[string]any{
+			"snapshot":  snap["snapshot"],
+			"state":     snap["state"],
+			"startTime": snap["start_time"],
+			"endTime":   snap["end_time"],
+			"shards":    snap["shards"],
+			"failures":  snap["failures"],
+			"indices":   indices,
+		})
+	}
+	return result
+}
+
+func filterIndices(indices []string, pattern string) []string {
+	matched := make([]string, 0)
+	for _, index := range indices {
+		if ok, _ := path.Match(pattern, index); ok {
+			matched = append(matched, index)
+		}
+	}
+	return matched
+}
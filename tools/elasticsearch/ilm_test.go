@@ -0,0 +1,64 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeILMPolicies(t *testing.T) {
+	policies := map[string]any{
+		"logs": map[string]any{
+			"version":       float64(3),
+			"modified_date": "2025-01-01T00:00:00.000Z",
+			"policy": map[string]any{
+				"phases": map[string]any{
+					"hot":    map[string]any{"min_age": "0ms", "actions": map[string]any{"rollover": map[string]any{}, "set_priority": map[string]any{}}},
+					"delete": map[string]any{"min_age": "7d", "actions": map[string]any{"delete": map[string]any{}}},
+				},
+			},
+		},
+	}
+
+	summary := summarizeILMPolicies(policies)
+	assert.Len(t, summary, 1)
+	phases := summary[0]["phases"].(map[string]any)
+	assert.Equal(t, map[string]any{"minAge": "7d", "actions": []string{"delete"}}, phases["delete"])
+	assert.Equal(t, []string{"rollover", "set_priority"}, phases["hot"].(map[string]any)["actions"])
+}
+
+func TestSummarizeILMExplain(t *testing.T) {
+	explain := map[string]any{
+		"indices": map[string]any{
+			"logs-000001": map[string]any{
+				"managed": true, "policy": "logs", "phase": "hot", "action": "rollover", "step": "ERROR", "age": "2d",
+				"failed_step": "check-rollover-ready",
+				"step_info":   map[string]any{"type": "illegal_argument_exception", "reason": "rollover alias missing"},
+			},
+			"metrics": map[string]any{"managed": false},
+		},
+	}
+
+	rows, failed := summarizeILMExplain(explain)
+	assert.Equal(t, 1, failed)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "logs-000001", rows[0]["index"])
+	assert.Equal(t, "illegal_argument_exception: rollover alias missing", rows[0]["error"])
+	assert.NotContains(t, rows[1], "phase")
+}
+
+func TestSummarizeSnapshots(t *testing.T) {
+	response := map[string]any{
+		"snapshots": []any{
+			map[string]any{"snapshot": "nightly-1", "state": "SUCCESS", "indices": []any{"logs-2025.01.01", "metrics"}},
+			map[string]any{"snapshot": "nightly-2", "state": "PARTIAL", "indices": []any{"metrics"}},
+		},
+	}
+
+	assert.Len(t, summarizeSnapshots(response, ""), 2)
+
+	rows := summarizeSnapshots(response, "logs-*")
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "nightly-1", rows[0]["snapshot"])
+	assert.Equal(t, []string{"logs-2025.01.01"}, rows[0]["indices"])
+}
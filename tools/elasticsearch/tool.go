@@ -21,6 +21,13 @@ func RegisterTool(s *server.MCPServer) {
 	GetMappingTool(s)
 	SearchTool(s)
 	GetShardsTool(s)
+	FindFieldTool(s)
+	VolumeAnomaliesTool(s)
+	ExportTool(s)
+	ILMPoliciesTool(s)
+	ILMExplainTool(s)
+	SnapshotRepositoriesTool(s)
+	SnapshotsTool(s)
 }
 
 func initClient() {
@@ -1,11 +1,41 @@
 package elasticsearch
 
+import "fmt"
+
 // IClient 定义 Elasticsearch 客户端接口
 type IClient interface {
 	ListIndices(pattern string) ([]map[string]any, error)
 	GetMapping(index string) (map[string]any, error)
 	Search(index string, query map[string]any) (map[string]any, error)
 	GetShards(index string) ([]map[string]any, error)
+	GetFieldMapping(pattern, field string) (map[string]any, error)
+	Aggregate(index string, query map[string]any) (map[string]any, error)
+	Scroll(index string, query map[string]any, batchSize int, fn ScrollFunc) error
+	GetILMPolicies(policy string) (map[string]any, error)
+	ExplainILM(pattern string, onlyErrors bool) (map[string]any, error)
+	GetSnapshotRepositories() (map[string]any, error)
+	GetSnapshots(repository, snapshot string) (map[string]any, error)
+}
+
+// ScrollFunc 处理 scroll 返回的一批命中结果，total 为命中总数，返回 false 时停止滚动
+type ScrollFunc func(total HitsTotal, hits []any) (bool, error)
+
+// HitsTotal 命中总数，Relation 为 "gte" 时 Value 只是下限（受 track_total_hits 限制）
+type HitsTotal struct {
+	Value    int64
+	Relation string
+}
+
+// Exact 返回命中总数是否精确
+func (t HitsTotal) Exact() bool {
+	return t.Relation != "gte"
+}
+
+func (t HitsTotal) String() string {
+	if t.Exact() {
+		return fmt.Sprintf("%d", t.Value)
+	}
+	return fmt.Sprintf("at least %d", t.Value)
 }
 
 // Config 定义 Elasticsearch 配置
@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxTemplateDepth 生成请求示例时嵌套消息的最大深度，防止递归消息无限展开
+const maxTemplateDepth = 4
+
+// describe 以 proto 语法描述服务、方法、消息或枚举，消息会附带其引用的消息和枚举定义
+func describe(d protoreflect.Descriptor) string {
+	var sb strings.Builder
+	var refs []protoreflect.Descriptor
+	seen := make(map[protoreflect.FullName]bool)
+	addRef := func(ref protoreflect.Descriptor) {
+		if !seen[ref.FullName()] && !isWellKnown(ref) {
+			seen[ref.FullName()] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	switch d := d.(type) {
+	case protoreflect.ServiceDescriptor:
+		writeService(&sb, d)
+		return strings.TrimRight(sb.String(), "\n")
+	case protoreflect.MethodDescriptor:
+		sb.WriteString(methodSignature(d) + "\n")
+		addRef(d.Input())
+		addRef(d.Output())
+	case protoreflect.MessageDescriptor, protoreflect.EnumDescriptor:
+		addRef(d)
+	default:
+		return fmt.Sprintf("%s (%T)", d.FullName(), d)
+	}
+
+	// 依次展开引用，消息字段引用的类型追加到队尾
+	for i := 0; i < len(refs); i++ {
+		sb.WriteString("\n")
+		switch ref := refs[i].(type) {
+		case protoreflect.MessageDescriptor:
+			writeMessage(&sb, ref)
+			fields := ref.Fields()
+			for j := 0; j < fields.Len(); j++ {
+				f := fields.Get(j)
+				if f.IsMap() {
+					f = f.MapValue()
+				}
+				if f.Message() != nil {
+					addRef(f.Message())
+				} else if f.Enum() != nil {
+					addRef(f.Enum())
+				}
+			}
+		case protoreflect.EnumDescriptor:
+			writeEnum(&sb, ref)
+		}
+	}
+
+	if md, ok := d.(protoreflect.MethodDescriptor); ok {
+		d = md.Input()
+	}
+	if msg, ok := d.(protoreflect.MessageDescriptor); ok {
+		data, _ := json.MarshalIndent(messageTemplate(msg, 0), "", "  ")
+		fmt.Fprintf(&sb, "\nJSON 示例 (%s):\n%s\n", msg.FullName(), data)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// isWellKnown google.protobuf 下的类型（Timestamp、Struct 等）不展开
+func isWellKnown(d protoreflect.Descriptor) bool {
+	return d.ParentFile() != nil && d.ParentFile().Package() == "google.protobuf"
+}
+
+func writeService(sb *strings.Builder, sd protoreflect.ServiceDescriptor) {
+	fmt.Fprintf(sb, "service %s {\n", sd.FullName())
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		fmt.Fprintf(sb, "  %s\n", methodSignature(methods.Get(i)))
+	}
+	sb.WriteString("}\n")
+}
+
+// methodSignature 返回 rpc Name(Request) returns (Response); 形式的方法签名
+func methodSignature(md protoreflect.MethodDescriptor) string {
+	in, out := string(md.Input().FullName()), string(md.Output().FullName())
+	if md.IsStreamingClient() {
+		in = "stream " + in
+	}
+	if md.IsStreamingServer() {
+		out = "stream " + out
+	}
+	return fmt.Sprintf("rpc %s(%s) returns (%s);", md.Name(), in, out)
+}
+
+func writeMessage(sb *strings.Builder, msg protoreflect.MessageDescriptor) {
+	fmt.Fprintf(sb, "message %s {\n", msg.FullName())
+	fields := msg.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		fmt.Fprintf(sb, "  %s %s = %d;", fieldType(f), f.Name(), f.Number())
+		if oneof := f.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			fmt.Fprintf(sb, " // oneof %s", oneof.Name())
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+}
+
+func writeEnum(sb *strings.Builder, ed protoreflect.EnumDescriptor) {
+	fmt.Fprintf(sb, "enum %s {\n", ed.FullName())
+	values := ed.Values()
+	for i := 0; i < values.Len(); i++ {
+		fmt.Fprintf(sb, "  %s = %d;\n", values.Get(i).Name(), values.Get(i).Number())
+	}
+	sb.WriteString("}\n")
+}
+
+// fieldType 返回字段在 proto 中的类型写法
+func fieldType(f protoreflect.FieldDescriptor) string {
+	if f.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", scalarType(f.MapKey()), scalarType(f.MapValue()))
+	}
+	t := scalarType(f)
+	switch {
+	case f.IsList():
+		return "repeated " + t
+	case f.HasOptionalKeyword():
+		return "optional " + t
+	}
+	return t
+}
+
+func scalarType(f protoreflect.FieldDescriptor) string {
+	switch {
+	case f.Message() != nil:
+		return string(f.Message().FullName())
+	case f.Enum() != nil:
+		return string(f.Enum().FullName())
+	}
+	return f.Kind().String()
+}
+
+// messageTemplate 生成消息的 JSON 示例，字段使用 protojson 的名称和对应类型的零值
+func messageTemplate(msg protoreflect.MessageDescriptor, depth int) any {
+	if v, ok := wellKnownTemplate(msg); ok {
+		return v
+	}
+	out := make(map[string]any)
+	if depth >= maxTemplateDepth {
+		return out
+	}
+	fields := msg.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		switch {
+		case f.IsMap():
+			out[f.JSONName()] = map[string]any{"key": valueTemplate(f.MapValue(), depth)}
+		case f.IsList():
+			out[f.JSONName()] = []any{valueTemplate(f, depth)}
+		default:
+			out[f.JSONName()] = valueTemplate(f, depth)
+		}
+	}
+	return out
+}
+
+func valueTemplate(f protoreflect.FieldDescriptor, depth int) any {
+	switch f.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageTemplate(f.Message(), depth+1)
+	case protoreflect.EnumKind:
+		if values := f.Enum().Values(); values.Len() > 0 {
+			return string(values.Get(0).Name())
+		}
+		return 0
+	case protoreflect.BoolKind:
+		return false
+	case protoreflect.StringKind:
+		return ""
+	case protoreflect.BytesKind:
+		return "" // base64
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "0" // protojson 中 64 位整数为字符串
+	}
+	return 0
+}
+
+// wellKnownTemplate 常用 well-known 类型在 JSON 中的写法
+func wellKnownTemplate(msg protoreflect.MessageDescriptor) (any, bool) {
+	switch msg.FullName() {
+	case "google.protobuf.Timestamp":
+		return "1970-01-01T00:00:00Z", true
+	case "google.protobuf.Duration":
+		return "0s", true
+	case "google.protobuf.Struct":
+		return map[string]any{}, true
+	case "google.protobuf.Value":
+		return nil, true
+	case "google.protobuf.ListValue":
+		return []any{}, true
+	case "google.protobuf.FieldMask":
+		return "", true
+	case "google.protobuf.Empty":
+		return map[string]any{}, true
+	case "google.protobuf.Any":
+		return map[string]any{"@type": ""}, true
+	}
+	if isWellKnown(msg) && strings.HasSuffix(string(msg.Name()), "Value") {
+		// 包装类型（StringValue、Int32Value 等）在 JSON 中直接写值
+		return valueTemplate(msg.Fields().ByName("value"), maxTemplateDepth), true
+	}
+	return nil, false
+}
@@ -0,0 +1,236 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// reflectionStream 服务端反射的双向流，v1alpha 与 v1 的消息在线上格式一致，统一使用 v1 的类型
+type reflectionStream interface {
+	Send(*reflectionv1.ServerReflectionRequest) error
+	Recv() (*reflectionv1.ServerReflectionResponse, error)
+	CloseSend() error
+}
+
+// v1alphaStream 把 v1 的请求和响应转换为 v1alpha 收发，用于只注册了 v1alpha 反射服务的服务端
+type v1alphaStream struct {
+	stream reflectionv1alpha.ServerReflection_ServerReflectionInfoClient
+}
+
+func (s *v1alphaStream) Send(req *reflectionv1.ServerReflectionRequest) error {
+	var alpha reflectionv1alpha.ServerReflectionRequest
+	if err := convertMessage(req, &alpha); err != nil {
+		return err
+	}
+	return s.stream.Send(&alpha)
+}
+
+func (s *v1alphaStream) Recv() (*reflectionv1.ServerReflectionResponse, error) {
+	alpha, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	var resp reflectionv1.ServerReflectionResponse
+	if err := convertMessage(alpha, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s *v1alphaStream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// convertMessage 通过序列化在线上格式相同的两种消息之间转换
+func convertMessage(from, to proto.Message) error {
+	data, err := proto.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, to)
+}
+
+// reflectionClient 通过服务端反射获取服务列表和描述符，获取过的文件会缓存，描述符按需解析依赖
+type reflectionClient struct {
+	stream reflectionStream
+	protos map[string]*descriptorpb.FileDescriptorProto
+	files  *protoregistry.Files
+}
+
+// newReflectionClient 优先使用 v1 反射服务，服务端未实现时回退到 v1alpha
+func newReflectionClient(ctx context.Context, conn grpc.ClientConnInterface) (*reflectionClient, error) {
+	c := &reflectionClient{protos: make(map[string]*descriptorpb.FileDescriptorProto)}
+
+	v1, err := reflectionv1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err == nil {
+		c.stream = v1
+		// 流建立后第一次收发才能知道服务端是否实现了 v1
+		if _, err = c.listServices(); err == nil {
+			return c, nil
+		}
+		v1.CloseSend()
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, err
+	}
+
+	alpha, err := reflectionv1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.stream = &v1alphaStream{stream: alpha}
+	return c, nil
+}
+
+// Close 结束反射流
+func (c *reflectionClient) Close() {
+	c.stream.CloseSend()
+}
+
+// call 发送一个反射请求并返回响应，服务端返回错误响应时转换为 gRPC 状态错误
+func (c *reflectionClient) call(req *reflectionv1.ServerReflectionRequest) (*reflectionv1.ServerReflectionResponse, error) {
+	if err := c.stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// listServices 返回按名称排序的服务列表
+func (c *reflectionClient) listServices() ([]string, error) {
+	resp, err := c.call(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// addFiles 缓存响应中的文件描述符
+func (c *reflectionClient) addFiles(resp *reflectionv1.ServerReflectionResponse) error {
+	for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, fd); err != nil {
+			return fmt.Errorf("解析文件描述符失败: %w", err)
+		}
+		c.protos[fd.GetName()] = fd
+	}
+	return nil
+}
+
+// fetchDependencies 获取已缓存文件依赖但尚未获取的文件
+func (c *reflectionClient) fetchDependencies() error {
+	for {
+		var missing []string
+		for _, fd := range c.protos {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := c.protos[dep]; !ok {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		for _, name := range missing {
+			if _, ok := c.protos[name]; ok {
+				continue
+			}
+			resp, err := c.call(&reflectionv1.ServerReflectionRequest{
+				MessageRequest: &reflectionv1.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return fmt.Errorf("获取文件 %s 失败: %w", name, err)
+			}
+			if err := c.addFiles(resp); err != nil {
+				return err
+			}
+			if _, ok := c.protos[name]; !ok {
+				return fmt.Errorf("服务端没有返回文件 %s", name)
+			}
+		}
+	}
+}
+
+// Resolve 返回符号的描述符，符号为服务、方法（<package>.<service>.<method>）、消息或枚举的全名
+func (c *reflectionClient) Resolve(symbol string) (protoreflect.Descriptor, error) {
+	name := protoreflect.FullName(strings.TrimPrefix(symbol, "."))
+	if c.files != nil {
+		if d, err := c.files.FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+
+	resp, err := c.call(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: string(name)},
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, fmt.Errorf("服务端没有找到符号 %s", name)
+		}
+		return nil, err
+	}
+	if err := c.addFiles(resp); err != nil {
+		return nil, err
+	}
+	if err := c.fetchDependencies(); err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: c.fileList()})
+	if err != nil {
+		return nil, fmt.Errorf("构建描述符失败: %w", err)
+	}
+	c.files = files
+	d, err := files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("没有找到符号 %s", name)
+	}
+	return d, nil
+}
+
+func (c *reflectionClient) fileList() []*descriptorpb.FileDescriptorProto {
+	names := make([]string, 0, len(c.protos))
+	for name := range c.protos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]*descriptorpb.FileDescriptorProto, 0, len(names))
+	for _, name := range names {
+		list = append(list, c.protos[name])
+	}
+	return list
+}
+
+// Files 返回已解析的文件，用于构造动态消息类型
+func (c *reflectionClient) Files() *protoregistry.Files {
+	if c.files == nil {
+		return new(protoregistry.Files)
+	}
+	return c.files
+}
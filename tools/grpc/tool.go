@@ -0,0 +1,378 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cast"
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // 注册 google.rpc 错误详情类型，用于展示 status details
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// 调用超时的默认值和上限
+const (
+	defaultTimeout = 10 * time.Second
+	maxTimeout     = 60 * time.Second
+)
+
+// RegisterTool 注册 gRPC 调用工具
+func RegisterTool(s *server.MCPServer) {
+	s.AddTool(grpcListTool, grpcListHandler)
+	s.AddTool(grpcDescribeTool, grpcDescribeHandler)
+	s.AddTool(grpcInvokeTool, grpcInvokeHandler)
+}
+
+// connectionOptions 连接参数，所有 gRPC 工具共用
+var connectionOptions = []mcp.ToolOption{
+	mcp.WithString("address",
+		mcp.Required(),
+		mcp.Description("gRPC 服务地址，如 localhost:50051、dns:///svc.internal:8080"),
+	),
+	mcp.WithBoolean("tls",
+		mcp.Description("是否使用 TLS 连接，默认为明文连接"),
+	),
+	mcp.WithBoolean("insecureSkipVerify",
+		mcp.Description("使用 TLS 时跳过证书校验（仅用于测试环境）"),
+	),
+	mcp.WithObject("headers",
+		mcp.Description("请求元数据（metadata），如 {\"authorization\": \"Bearer xxx\"}，反射请求也会携带"),
+	),
+	mcp.WithNumber("timeoutSeconds",
+		mcp.Description(fmt.Sprintf("超时时间（秒），默认 %d，最大 %d", int(defaultTimeout.Seconds()), int(maxTimeout.Seconds()))),
+	),
+}
+
+// getURLPolicy 返回出站策略，测试中可替换
+var getURLPolicy = helper.GetURLPolicy
+
+func withConnectionOptions(name string, opts ...mcp.ToolOption) mcp.Tool {
+	return mcp.NewTool(name, append(opts, connectionOptions...)...)
+}
+
+// dial 按参数建立连接，返回携带超时和请求元数据的上下文
+func dial(ctx context.Context, args map[string]any) (*grpc.ClientConn, context.Context, context.CancelFunc, error) {
+	address := cast.ToString(args["address"])
+	if address == "" {
+		return nil, nil, nil, errors.New("address 参数不能为空")
+	}
+
+	// 先按主机名检查以便尽早返回明确的错误，建立连接时再检查解析后的地址，防止 DNS 重绑定
+	policy := getURLPolicy()
+	if err := policy.CheckHost(ctx, targetHost(address)); err != nil {
+		return nil, nil, nil, err
+	}
+	policyDial := helper.PolicyDialer(func() *helper.URLPolicy { return policy }, nil)((&net.Dialer{}).DialContext)
+
+	creds := insecure.NewCredentials()
+	if cast.ToBool(args["tls"]) {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: cast.ToBool(args["insecureSkipVerify"])})
+	}
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return policyDial(ctx, "tcp", addr)
+		}),
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	timeout := time.Duration(cast.ToFloat64(args["timeoutSeconds"]) * float64(time.Second))
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, min(timeout, maxTimeout))
+	if headers, ok := args["headers"].(map[string]any); ok && len(headers) > 0 {
+		md := metadata.MD{}
+		for k, v := range headers {
+			if s, ok := v.(string); ok {
+				md.Set(k, s)
+			} else {
+				md.Set(k, cast.ToStringSlice(v)...)
+			}
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	return conn, ctx, cancel, nil
+}
+
+// targetHost 返回连接地址中的主机名，支持 host:port 和 dns:///host:port 形式
+func targetHost(address string) string {
+	endpoint := address
+	if strings.Contains(address, "://") {
+		if u, err := url.Parse(address); err == nil {
+			endpoint = strings.TrimPrefix(u.Path, "/")
+		}
+	}
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+	return endpoint
+}
+
+// withReflection 建立连接和反射客户端后执行 fn
+func withReflection(ctx context.Context, args map[string]any, fn func(ctx context.Context, conn *grpc.ClientConn, rc *reflectionClient) (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	conn, ctx, cancel, err := dial(ctx, args)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("连接 gRPC 服务失败", err), nil
+	}
+	defer conn.Close()
+	defer cancel()
+
+	rc, err := newReflectionClient(ctx, conn)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("服务端反射不可用，请确认服务已启用 reflection: %s", formatStatus(err))), nil
+	}
+	defer rc.Close()
+	return fn(ctx, conn, rc)
+}
+
+var grpcListTool = withConnectionOptions("grpc_list",
+	mcp.WithDescription("通过服务端反射列出 gRPC 服务；指定 service 时列出该服务的方法签名。"),
+	mcp.WithString("service",
+		mcp.Description("服务全名，如 helloworld.Greeter；为空时列出全部服务"),
+	),
+)
+
+func grpcListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	return withReflection(ctx, args, func(ctx context.Context, conn *grpc.ClientConn, rc *reflectionClient) (*mcp.CallToolResult, error) {
+		service := cast.ToString(args["service"])
+		if service == "" {
+			services, err := rc.listServices()
+			if err != nil {
+				return mcp.NewToolResultError("列出服务失败: " + formatStatus(err)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Services (%d):\n%s", len(services), strings.Join(services, "\n"))), nil
+		}
+
+		d, err := rc.Resolve(service)
+		if err != nil {
+			return mcp.NewToolResultError(formatStatus(err)), nil
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("%s 不是服务", service)), nil
+		}
+		return mcp.NewToolResultText(describe(sd)), nil
+	})
+}
+
+var grpcDescribeTool = withConnectionOptions("grpc_describe",
+	mcp.WithDescription("通过服务端反射描述服务、方法、消息或枚举的定义（proto 语法），消息和方法会附带引用的类型定义和请求 JSON 示例。"),
+	mcp.WithString("symbol",
+		mcp.Required(),
+		mcp.Description("符号全名，如 helloworld.Greeter、helloworld.Greeter/SayHello、helloworld.HelloRequest"),
+	),
+)
+
+func grpcDescribeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	return withReflection(ctx, args, func(ctx context.Context, conn *grpc.ClientConn, rc *reflectionClient) (*mcp.CallToolResult, error) {
+		d, err := rc.Resolve(methodSymbol(cast.ToString(args["symbol"])))
+		if err != nil {
+			return mcp.NewToolResultError(formatStatus(err)), nil
+		}
+		return mcp.NewToolResultText(describe(d)), nil
+	})
+}
+
+var grpcInvokeTool = withConnectionOptions("grpc_invoke",
+	mcp.WithDescription(`调用 gRPC 一元方法：通过服务端反射获取方法的请求和响应类型，把 JSON 请求转换为 protobuf 发送，返回 JSON 格式的响应、响应元数据和状态。
+调用失败时返回状态码、错误信息和 status details。可先用 grpc_describe 查看请求消息的字段和 JSON 示例。`),
+	mcp.WithString("method",
+		mcp.Required(),
+		mcp.Description("方法全名，如 helloworld.Greeter/SayHello 或 helloworld.Greeter.SayHello"),
+	),
+	mcp.WithObject("request",
+		mcp.Description("请求消息，使用 protojson 格式（字段名为 lowerCamelCase 或原始名称，64 位整数可用字符串，枚举使用名称）"),
+	),
+)
+
+func grpcInvokeHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	return withReflection(ctx, args, func(ctx context.Context, conn *grpc.ClientConn, rc *reflectionClient) (*mcp.CallToolResult, error) {
+		method := cast.ToString(args["method"])
+		d, err := rc.Resolve(methodSymbol(method))
+		if err != nil {
+			return mcp.NewToolResultError(formatStatus(err)), nil
+		}
+		md, ok := d.(protoreflect.MethodDescriptor)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("%s 不是方法", method)), nil
+		}
+		if md.IsStreamingClient() || md.IsStreamingServer() {
+			return mcp.NewToolResultError(fmt.Sprintf("只支持一元方法，%s", methodSignature(md))), nil
+		}
+
+		resolver := newTypeResolver(rc.Files())
+		req := dynamicpb.NewMessage(md.Input())
+		if raw, ok := args["request"]; ok && raw != nil {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				return mcp.NewToolResultErrorFromErr("解析 request 参数失败", err), nil
+			}
+			if err := (protojson.UnmarshalOptions{Resolver: resolver}).Unmarshal(data, req); err != nil {
+				return mcp.NewToolResultErrorFromErr(fmt.Sprintf("request 不符合 %s 的定义", md.Input().FullName()), err), nil
+			}
+		}
+
+		fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+		log.Printf("gRPC 调用 %s %s", cast.ToString(args["address"]), fullMethod)
+		resp := dynamicpb.NewMessage(md.Output())
+		var header, trailer metadata.MD
+		start := time.Now()
+		err = conn.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+		elapsed := time.Since(start)
+
+		var sb strings.Builder
+		st := status.Convert(err)
+		fmt.Fprintf(&sb, "Status: %s (%d)\n", st.Code(), st.Code())
+		fmt.Fprintf(&sb, "Time: %dms\n", elapsed.Milliseconds())
+		writeMetadata(&sb, "Headers", header)
+		if err != nil {
+			fmt.Fprintf(&sb, "Message: %s\n", st.Message())
+			writeDetails(&sb, st, resolver)
+			writeMetadata(&sb, "Trailers", trailer)
+			return mcp.NewToolResultError(strings.TrimRight(sb.String(), "\n")), nil
+		}
+
+		body, err := marshalJSON(resp, resolver, "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("转换响应为 JSON 失败", err), nil
+		}
+		fmt.Fprintf(&sb, "Response (%s):\n%s\n", md.Output().FullName(), body)
+		writeMetadata(&sb, "Trailers", trailer)
+		return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+	})
+}
+
+// marshalJSON 把消息转换为 JSON，indent 非空时格式化输出
+//
+// protojson 的输出会随机加入空格，这里重新格式化以保证输出稳定。
+func marshalJSON(m proto.Message, resolver *typeResolver, indent string) (string, error) {
+	data, err := (protojson.MarshalOptions{Resolver: resolver}).Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if indent == "" {
+		err = json.Compact(&buf, data)
+	} else {
+		err = json.Indent(&buf, data, "", indent)
+	}
+	return buf.String(), err
+}
+
+// methodSymbol 把 Service/Method 形式的方法名转换为反射使用的 Service.Method
+func methodSymbol(name string) string {
+	return strings.ReplaceAll(strings.Trim(strings.TrimSpace(name), "/"), "/", ".")
+}
+
+// formatStatus 以 "Code: message" 形式显示 gRPC 错误
+func formatStatus(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return fmt.Sprintf("%s: %s", st.Code(), st.Message())
+	}
+	return err.Error()
+}
+
+// writeMetadata 按名称排序输出元数据，跳过 content-type
+func writeMetadata(sb *strings.Builder, title string, md metadata.MD) {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		if k != "content-type" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(sb, "%s:\n", title)
+	for _, k := range keys {
+		for _, v := range md[k] {
+			if strings.HasSuffix(k, "-bin") {
+				v = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			fmt.Fprintf(sb, "  %s: %s\n", k, v)
+		}
+	}
+}
+
+// writeDetails 输出 status details，无法识别的类型显示 type URL 和 base64 内容
+func writeDetails(sb *strings.Builder, st *status.Status, resolver *typeResolver) {
+	details := st.Proto().GetDetails()
+	if len(details) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "Details (%d):\n", len(details))
+	for i, detail := range details {
+		data, err := marshalJSON(detail, resolver, "")
+		if err != nil {
+			fmt.Fprintf(sb, "  %d. %s: %s\n", i+1, detail.GetTypeUrl(), base64.StdEncoding.EncodeToString(detail.GetValue()))
+			continue
+		}
+		fmt.Fprintf(sb, "  %d. %s\n", i+1, data)
+	}
+}
+
+// typeResolver 先查找反射得到的类型，再查找本进程注册的类型（well-known 类型、google.rpc 错误详情等）
+type typeResolver struct {
+	types *dynamicpb.Types
+}
+
+func newTypeResolver(files *protoregistry.Files) *typeResolver {
+	return &typeResolver{types: dynamicpb.NewTypes(files)}
+}
+
+func (r *typeResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if mt, err := r.types.FindMessageByName(name); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+func (r *typeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if mt, err := r.types.FindMessageByURL(url); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByURL(url)
+}
+
+func (r *typeResolver) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if xt, err := r.types.FindExtensionByName(name); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(name)
+}
+
+func (r *typeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if xt, err := r.types.FindExtensionByNumber(message, field); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
@@ -0,0 +1,394 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// echoProto 测试服务的定义:
+//
+//	package test.echo;
+//	import "google/protobuf/timestamp.proto";
+//	enum Mode { MODE_UNSPECIFIED = 0; LOUD = 1; }
+//	message EchoRequest {
+//	  message Meta { string trace_id = 1; }
+//	  string message = 1; int32 repeat = 2; Mode mode = 3; repeated string tags = 4;
+//	  Meta meta = 5; map<string, int64> counts = 6; google.protobuf.Timestamp at = 7;
+//	}
+//	message EchoResponse { repeated string messages = 1; }
+//	service Echo {
+//	  rpc Say(EchoRequest) returns (EchoResponse);
+//	  rpc Watch(EchoRequest) returns (stream EchoResponse);
+//	}
+var echoProto = &descriptorpb.FileDescriptorProto{
+	Name:       proto.String("test/echo.proto"),
+	Package:    proto.String("test.echo"),
+	Dependency: []string{"google/protobuf/timestamp.proto"},
+	Syntax:     proto.String("proto3"),
+	EnumType: []*descriptorpb.EnumDescriptorProto{{
+		Name: proto.String("Mode"),
+		Value: []*descriptorpb.EnumValueDescriptorProto{
+			{Name: proto.String("MODE_UNSPECIFIED"), Number: proto.Int32(0)},
+			{Name: proto.String("LOUD"), Number: proto.Int32(1)},
+		},
+	}},
+	MessageType: []*descriptorpb.DescriptorProto{
+		{
+			Name: proto.String("EchoRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("repeat", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				field("mode", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.echo.Mode"),
+				repeated(field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")),
+				field("meta", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.echo.EchoRequest.Meta"),
+				repeated(field("counts", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.echo.EchoRequest.CountsEntry")),
+				field("at", 7, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{
+				{
+					Name:  proto.String("Meta"),
+					Field: []*descriptorpb.FieldDescriptorProto{field("trace_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")},
+				},
+				{
+					Name: proto.String("CountsEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+						field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				},
+			},
+		},
+		{
+			Name:  proto.String("EchoResponse"),
+			Field: []*descriptorpb.FieldDescriptorProto{repeated(field("messages", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""))},
+		},
+	},
+	Service: []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("Echo"),
+		Method: []*descriptorpb.MethodDescriptorProto{
+			{Name: proto.String("Say"), InputType: proto.String(".test.echo.EchoRequest"), OutputType: proto.String(".test.echo.EchoResponse")},
+			{Name: proto.String("Watch"), InputType: proto.String(".test.echo.EchoRequest"), OutputType: proto.String(".test.echo.EchoResponse"), ServerStreaming: proto.Bool(true)},
+		},
+	}},
+}
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+// echoResolver 先查找测试服务的定义，再查找全局注册的描述符（健康检查、反射服务等）
+type echoResolver struct {
+	files *protoregistry.Files
+}
+
+func (r echoResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r echoResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// TestMain 测试服务监听在回环地址上，默认放行回环地址
+func TestMain(m *testing.M) {
+	policy, err := helper.NewURLPolicy(&helper.URLPolicyConfig{AllowCIDRs: []string{"127.0.0.0/8"}})
+	if err != nil {
+		panic(err)
+	}
+	getURLPolicy = func() *helper.URLPolicy { return policy }
+	os.Exit(m.Run())
+}
+
+// setURLPolicy 在测试期间替换出站策略
+func setURLPolicy(t *testing.T, cfg *helper.URLPolicyConfig) {
+	t.Helper()
+	policy, err := helper.NewURLPolicy(cfg)
+	require.NoError(t, err)
+	orig := getURLPolicy
+	getURLPolicy = func() *helper.URLPolicy { return policy }
+	t.Cleanup(func() { getURLPolicy = orig })
+}
+
+// newTestServer 启动带反射、健康检查和 test.echo.Echo 服务的 gRPC 服务，返回服务地址
+//
+// Say 返回重复 repeat 次的 message，并在响应头中带上请求元数据 x-user；message 为 "fail" 时返回带 ErrorInfo 的错误。
+func newTestServer(t *testing.T) string {
+	fd, err := protodesc.NewFile(echoProto, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	files := new(protoregistry.Files)
+	require.NoError(t, files.RegisterFile(fd))
+	service := fd.Services().ByName("Echo")
+	say := service.Methods().ByName("Say")
+
+	s := grpc.NewServer()
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.echo.Echo",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Say",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := dynamicpb.NewMessage(say.Input())
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				msg := req.Get(say.Input().Fields().ByName("message")).String()
+				if msg == "fail" {
+					st, _ := status.New(codes.FailedPrecondition, "echo disabled").WithDetails(&errdetails.ErrorInfo{Reason: "DISABLED", Domain: "echo.test"})
+					return nil, st.Err()
+				}
+				if md, ok := metadata.FromIncomingContext(ctx); ok {
+					grpc.SetHeader(ctx, metadata.Pairs("x-user", strings.Join(md.Get("x-user"), ",")))
+				}
+				resp := dynamicpb.NewMessage(say.Output())
+				list := resp.Mutable(say.Output().Fields().ByName("messages")).List()
+				for range req.Get(say.Input().Fields().ByName("repeat")).Int() {
+					list.Append(protoreflect.ValueOfString(msg))
+				}
+				return resp, nil
+			},
+		}},
+		Streams: []grpc.StreamDesc{{StreamName: "Watch", ServerStreams: true, Handler: func(any, grpc.ServerStream) error { return nil }}},
+	}, struct{}{})
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	opts := reflection.ServerOptions{Services: s, DescriptorResolver: echoResolver{files}}
+	reflectionv1.RegisterServerReflectionServer(s, reflection.NewServerV1(opts))
+	reflectionv1alpha.RegisterServerReflectionServer(s, reflection.NewServer(opts))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func callTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Content)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestGrpcTools(t *testing.T) {
+	addr := newTestServer(t)
+
+	t.Run("列出服务", func(t *testing.T) {
+		text, isErr := callTool(t, grpcListHandler, map[string]any{"address": addr})
+		require.False(t, isErr, text)
+		assert.Equal(t, `Services (4):
+grpc.health.v1.Health
+grpc.reflection.v1.ServerReflection
+grpc.reflection.v1alpha.ServerReflection
+test.echo.Echo`, text)
+	})
+
+	t.Run("列出方法", func(t *testing.T) {
+		text, isErr := callTool(t, grpcListHandler, map[string]any{"address": addr, "service": "test.echo.Echo"})
+		require.False(t, isErr, text)
+		assert.Equal(t, `service test.echo.Echo {
+  rpc Say(test.echo.EchoRequest) returns (test.echo.EchoResponse);
+  rpc Watch(test.echo.EchoRequest) returns (stream test.echo.EchoResponse);
+}`, text)
+	})
+
+	t.Run("描述方法", func(t *testing.T) {
+		text, isErr := callTool(t, grpcDescribeHandler, map[string]any{"address": addr, "symbol": "test.echo.Echo/Say"})
+		require.False(t, isErr, text)
+		assert.Equal(t, `rpc Say(test.echo.EchoRequest) returns (test.echo.EchoResponse);
+
+message test.echo.EchoRequest {
+  string message = 1;
+  int32 repeat = 2;
+  test.echo.Mode mode = 3;
+  repeated string tags = 4;
+  test.echo.EchoRequest.Meta meta = 5;
+  map<string, int64> counts = 6;
+  google.protobuf.Timestamp at = 7;
+}
+
+message test.echo.EchoResponse {
+  repeated string messages = 1;
+}
+
+enum test.echo.Mode {
+  MODE_UNSPECIFIED = 0;
+  LOUD = 1;
+}
+
+message test.echo.EchoRequest.Meta {
+  string trace_id = 1;
+}
+
+JSON 示例 (test.echo.EchoRequest):
+{
+  "at": "1970-01-01T00:00:00Z",
+  "counts": {
+    "key": "0"
+  },
+  "message": "",
+  "meta": {
+    "traceId": ""
+  },
+  "mode": "MODE_UNSPECIFIED",
+  "repeat": 0,
+  "tags": [
+    ""
+  ]
+}`, text)
+	})
+
+	t.Run("调用一元方法", func(t *testing.T) {
+		text, isErr := callTool(t, grpcInvokeHandler, map[string]any{
+			"address": addr,
+			"method":  "test.echo.Echo/Say",
+			"headers": map[string]any{"x-user": "alice"},
+			"request": map[string]any{"message": "hi", "repeat": 2, "mode": "LOUD", "at": "2024-01-02T03:04:05Z"},
+		})
+		require.False(t, isErr, text)
+		assert.Regexp(t, `^Status: OK \(0\)\nTime: \d+ms\nHeaders:\n  x-user: alice\nResponse \(test.echo.EchoResponse\):\n`, text)
+		assert.Contains(t, text, `"messages": [
+    "hi",
+    "hi"
+  ]`)
+
+		text, isErr = callTool(t, grpcInvokeHandler, map[string]any{
+			"address": addr,
+			"method":  "grpc.health.v1.Health.Check",
+			"request": map[string]any{},
+		})
+		require.False(t, isErr, text)
+		assert.Contains(t, text, `"status": "SERVING"`)
+	})
+
+	t.Run("返回状态和错误详情", func(t *testing.T) {
+		text, isErr := callTool(t, grpcInvokeHandler, map[string]any{
+			"address": addr,
+			"method":  "test.echo.Echo/Say",
+			"request": map[string]any{"message": "fail"},
+		})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "Status: FailedPrecondition (9)\n")
+		assert.Contains(t, text, "Message: echo disabled\nDetails (1):\n  1. ")
+		assert.Contains(t, text, `"@type":"type.googleapis.com/google.rpc.ErrorInfo"`)
+		assert.Contains(t, text, `"reason":"DISABLED"`)
+
+		text, isErr = callTool(t, grpcInvokeHandler, map[string]any{
+			"address": addr,
+			"method":  "grpc.health.v1.Health/Check",
+			"request": map[string]any{"service": "unknown"},
+		})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "Status: NotFound (5)\n")
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		text, isErr := callTool(t, grpcInvokeHandler, map[string]any{
+			"address": addr,
+			"method":  "test.echo.Echo/Say",
+			"request": map[string]any{"unknown": 1},
+		})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "request 不符合 test.echo.EchoRequest 的定义")
+
+		text, isErr = callTool(t, grpcInvokeHandler, map[string]any{"address": addr, "method": "test.echo.Echo/Watch"})
+		assert.True(t, isErr)
+		assert.Equal(t, "只支持一元方法，rpc Watch(test.echo.EchoRequest) returns (stream test.echo.EchoResponse);", text)
+
+		text, isErr = callTool(t, grpcDescribeHandler, map[string]any{"address": addr, "symbol": "test.echo.Missing"})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "服务端没有找到符号 test.echo.Missing")
+	})
+}
+
+func TestGrpcTools_Policy(t *testing.T) {
+	addr := newTestServer(t)
+
+	t.Run("默认拒绝回环地址", func(t *testing.T) {
+		setURLPolicy(t, nil)
+		text, isErr := callTool(t, grpcListHandler, map[string]any{"address": addr})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "规则 default:loopback 禁止访问 127.0.0.1")
+
+		text, isErr = callTool(t, grpcListHandler, map[string]any{"address": "dns:///" + addr})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "规则 default:loopback")
+	})
+
+	t.Run("allow_cidrs 放行回环地址", func(t *testing.T) {
+		setURLPolicy(t, &helper.URLPolicyConfig{AllowCIDRs: []string{"127.0.0.1"}})
+		text, isErr := callTool(t, grpcListHandler, map[string]any{"address": addr})
+		require.False(t, isErr, text)
+		assert.Contains(t, text, "test.echo.Echo")
+	})
+
+	t.Run("连接时检查解析后的地址", func(t *testing.T) {
+		// 模拟 DNS 重绑定：检查主机名时解析到公网地址，建立连接时解析到回环地址
+		policy, err := helper.NewURLPolicy(nil)
+		require.NoError(t, err)
+		var lookups atomic.Int32
+		policy.Lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+			if lookups.Add(1) == 1 {
+				return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, nil
+			}
+			return []netip.Addr{netip.MustParseAddr("127.0.0.1")}, nil
+		}
+		orig := getURLPolicy
+		getURLPolicy = func() *helper.URLPolicy { return policy }
+		t.Cleanup(func() { getURLPolicy = orig })
+
+		_, port, _ := net.SplitHostPort(addr)
+		text, isErr := callTool(t, grpcListHandler, map[string]any{"address": "passthrough:///rebind.test:" + port})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "规则 default:loopback 禁止访问 127.0.0.1")
+	})
+}
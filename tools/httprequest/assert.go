@@ -0,0 +1,386 @@
+package httprequest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// Assertion http_assert 的一条断言
+type Assertion struct {
+	Type   string // status | header | jsonpath | responseTime | jsonSchema
+	Name   string // header 断言的响应头名称
+	Path   string // jsonpath 断言的表达式
+	Op     string // exists | notExists | equals | notEquals | contains | matches
+	Value  any    // 期望值；status 支持 200、"2xx" 或 [200, 201]，responseTime 为毫秒上限
+	Schema any    // jsonSchema 断言的 schema
+}
+
+// AssertionResult 断言的执行结果
+type AssertionResult struct {
+	Assertion Assertion
+	Passed    bool
+	Message   string // 断言描述
+	Detail    string // 失败原因
+}
+
+// assertResponse 断言使用的响应数据
+type assertResponse struct {
+	*helper.HttpResponse
+	Duration time.Duration
+
+	json    any
+	jsonErr error
+	parsed  bool
+}
+
+// JSON 返回解析后的响应体，只解析一次
+func (r *assertResponse) JSON() (any, error) {
+	if !r.parsed {
+		r.parsed = true
+		r.jsonErr = json.Unmarshal(r.Body, &r.json)
+	}
+	return r.json, r.jsonErr
+}
+
+// parseAssertions 解析 assertions 参数
+func parseAssertions(arg any) ([]Assertion, error) {
+	items, ok := arg.([]any)
+	if !ok || len(items) == 0 {
+		return nil, errors.New("assertions 参数必须是非空数组")
+	}
+
+	out := make([]Assertion, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("第 %d 条断言必须是对象", i+1)
+		}
+		a := Assertion{
+			Type:   cast.ToString(m["type"]),
+			Name:   cast.ToString(m["name"]),
+			Path:   cast.ToString(m["path"]),
+			Op:     cast.ToString(m["op"]),
+			Value:  m["value"],
+			Schema: m["schema"],
+		}
+		switch a.Type {
+		case "status", "responseTime":
+			if a.Value == nil {
+				return nil, fmt.Errorf("第 %d 条 %s 断言缺少 value", i+1, a.Type)
+			}
+		case "header":
+			if a.Name == "" {
+				return nil, fmt.Errorf("第 %d 条 header 断言缺少 name", i+1)
+			}
+		case "jsonpath":
+			if a.Path == "" {
+				return nil, fmt.Errorf("第 %d 条 jsonpath 断言缺少 path", i+1)
+			}
+		case "jsonSchema":
+			if a.Schema == nil {
+				return nil, fmt.Errorf("第 %d 条 jsonSchema 断言缺少 schema", i+1)
+			}
+		default:
+			return nil, fmt.Errorf("第 %d 条断言的 type %q 无效", i+1, a.Type)
+		}
+		if a.Op == "" {
+			a.Op = "equals"
+			if a.Value == nil {
+				a.Op = "exists"
+			}
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// Evaluate 对响应执行断言
+func (a Assertion) Evaluate(resp *assertResponse) AssertionResult {
+	switch a.Type {
+	case "status":
+		return a.evalStatus(resp)
+	case "header":
+		return a.evalHeader(resp)
+	case "jsonpath":
+		return a.evalJSONPath(resp)
+	case "responseTime":
+		limit := cast.ToInt64(a.Value)
+		res := AssertionResult{Assertion: a, Message: fmt.Sprintf("response time < %dms", limit)}
+		res.Passed = resp.Duration.Milliseconds() < limit
+		if !res.Passed {
+			res.Detail = fmt.Sprintf("actual %dms", resp.Duration.Milliseconds())
+		}
+		return res
+	case "jsonSchema":
+		res := AssertionResult{Assertion: a, Message: "body matches JSON Schema"}
+		data, err := resp.JSON()
+		if err != nil {
+			res.Detail = "响应体不是 JSON: " + err.Error()
+			return res
+		}
+		errs := ValidateJSONSchema(a.Schema, data)
+		res.Passed = len(errs) == 0
+		if len(errs) > 5 {
+			errs = append(errs[:5], fmt.Sprintf("...(共 %d 处)", len(errs)))
+		}
+		res.Detail = strings.Join(errs, "; ")
+		return res
+	}
+	return AssertionResult{Assertion: a, Message: a.Type, Detail: "未知的断言类型"}
+}
+
+func (a Assertion) evalStatus(resp *assertResponse) AssertionResult {
+	res := AssertionResult{Assertion: a, Message: "status " + a.Op + " " + displayValue(a.Value)}
+	matched := false
+	switch v := a.Value.(type) {
+	case []any:
+		for _, item := range v {
+			matched = matched || statusMatches(resp.StatusCode, item)
+		}
+	default:
+		matched = statusMatches(resp.StatusCode, v)
+	}
+	res.Passed = matched == (a.Op != "notEquals")
+	if !res.Passed {
+		res.Detail = fmt.Sprintf("actual %d", resp.StatusCode)
+	}
+	return res
+}
+
+// statusMatches 判断状态码是否匹配期望值，支持 "2xx" 形式
+func statusMatches(status int, want any) bool {
+	if s, ok := want.(string); ok && len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") {
+		return strconv.Itoa(status)[0] == s[0]
+	}
+	return status == cast.ToInt(want)
+}
+
+func (a Assertion) evalHeader(resp *assertResponse) AssertionResult {
+	res := AssertionResult{Assertion: a, Message: "header " + a.Name + " " + a.Op}
+	if a.Value != nil {
+		res.Message += " " + displayValue(a.Value)
+	}
+	values, exists := resp.Header[http.CanonicalHeaderKey(a.Name)]
+	actual := strings.Join(values, ", ")
+	switch a.Op {
+	case "exists":
+		res.Passed = exists
+	case "notExists":
+		res.Passed = !exists
+	case "equals":
+		res.Passed = exists && actual == cast.ToString(a.Value)
+	case "notEquals":
+		res.Passed = actual != cast.ToString(a.Value)
+	case "contains":
+		res.Passed = exists && strings.Contains(actual, cast.ToString(a.Value))
+	case "matches":
+		res.Passed, res.Detail = regexMatches(cast.ToString(a.Value), actual)
+		res.Passed = exists && res.Passed
+	default:
+		res.Detail = "不支持的 op " + a.Op
+		return res
+	}
+	if !res.Passed && res.Detail == "" {
+		if exists {
+			res.Detail = "actual " + strconv.Quote(actual)
+		} else {
+			res.Detail = "header not found"
+		}
+	}
+	return res
+}
+
+func (a Assertion) evalJSONPath(resp *assertResponse) AssertionResult {
+	res := AssertionResult{Assertion: a, Message: a.Path + " " + a.Op}
+	if a.Value != nil {
+		res.Message += " " + displayValue(a.Value)
+	}
+	data, err := resp.JSON()
+	if err != nil {
+		res.Detail = "响应体不是 JSON: " + err.Error()
+		return res
+	}
+	actual, err := EvalJSONPath(data, a.Path)
+	exists := err == nil
+	if list, ok := actual.([]any); ok && exists && isMultiPath(a.Path) {
+		exists = len(list) > 0
+	}
+
+	switch a.Op {
+	case "exists":
+		res.Passed = exists
+	case "notExists":
+		res.Passed = !exists
+	case "equals":
+		res.Passed = exists && jsonEqual(actual, a.Value)
+	case "notEquals":
+		res.Passed = !exists || !jsonEqual(actual, a.Value)
+	case "contains":
+		res.Passed = exists && jsonContains(actual, a.Value)
+	case "matches":
+		res.Passed, res.Detail = regexMatches(cast.ToString(a.Value), displayString(actual))
+		res.Passed = exists && res.Passed
+	default:
+		res.Detail = "不支持的 op " + a.Op
+		return res
+	}
+	if !res.Passed && res.Detail == "" {
+		if exists {
+			res.Detail = "actual " + diffValue(actual)
+		} else {
+			res.Detail = "path not found"
+		}
+	}
+	return res
+}
+
+// isMultiPath 判断表达式是否包含通配或递归，此时结果为列表，空列表视为不存在
+func isMultiPath(path string) bool {
+	return strings.Contains(path, "*") || strings.Contains(path, "..") || strings.Contains(path, "[]")
+}
+
+// jsonContains 字符串判断子串，数组判断是否包含元素，对象判断是否包含字段
+func jsonContains(actual, want any) bool {
+	switch v := actual.(type) {
+	case string:
+		return strings.Contains(v, cast.ToString(want))
+	case []any:
+		for _, item := range v {
+			if jsonEqual(item, want) {
+				return true
+			}
+		}
+	case map[string]any:
+		_, ok := v[cast.ToString(want)]
+		return ok
+	}
+	return false
+}
+
+func regexMatches(pattern, s string) (bool, string) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, "正则表达式无效: " + err.Error()
+	}
+	return re.MatchString(s), ""
+}
+
+// displayString 字符串原样返回，其他值返回 JSON
+func displayString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// displayValue 返回报告中显示的期望值
+func displayValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return diffValue(v)
+}
+
+// formatAssertReport 生成断言报告
+func formatAssertReport(resp *assertResponse, results []AssertionResult) string {
+	passed := 0
+	for _, r := range results {
+		if r.Passed {
+			passed++
+		}
+	}
+
+	var sb strings.Builder
+	if passed == len(results) {
+		fmt.Fprintf(&sb, "PASS: %d/%d assertions passed\n", passed, len(results))
+	} else {
+		fmt.Fprintf(&sb, "FAIL: %d/%d assertions passed\n", passed, len(results))
+	}
+	fmt.Fprintf(&sb, "Status: %d, Time: %dms, Body: %d bytes\n", resp.StatusCode, resp.Duration.Milliseconds(), len(resp.Body))
+	for i, r := range results {
+		mark := "PASS"
+		if !r.Passed {
+			mark = "FAIL"
+		}
+		fmt.Fprintf(&sb, "[%s] %d. %s", mark, i+1, r.Message)
+		if r.Detail != "" {
+			fmt.Fprintf(&sb, " — %s", r.Detail)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// httpAssertTool 在 http_request 请求参数的基础上增加 assertions 参数
+var httpAssertTool = withRequestParams(mcp.NewTool("http_assert",
+	mcp.WithDescription(`执行 HTTP 请求并逐条检查断言，返回每条断言的 PASS/FAIL 报告，用于快速验证接口契约。
+请求参数与 http_request 相同；assertions 中每一项的 type 取值：
+- status: value 为 200、"2xx" 或 [200, 201]；op 可为 equals（默认）、notEquals
+- header: name 为响应头名称；op 为 exists（未给 value 时默认）、notExists、equals、notEquals、contains、matches（正则）
+- jsonpath: path 为 JSONPath 表达式（如 $.data.items[0].id）；op 为 exists、notExists、equals、notEquals、contains（子串/数组元素/对象字段）、matches（正则）
+- responseTime: value 为毫秒上限，响应时间小于该值时通过
+- jsonSchema: schema 为 JSON Schema 对象，校验整个响应体
+
+示例：
+[
+	{"type": "status", "value": 200},
+	{"type": "header", "name": "Content-Type", "op": "contains", "value": "application/json"},
+	{"type": "jsonpath", "path": "$.data.id", "value": 42},
+	{"type": "jsonpath", "path": "$.data.email", "op": "matches", "value": "^.+@example\\.com$"},
+	{"type": "responseTime", "value": 500},
+	{"type": "jsonSchema", "schema": {"type": "object", "required": ["data"]}}
+]`),
+	mcp.WithArray("assertions",
+		mcp.Required(),
+		mcp.Description("断言列表"),
+		mcp.Items(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"type":   map[string]any{"type": "string", "enum": []string{"status", "header", "jsonpath", "responseTime", "jsonSchema"}},
+				"name":   map[string]any{"type": "string", "description": "Response header name for header assertions"},
+				"path":   map[string]any{"type": "string", "description": "JSONPath expression for jsonpath assertions"},
+				"op":     map[string]any{"type": "string", "enum": []string{"exists", "notExists", "equals", "notEquals", "contains", "matches"}},
+				"value":  map[string]any{"description": "Expected value"},
+				"schema": map[string]any{"type": "object", "description": "JSON Schema for jsonSchema assertions"},
+			},
+			"required": []string{"type"},
+		}),
+	),
+))
+
+func httpAssertHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	assertions, err := parseAssertions(args["assertions"])
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("解析断言失败", err), nil
+	}
+	req, err := buildRequest(args)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("解析请求参数失败", err), nil
+	}
+
+	start := time.Now()
+	resp, err := sendRequest(ctx, req)
+	if err != nil {
+		return requestErrorResult(err), nil
+	}
+	ar := &assertResponse{HttpResponse: resp, Duration: time.Since(start)}
+
+	results := make([]AssertionResult, len(assertions))
+	for i, a := range assertions {
+		results[i] = a.Evaluate(ar)
+	}
+	return mcp.NewToolResultText(formatAssertReport(ar, results)), nil
+}
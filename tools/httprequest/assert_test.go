@@ -0,0 +1,80 @@
+package httprequest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpAssertHandler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Request-Id", "req-123")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data": {"id": 42, "email": "bob@example.com", "tags": ["a", "b"]}}`))
+	}))
+	defer srv.Close()
+
+	call := func(assertions ...any) string {
+		result, err := httpAssertHandler(context.Background(), newCallToolRequest("http_assert", map[string]any{
+			"method":     "POST",
+			"url":        srv.URL + "/users",
+			"json":       map[string]any{"email": "bob@example.com"},
+			"assertions": assertions,
+		}))
+		require.NoError(t, err)
+		return resultText(t, result)
+	}
+
+	text := call(
+		map[string]any{"type": "status", "value": "2xx"},
+		map[string]any{"type": "header", "name": "x-request-id"},
+		map[string]any{"type": "header", "name": "Content-Type", "op": "contains", "value": "application/json"},
+		map[string]any{"type": "jsonpath", "path": "$.data.id", "value": float64(42)},
+		map[string]any{"type": "jsonpath", "path": "$.data.tags", "op": "contains", "value": "b"},
+		map[string]any{"type": "jsonpath", "path": "$.data.email", "op": "matches", "value": `^.+@example\.com$`},
+		map[string]any{"type": "jsonpath", "path": "$.data.deleted", "op": "notExists"},
+		map[string]any{"type": "responseTime", "value": float64(5000)},
+		map[string]any{"type": "jsonSchema", "schema": map[string]any{
+			"type":     "object",
+			"required": []any{"data"},
+			"properties": map[string]any{
+				"data": map[string]any{"type": "object", "required": []any{"id", "email"}},
+			},
+		}},
+	)
+	assert.Contains(t, text, "PASS: 9/9 assertions passed")
+	assert.Contains(t, text, "Status: 201")
+	assert.Contains(t, text, "[PASS] 4. $.data.id equals 42")
+
+	text = call(
+		map[string]any{"type": "status", "value": []any{float64(200), float64(204)}},
+		map[string]any{"type": "header", "name": "X-Request-Id", "value": "other"},
+		map[string]any{"type": "jsonpath", "path": "$.data.name", "value": "bob"},
+		map[string]any{"type": "jsonpath", "path": "$.data.id", "value": "42"},
+		map[string]any{"type": "responseTime", "value": float64(0)},
+		map[string]any{"type": "jsonSchema", "schema": map[string]any{
+			"properties": map[string]any{"data": map[string]any{"properties": map[string]any{"id": map[string]any{"type": "string"}}}},
+		}},
+	)
+	assert.Contains(t, text, "FAIL: 0/6 assertions passed")
+	assert.Contains(t, text, `[FAIL] 1. status equals [200,204] — actual 201`)
+	assert.Contains(t, text, `[FAIL] 2. header X-Request-Id equals "other" — actual "req-123"`)
+	assert.Contains(t, text, `[FAIL] 3. $.data.name equals "bob" — path not found`)
+	assert.Contains(t, text, `[FAIL] 4. $.data.id equals "42" — actual 42`)
+	assert.Contains(t, text, `[FAIL] 5. response time < 0ms — actual`)
+	assert.Contains(t, text, `[FAIL] 6. body matches JSON Schema — $.data.id: 类型应为 string，实际为 integer`)
+
+	result, err := httpAssertHandler(context.Background(), newCallToolRequest("http_assert", map[string]any{
+		"method":     "GET",
+		"url":        srv.URL,
+		"assertions": []any{map[string]any{"type": "jsonpath"}},
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "缺少 path")
+}
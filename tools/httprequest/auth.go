@@ -0,0 +1,366 @@
+package httprequest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// AuthProfile 认证配置，在 http.yaml 的 profiles 区块中按名称配置
+//
+// 所有字段均支持 ${ENV} 形式引用环境变量，避免把密钥直接写入配置文件。
+type AuthProfile struct {
+	Type  string   `mapstructure:"type" json:"type" yaml:"type"`    // bearer | basic | apikey | hmac | oauth2
+	Hosts []string `mapstructure:"hosts" json:"hosts" yaml:"hosts"` // 允许使用该配置的主机，支持 *.example.com，必填
+
+	Token    string `mapstructure:"token" json:"token" yaml:"token"`          // bearer: 令牌
+	Username string `mapstructure:"username" json:"username" yaml:"username"` // basic: 用户名
+	Password string `mapstructure:"password" json:"password" yaml:"password"` // basic: 密码
+
+	Name  string `mapstructure:"name" json:"name" yaml:"name"`    // apikey: 请求头或查询参数名称
+	Value string `mapstructure:"value" json:"value" yaml:"value"` // apikey: 密钥
+	In    string `mapstructure:"in" json:"in" yaml:"in"`          // apikey: header | query，默认 header
+
+	Secret          string `mapstructure:"secret" json:"secret" yaml:"secret"`                               // hmac: 签名密钥
+	Algorithm       string `mapstructure:"algorithm" json:"algorithm" yaml:"algorithm"`                      // hmac: sha256 | sha1 | sha512，默认 sha256
+	KeyID           string `mapstructure:"key_id" json:"key_id" yaml:"key_id"`                               // hmac: 密钥标识，非空时写入 KeyIDHeader
+	KeyIDHeader     string `mapstructure:"key_id_header" json:"key_id_header" yaml:"key_id_header"`          // hmac: 默认 X-Key-Id
+	SignatureHeader string `mapstructure:"signature_header" json:"signature_header" yaml:"signature_header"` // hmac: 默认 X-Signature
+	TimestampHeader string `mapstructure:"timestamp_header" json:"timestamp_header" yaml:"timestamp_header"` // hmac: 默认 X-Timestamp
+
+	TokenURL     string   `mapstructure:"token_url" json:"token_url" yaml:"token_url"`             // oauth2: 令牌地址
+	ClientID     string   `mapstructure:"client_id" json:"client_id" yaml:"client_id"`             // oauth2: 客户端 ID
+	ClientSecret string   `mapstructure:"client_secret" json:"client_secret" yaml:"client_secret"` // oauth2: 客户端密钥
+	Scopes       []string `mapstructure:"scopes" json:"scopes" yaml:"scopes"`                      // oauth2: 授权范围
+}
+
+// getAuthProfiles 读取认证配置，测试中可替换
+var getAuthProfiles = func() (map[string]*AuthProfile, error) {
+	profiles := make(map[string]*AuthProfile)
+	if err := helper.HttpConfig().UnmarshalKey("profiles", &profiles); err != nil {
+		return nil, fmt.Errorf("解析认证配置失败: %w", err)
+	}
+	return profiles, nil
+}
+
+// oauth2Token 缓存的 OAuth2 访问令牌
+type oauth2Token struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// oauth2TokenEntry 单个认证配置的令牌缓存，获取令牌时只锁定该配置，不阻塞其他配置
+type oauth2TokenEntry struct {
+	mu    sync.Mutex
+	token *oauth2Token
+}
+
+var (
+	oauth2TokensLock sync.Mutex
+	oauth2Tokens     = make(map[string]*oauth2TokenEntry) // 键为小写的配置名称
+)
+
+// oauth2ExpiryMargin 令牌提前过期的时间，避免请求途中令牌失效
+const oauth2ExpiryMargin = 30 * time.Second
+
+var httpAuthProfilesTool = mcp.NewTool("http_auth_profiles",
+	mcp.WithDescription("列出已配置的认证配置（名称、类型和允许的主机），不会输出任何密钥。调用 http_request 等工具时通过 profile 参数引用。"),
+)
+
+// profileToolOption 请求工具的 profile 参数
+var profileToolOption = mcp.WithString("profile",
+	mcp.Description("认证配置名称（见 http_auth_profiles），由服务端注入认证信息；不要在 headers 中自行填写令牌"),
+)
+
+func httpAuthProfilesHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	profiles, err := loadAuthProfiles()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("读取认证配置失败", err), nil
+	}
+	if len(profiles) == 0 {
+		return mcp.NewToolResultText("没有配置认证信息，请在 http.yaml 的 profiles 中添加"), nil
+	}
+
+	var sb strings.Builder
+	for _, name := range sortedKeys(profiles) {
+		p := profiles[name]
+		fmt.Fprintf(&sb, "%s: type=%s hosts=%s\n", name, p.Type, strings.Join(p.Hosts, ", "))
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+// loadAuthProfiles 读取认证配置，并把各配置注入的请求头和查询参数名称加入日志脱敏列表
+//
+// 未配置 hosts 的认证配置会被拒绝：URL 由模型决定，不限制主机时一次提示注入即可把密钥发往任意地址。
+func loadAuthProfiles() (map[string]*AuthProfile, error) {
+	profiles, err := getAuthProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(profiles) {
+		if len(profiles[name].Hosts) == 0 {
+			return nil, fmt.Errorf("认证配置 %s 缺少 hosts，必须指定允许使用该配置的主机", name)
+		}
+	}
+	names := &helper.RedactConfig{}
+	for _, p := range profiles {
+		if strings.EqualFold(p.Type, "apikey") && strings.EqualFold(p.In, "query") {
+			names.QueryParams = append(names.QueryParams, p.Name)
+			continue
+		}
+		names.Headers = append(names.Headers, p.headerNames()...)
+	}
+	helper.AddRedactNames(names)
+	return profiles, nil
+}
+
+// lookupAuthProfile 按名称查找认证配置
+func lookupAuthProfile(name string) (*AuthProfile, error) {
+	profiles, err := loadAuthProfiles()
+	if err != nil {
+		return nil, err
+	}
+	// viper 读取的配置名称为小写
+	profile, ok := profiles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("认证配置 %s 不存在", name)
+	}
+	return profile, nil
+}
+
+// headerNames 返回认证配置注入的请求头名称
+func (p *AuthProfile) headerNames() []string {
+	switch strings.ToLower(p.Type) {
+	case "apikey":
+		if strings.EqualFold(p.In, "query") {
+			return nil
+		}
+		return []string{p.Name}
+	case "hmac":
+		return []string{
+			headerOr(p.TimestampHeader, "X-Timestamp"),
+			headerOr(p.SignatureHeader, "X-Signature"),
+			headerOr(p.KeyIDHeader, "X-Key-Id"),
+		}
+	}
+	return []string{"Authorization"}
+}
+
+// profileRedirectClient 返回跟随重定向时检查目标主机的客户端
+//
+// 标准库只在跨域重定向时移除 Authorization 和 Cookie，自定义的 API Key、签名请求头会原样发给新主机。
+// 重定向目标不在认证配置的 hosts 中时移除认证配置注入的请求头。
+func profileRedirectClient(base *http.Client, profile *AuthProfile) *http.Client {
+	client := *base
+	check := base.CheckRedirect
+	names := profile.headerNames()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !helper.MatchHost(req.URL.Hostname(), profile.Hosts) {
+			for _, name := range names {
+				req.Header.Del(name)
+			}
+		}
+		if check != nil {
+			return check(req, via)
+		}
+		return nil
+	}
+	return &client
+}
+
+// applyAuthProfile 按请求的 Profile 注入认证信息，返回新的请求，原请求不变
+func applyAuthProfile(ctx context.Context, req *Request) (*Request, error) {
+	if req.Profile == "" {
+		return req, nil
+	}
+
+	profile, err := lookupAuthProfile(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !helper.MatchHost(u.Hostname(), profile.Hosts) {
+		return nil, fmt.Errorf("认证配置 %s 不允许用于主机 %s", req.Profile, u.Hostname())
+	}
+
+	out := req.Clone()
+	switch strings.ToLower(profile.Type) {
+	case "bearer":
+		out.Header.Set("Authorization", "Bearer "+os.ExpandEnv(profile.Token))
+	case "basic":
+		out.Header.Set("Authorization", basicAuth(os.ExpandEnv(profile.Username), os.ExpandEnv(profile.Password)))
+	case "apikey":
+		if profile.Name == "" {
+			return nil, fmt.Errorf("认证配置 %s 缺少 name", req.Profile)
+		}
+		value := os.ExpandEnv(profile.Value)
+		if strings.EqualFold(profile.In, "query") {
+			u.RawQuery = setRawQuery(u.RawQuery, profile.Name, url.QueryEscape(value))
+			out.URL = u.String()
+		} else {
+			out.Header.Set(profile.Name, value)
+		}
+	case "hmac":
+		if len(req.Parts) > 0 {
+			return nil, errors.New("HMAC 签名不支持 multipart 请求体")
+		}
+		if err := signHMAC(out, u, profile, time.Now()); err != nil {
+			return nil, err
+		}
+	case "oauth2":
+		token, err := fetchOAuth2Token(ctx, req.Profile, profile)
+		if err != nil {
+			return nil, fmt.Errorf("获取 OAuth2 令牌失败: %w", err)
+		}
+		out.Header.Set("Authorization", "Bearer "+token.accessToken)
+	default:
+		return nil, fmt.Errorf("认证配置 %s 的类型 %q 不支持", req.Profile, profile.Type)
+	}
+	return out, nil
+}
+
+// setRawQuery 在原始查询字符串末尾设置参数 name=encodedValue 并移除同名参数，
+// 其余参数保持原样，不重新编码或排序，避免破坏带签名或依赖参数顺序的 URL
+func setRawQuery(rawQuery, name, encodedValue string) string {
+	var pairs []string
+	if rawQuery != "" {
+		for _, pair := range strings.Split(rawQuery, "&") {
+			key, _, _ := strings.Cut(pair, "=")
+			if k, err := url.QueryUnescape(key); err == nil && k == name {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	pairs = append(pairs, url.QueryEscape(name)+"="+encodedValue)
+	return strings.Join(pairs, "&")
+}
+
+// signHMAC 对请求签名
+//
+// 签名内容为 "METHOD\nPATH?QUERY\nTIMESTAMP\nSHA256(BODY)"，
+// 签名结果以十六进制写入 SignatureHeader，Unix 秒时间戳写入 TimestampHeader。
+func signHMAC(req *Request, u *url.URL, profile *AuthProfile, now time.Time) error {
+	var newHash func() hash.Hash
+	switch strings.ToLower(profile.Algorithm) {
+	case "", "sha256":
+		newHash = sha256.New
+	case "sha1":
+		newHash = sha1.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return fmt.Errorf("不支持的 HMAC 算法 %q", profile.Algorithm)
+	}
+
+	secret := os.ExpandEnv(profile.Secret)
+	if secret == "" {
+		return errors.New("HMAC 签名密钥为空")
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(hmacStringToSign(req.Method, u.RequestURI(), timestamp, req.Body)))
+
+	req.Header.Set(headerOr(profile.TimestampHeader, "X-Timestamp"), timestamp)
+	req.Header.Set(headerOr(profile.SignatureHeader, "X-Signature"), hex.EncodeToString(mac.Sum(nil)))
+	if keyID := os.ExpandEnv(profile.KeyID); keyID != "" {
+		req.Header.Set(headerOr(profile.KeyIDHeader, "X-Key-Id"), keyID)
+	}
+	return nil
+}
+
+// hmacStringToSign 生成 HMAC 签名内容
+func hmacStringToSign(method, requestURI, timestamp, body string) string {
+	return strings.Join([]string{method, requestURI, timestamp, sha256Hex([]byte(body))}, "\n")
+}
+
+func basicAuth(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+func headerOr(name, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
+
+// fetchOAuth2Token 通过 client_credentials 模式获取访问令牌，令牌过期前使用缓存
+func fetchOAuth2Token(ctx context.Context, name string, profile *AuthProfile) (*oauth2Token, error) {
+	key := strings.ToLower(name)
+	oauth2TokensLock.Lock()
+	entry, ok := oauth2Tokens[key]
+	if !ok {
+		entry = &oauth2TokenEntry{}
+		oauth2Tokens[key] = entry
+	}
+	oauth2TokensLock.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.token != nil && time.Now().Before(entry.token.expiresAt) {
+		return entry.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(profile.Scopes) > 0 {
+		form.Set("scope", strings.Join(profile.Scopes, " "))
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	header.Set("Accept", "application/json")
+	// RFC 6749 2.3.1: 客户端凭证先做 form 编码再放入 Basic 认证
+	header.Set("Authorization", basicAuth(url.QueryEscape(os.ExpandEnv(profile.ClientID)), url.QueryEscape(os.ExpandEnv(profile.ClientSecret))))
+
+	resp, err := helper.DoHttpRequest(ctx, http.MethodPost, os.ExpandEnv(profile.TokenURL), header, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("令牌接口返回状态码 %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("解析令牌响应失败: %w", err)
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("令牌响应中没有 access_token")
+	}
+
+	token := &oauth2Token{
+		accessToken: result.AccessToken,
+		expiresAt:   time.Now().Add(time.Hour),
+	}
+	if result.ExpiresIn > 0 {
+		token.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - oauth2ExpiryMargin)
+	}
+	entry.token = token
+	return token, nil
+}
@@ -0,0 +1,292 @@
+package httprequest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLog 捕获 fn 执行期间的标准日志输出
+func captureLog(t *testing.T, fn func()) string {
+	t.Helper()
+	var buf bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(orig)
+	fn()
+	return buf.String()
+}
+
+// setAuthProfiles 在测试期间替换认证配置
+func setAuthProfiles(t *testing.T, profiles map[string]*AuthProfile) {
+	t.Helper()
+	orig := getAuthProfiles
+	getAuthProfiles = func() (map[string]*AuthProfile, error) { return profiles, nil }
+	t.Cleanup(func() { getAuthProfiles = orig })
+}
+
+func TestApplyAuthProfile(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "env-token")
+	setAuthProfiles(t, map[string]*AuthProfile{
+		"bearer": {Type: "bearer", Token: "${TEST_API_TOKEN}", Hosts: []string{"*.internal.com"}},
+		"basic":  {Type: "basic", Username: "user", Password: "pass", Hosts: []string{"*.internal.com"}},
+		"header": {Type: "apikey", Name: "X-Api-Key", Value: "k1", Hosts: []string{"*.internal.com"}},
+		"query":  {Type: "apikey", Name: "api_key", Value: "k2", In: "query", Hosts: []string{"*.internal.com"}},
+		"scoped": {Type: "bearer", Token: "t", Hosts: []string{"*.internal.com"}},
+	})
+
+	newReq := func(profile string) *Request {
+		return &Request{Method: "GET", URL: "https://api.internal.com/items?x=1", Header: make(http.Header), Profile: profile}
+	}
+
+	t.Run("bearer 支持环境变量", func(t *testing.T) {
+		req := newReq("bearer")
+		out, err := applyAuthProfile(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer env-token", out.Header.Get("Authorization"))
+		assert.Empty(t, req.Header.Get("Authorization"), "原请求不应被修改")
+	})
+
+	t.Run("basic", func(t *testing.T) {
+		out, err := applyAuthProfile(context.Background(), newReq("basic"))
+		require.NoError(t, err)
+		assert.Equal(t, "Basic dXNlcjpwYXNz", out.Header.Get("Authorization"))
+	})
+
+	t.Run("apikey 请求头和查询参数", func(t *testing.T) {
+		out, err := applyAuthProfile(context.Background(), newReq("header"))
+		require.NoError(t, err)
+		assert.Equal(t, "k1", out.Header.Get("X-Api-Key"))
+
+		out, err = applyAuthProfile(context.Background(), newReq("query"))
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.internal.com/items?x=1&api_key=k2", out.URL)
+
+		// 已有查询参数保持原样，不重新编码或排序，同名参数被替换
+		req := newReq("query")
+		req.URL = "https://api.internal.com/items?z=1&sig=a%2Fb&flag&api_key=old&a=x+y"
+		out, err = applyAuthProfile(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.internal.com/items?z=1&sig=a%2Fb&flag&a=x+y&api_key=k2", out.URL)
+	})
+
+	t.Run("限制主机", func(t *testing.T) {
+		_, err := applyAuthProfile(context.Background(), newReq("scoped"))
+		require.NoError(t, err)
+
+		req := newReq("scoped")
+		req.URL = "https://evil.example.com/"
+		_, err = applyAuthProfile(context.Background(), req)
+		assert.ErrorContains(t, err, "不允许用于主机 evil.example.com")
+	})
+
+	t.Run("配置不存在", func(t *testing.T) {
+		_, err := applyAuthProfile(context.Background(), newReq("missing"))
+		assert.ErrorContains(t, err, "认证配置 missing 不存在")
+	})
+
+	t.Run("未配置 hosts 的认证配置被拒绝", func(t *testing.T) {
+		setAuthProfiles(t, map[string]*AuthProfile{
+			"bearer":   {Type: "bearer", Token: "t", Hosts: []string{"*.internal.com"}},
+			"anywhere": {Type: "bearer", Token: "t"},
+		})
+		_, err := applyAuthProfile(context.Background(), newReq("bearer"))
+		assert.ErrorContains(t, err, "认证配置 anywhere 缺少 hosts")
+
+		result, err := httpAuthProfilesHandler(context.Background(), newCallToolRequest("http_auth_profiles", nil))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestSignHMAC(t *testing.T) {
+	req := &Request{Method: "POST", URL: "https://api.example.com/v1/items?b=2", Header: make(http.Header), Body: `{"a":1}`}
+	u, _ := url.Parse(req.URL)
+	profile := &AuthProfile{Type: "hmac", Secret: "s3cret", KeyID: "app1"}
+
+	require.NoError(t, signHMAC(req, u, profile, time.Unix(1700000000, 0)))
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("POST\n/v1/items?b=2\n1700000000\n" + sha256Hex([]byte(`{"a":1}`))))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Signature"))
+	assert.Equal(t, "1700000000", req.Header.Get("X-Timestamp"))
+	assert.Equal(t, "app1", req.Header.Get("X-Key-Id"))
+
+	assert.Error(t, signHMAC(req, u, &AuthProfile{Secret: "s", Algorithm: "md5"}, time.Now()))
+	assert.Error(t, signHMAC(req, u, &AuthProfile{}, time.Now()))
+}
+
+func TestOAuth2Profile(t *testing.T) {
+	var tokenCalls atomic.Int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls.Add(1)
+		user, pass, _ := r.BasicAuth()
+		r.ParseForm()
+		if user != "client" || pass != "secret" || r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"tok-123","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenSrv.Close()
+
+	srv := newEchoServer()
+	defer srv.Close()
+
+	setAuthProfiles(t, map[string]*AuthProfile{
+		"oauth": {Type: "oauth2", TokenURL: tokenSrv.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"read", "write"}, Hosts: []string{"127.0.0.1"}},
+	})
+	t.Cleanup(func() { delete(oauth2Tokens, "oauth") })
+
+	// 配置名称不区分大小写，共用同一个令牌缓存
+	for _, profile := range []string{"oauth", "OAuth"} {
+		result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+			"method":  "GET",
+			"url":     srv.URL + "/me",
+			"profile": profile,
+		}))
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Contains(t, resultText(t, result), "Authorization: Bearer tok-123")
+	}
+	assert.EqualValues(t, 1, tokenCalls.Load(), "令牌应被缓存")
+}
+
+func TestOAuth2Profile_SlowTokenEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	slowSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"access_token":"slow","expires_in":3600}`))
+	}))
+	defer slowSrv.Close()
+	defer close(release)
+	fastSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"fast","expires_in":3600}`))
+	}))
+	defer fastSrv.Close()
+	t.Cleanup(func() {
+		delete(oauth2Tokens, "slow")
+		delete(oauth2Tokens, "fast")
+	})
+
+	slowCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fetchOAuth2Token(slowCtx, "slow", &AuthProfile{TokenURL: slowSrv.URL})
+	time.Sleep(50 * time.Millisecond)
+
+	// 一个配置的令牌接口很慢时，不影响其他配置获取令牌
+	done := make(chan *oauth2Token)
+	go func() {
+		token, _ := fetchOAuth2Token(context.Background(), "fast", &AuthProfile{TokenURL: fastSrv.URL})
+		done <- token
+	}()
+	select {
+	case token := <-done:
+		require.NotNil(t, token)
+		assert.Equal(t, "fast", token.accessToken)
+	case <-time.After(2 * time.Second):
+		t.Fatal("获取令牌被其他配置阻塞")
+	}
+}
+
+func TestHttpAuthProfilesHandler(t *testing.T) {
+	setAuthProfiles(t, map[string]*AuthProfile{
+		"internal": {Type: "bearer", Token: "super-secret", Hosts: []string{"*.internal.com"}},
+		"public":   {Type: "apikey", Name: "key", Value: "another-secret", Hosts: []string{"api.example.com", "*.example.org"}},
+	})
+
+	result, err := httpAuthProfilesHandler(context.Background(), newCallToolRequest("http_auth_profiles", nil))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.Equal(t, "internal: type=bearer hosts=*.internal.com\npublic: type=apikey hosts=api.example.com, *.example.org", text)
+	assert.NotContains(t, text, "secret")
+}
+
+func TestAuthProfileRedirect(t *testing.T) {
+	// target 回显收到的认证请求头
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range []string{"X-Api-Key", "X-Signature", "X-Timestamp", "Authorization"} {
+			fmt.Fprintf(w, "%s=%s\n", name, r.Header.Get(name))
+		}
+	}))
+	defer target.Close()
+	targetURL, _ := url.Parse(target.URL)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := "127.0.0.1"
+		if r.URL.Path == "/same" {
+			host = "localhost"
+		}
+		http.Redirect(w, r, "http://"+host+":"+targetURL.Port()+"/", http.StatusFound)
+	}))
+	defer origin.Close()
+	originURL, _ := url.Parse(origin.URL)
+	setAuthProfiles(t, map[string]*AuthProfile{
+		"key":  {Type: "apikey", Name: "X-Api-Key", Value: "k1", Hosts: []string{"localhost"}},
+		"hmac": {Type: "hmac", Secret: "s1", Hosts: []string{"localhost"}},
+	})
+
+	call := func(profile, path string) string {
+		result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+			"method":  "GET",
+			"url":     "http://localhost:" + originURL.Port() + path,
+			"profile": profile,
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+		return resultText(t, result)
+	}
+
+	// 重定向到 hosts 之外的主机时移除 API Key
+	assert.Contains(t, call("key", "/other"), "X-Api-Key=\n")
+	assert.Contains(t, call("key", "/same"), "X-Api-Key=k1\n")
+
+	// 签名请求头同样只发给 hosts 中的主机
+	text := call("hmac", "/other")
+	assert.Contains(t, text, "X-Signature=\n")
+	assert.Contains(t, text, "X-Timestamp=\n")
+	assert.Regexp(t, `X-Signature=[0-9a-f]{64}\n`, call("hmac", "/same"))
+}
+
+func TestAuthProfileRedactLog(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+	setAuthProfiles(t, map[string]*AuthProfile{
+		"partner": {Type: "apikey", Name: "X-Partner-Key", Value: "partner-secret-1", Hosts: []string{"127.0.0.1"}},
+		"legacy":  {Type: "apikey", Name: "sigv", Value: "query-secret-2", In: "query", Hosts: []string{"127.0.0.1"}},
+		"signed":  {Type: "hmac", Secret: "hmac-secret-3", SignatureHeader: "X-Body-Sig", KeyID: "k1", Hosts: []string{"127.0.0.1"}},
+	})
+
+	output := captureLog(t, func() {
+		for _, profile := range []string{"partner", "legacy", "signed"} {
+			result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+				"method":  "POST",
+				"url":     srv.URL + "/items?x=1",
+				"body":    "data",
+				"profile": profile,
+			}))
+			require.NoError(t, err)
+			require.False(t, result.IsError, resultText(t, result))
+		}
+	})
+
+	assert.NotContains(t, output, "partner-secret-1")
+	assert.NotContains(t, output, "query-secret-2")
+	assert.Contains(t, output, "X-Partner-Key:[***]")
+	assert.Contains(t, output, "sigv=***")
+	assert.Regexp(t, `X-Body-Sig:\[\*\*\*\]`, output)
+	assert.NotRegexp(t, `X-Body-Sig: [0-9a-f]{16}`, output, "回显的签名同样脱敏")
+}
@@ -0,0 +1,246 @@
+package httprequest
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// SessionCookie 会话中保存的 Cookie，可序列化到磁盘
+type SessionCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Expires  time.Time `json:"expires,omitempty"` // 零值表示会话 Cookie
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"httpOnly,omitempty"`
+	HostOnly bool      `json:"hostOnly,omitempty"` // 未设置 Domain 属性时只发送给设置它的主机
+	SameSite string    `json:"sameSite,omitempty"`
+}
+
+// expired 判断 Cookie 是否已过期
+func (c *SessionCookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !now.Before(c.Expires)
+}
+
+// matches 判断 Cookie 是否应发送给 URL
+func (c *SessionCookie) matches(u *url.URL, host string) bool {
+	if c.Secure && u.Scheme != "https" {
+		return false
+	}
+	if c.HostOnly {
+		if host != c.Domain {
+			return false
+		}
+	} else if !domainMatch(host, c.Domain) {
+		return false
+	}
+	return pathMatch(requestPath(u), c.Path)
+}
+
+// cookieJar 可遍历、可序列化的 http.CookieJar 实现，规则参照 RFC 6265
+type cookieJar struct {
+	mu      sync.Mutex
+	cookies map[string]*SessionCookie // key: domain;path;name
+	now     func() time.Time
+}
+
+func newCookieJar() *cookieJar {
+	return &cookieJar{cookies: make(map[string]*SessionCookie), now: time.Now}
+}
+
+// SetCookies 保存响应中的 Cookie
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host := canonicalHost(u.Hostname())
+	now := j.now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, c := range cookies {
+		sc := &SessionCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: sameSiteName(c.SameSite),
+		}
+
+		domain := canonicalHost(strings.TrimPrefix(c.Domain, "."))
+		switch {
+		case domain == "" || domain == host:
+			sc.Domain, sc.HostOnly = host, domain == ""
+		case net.ParseIP(host) != nil || !domainMatch(host, domain):
+			// IP 地址不能设置 Domain，Domain 必须是当前主机的上级域名
+			continue
+		case isPublicSuffix(domain):
+			continue
+		default:
+			sc.Domain = domain
+		}
+		if sc.Path == "" || !strings.HasPrefix(sc.Path, "/") {
+			sc.Path = defaultCookiePath(u)
+		}
+
+		switch {
+		case c.MaxAge < 0:
+			sc.Expires = now
+		case c.MaxAge > 0:
+			sc.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			sc.Expires = c.Expires
+		}
+
+		key := sc.Domain + ";" + sc.Path + ";" + sc.Name
+		if sc.expired(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		j.cookies[key] = sc
+	}
+}
+
+// Cookies 返回应发送给 URL 的 Cookie，路径更长的排在前面
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	host := canonicalHost(u.Hostname())
+	now := j.now()
+
+	j.mu.Lock()
+	var matched []*SessionCookie
+	for key, c := range j.cookies {
+		if c.expired(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		if c.matches(u, host) {
+			matched = append(matched, c)
+		}
+	}
+	j.mu.Unlock()
+
+	sort.Slice(matched, func(a, b int) bool {
+		if len(matched[a].Path) != len(matched[b].Path) {
+			return len(matched[a].Path) > len(matched[b].Path)
+		}
+		return matched[a].Name < matched[b].Name
+	})
+	cookies := make([]*http.Cookie, len(matched))
+	for i, c := range matched {
+		cookies[i] = &http.Cookie{Name: c.Name, Value: c.Value}
+	}
+	return cookies
+}
+
+// All 返回未过期的 Cookie，domain 非空时只返回匹配该域名的 Cookie
+func (j *cookieJar) All(domain string) []SessionCookie {
+	domain = canonicalHost(strings.TrimPrefix(domain, "."))
+	now := j.now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var out []SessionCookie
+	for _, c := range j.cookies {
+		if c.expired(now) || (domain != "" && !domainMatch(c.Domain, domain)) {
+			continue
+		}
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Domain != out[b].Domain {
+			return out[a].Domain < out[b].Domain
+		}
+		if out[a].Path != out[b].Path {
+			return out[a].Path < out[b].Path
+		}
+		return out[a].Name < out[b].Name
+	})
+	return out
+}
+
+// Load 用给定的 Cookie 替换当前内容
+func (j *cookieJar) Load(cookies []SessionCookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cookies = make(map[string]*SessionCookie, len(cookies))
+	for i := range cookies {
+		c := cookies[i]
+		j.cookies[c.Domain+";"+c.Path+";"+c.Name] = &c
+	}
+}
+
+// Clear 删除 Cookie，domain 非空时只删除匹配该域名的 Cookie，返回删除的数量
+func (j *cookieJar) Clear(domain string) int {
+	domain = canonicalHost(strings.TrimPrefix(domain, "."))
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := 0
+	for key, c := range j.cookies {
+		if domain == "" || domainMatch(c.Domain, domain) {
+			delete(j.cookies, key)
+			n++
+		}
+	}
+	return n
+}
+
+func canonicalHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// domainMatch 判断 host 是否等于 domain 或是其子域名
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch 按 RFC 6265 5.1.4 判断请求路径是否匹配 Cookie 路径
+func pathMatch(reqPath, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(reqPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+}
+
+func requestPath(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// defaultCookiePath 按 RFC 6265 5.1.4 计算默认路径
+func defaultCookiePath(u *url.URL) string {
+	p := requestPath(u)
+	i := strings.LastIndex(p, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return p[:i]
+}
+
+func isPublicSuffix(domain string) bool {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix == domain
+}
+
+func sameSiteName(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
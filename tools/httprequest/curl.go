@@ -0,0 +1,568 @@
+package httprequest
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Request 表示解析后的 HTTP 请求参数
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Header  http.Header `json:"header,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	Profile string      `json:"profile,omitempty"` // 认证配置名称，发送前由 applyAuthProfile 注入认证信息
+	Session string      `json:"session,omitempty"` // 会话名称，同一会话的请求共享 Cookie
+	Parts   []FormPart  `json:"parts,omitempty"`   // multipart/form-data 字段，非空时忽略 Body，发送时从磁盘流式读取文件
+}
+
+// Clone 返回请求的副本
+func (r *Request) Clone() *Request {
+	out := *r
+	out.Header = r.Header.Clone()
+	if out.Header == nil {
+		out.Header = make(http.Header)
+	}
+	return &out
+}
+
+// curlValueOptions 需要携带参数值、但对请求本身没有影响的 curl 选项
+var curlValueOptions = map[string]bool{
+	"-o": true, "--output": true, "-m": true, "--max-time": true, "--connect-timeout": true,
+	"-x": true, "--proxy": true, "-U": true, "--proxy-user": true, "--noproxy": true,
+	"--preproxy": true, "--socks4": true, "--socks4a": true, "--socks5": true, "--socks5-hostname": true,
+	"--proxy-header": true, "--proxy-cacert": true, "--proxy-cert": true, "--proxy-key": true,
+	"-w": true, "--write-out": true, "--retry": true, "--retry-delay": true, "--retry-max-time": true,
+	"-c": true, "--cookie-jar": true, "--cacert": true, "--capath": true, "--cert": true, "--key": true,
+	"-E": true, "--cert-type": true, "--key-type": true, "--pass": true, "--ciphers": true,
+	"--pinnedpubkey": true, "--tls-max": true, "--crlfile": true,
+	"--resolve": true, "--connect-to": true, "--dns-servers": true, "--unix-socket": true,
+	"--abstract-unix-socket": true, "--interface": true, "--local-port": true,
+	"--max-redirs": true, "-D": true, "--dump-header": true, "--limit-rate": true,
+	"-y": true, "--speed-time": true, "-Y": true, "--speed-limit": true, "-C": true, "--continue-at": true,
+	"-z": true, "--time-cond": true, "--max-filesize": true, "--keepalive-time": true,
+	"--expect100-timeout": true, "--happy-eyeballs-timeout-ms": true, "--output-dir": true,
+	"--netrc-file": true, "--trace": true, "--trace-ascii": true, "--stderr": true,
+	"-P": true, "--ftp-port": true, "-Q": true, "--quote": true, "-t": true, "--telnet-option": true,
+}
+
+// curlFlagOptions 不带参数值、对请求本身没有影响的 curl 长选项，也接受 --no- 前缀的否定写法
+var curlFlagOptions = map[string]bool{
+	"--silent": true, "--show-error": true, "--insecure": true, "--location": true, "--location-trusted": true,
+	"--compressed": true, "--verbose": true, "--include": true, "--fail": true, "--fail-with-body": true,
+	"--fail-early": true, "--globoff": true, "--buffer": true, "--progress-bar": true, "--progress-meter": true,
+	"--raw": true, "--path-as-is": true, "--keepalive": true, "--tcp-nodelay": true, "--sessionid": true,
+	"--http1.0": true, "--http1.1": true, "--http2": true, "--http2-prior-knowledge": true, "--http3": true,
+	"--ipv4": true, "--ipv6": true, "--tlsv1": true, "--tlsv1.0": true, "--tlsv1.1": true, "--tlsv1.2": true,
+	"--tlsv1.3": true, "--ssl": true, "--ssl-reqd": true, "--alpn": true, "--proxy-insecure": true,
+	"--proxytunnel": true, "--basic": true, "--anyauth": true, "--digest": true, "--ntlm": true,
+	"--negotiate": true, "--remote-name": true, "--remote-name-all": true, "--remote-header-name": true,
+	"--create-dirs": true, "--retry-connrefused": true, "--retry-all-errors": true, "--trace-time": true,
+	"--styled-output": true, "--disable": true, "--netrc": true, "--netrc-optional": true,
+	"--tr-encoding": true, "--junk-session-cookies": true, "--xattr": true, "--ssl-no-revoke": true,
+}
+
+// curlUnsupportedOptions 会改变请求内容但无法转换的选项，直接报错以免发出与原命令不同的请求
+var curlUnsupportedOptions = map[string]bool{
+	"-K": true, "--config": true, "--request-target": true, "--aws-sigv4": true, "--url-query": true,
+}
+
+// curlShortValueFlags 需要携带参数值的短选项
+const curlShortValueFlags = "XHdFuAebomxwcErDrTUCKzyYPQt"
+
+// curlShortFlags 不带参数值的短选项
+const curlShortFlags = "sSkLviIfgGNOJjlnpqRBaZM0123456#"
+
+// ParseCurl 将 curl 命令解析为 HTTP 请求参数
+//
+// 支持 -X、-H、-d/--data-raw/--data-binary/--data-urlencode、-u、--oauth2-bearer、-F、-T、--json、-G 等常用选项，
+// 以及反斜杠续行、单引号、双引号和 $'...' 引用。无法识别的选项直接报错，避免把选项的参数值误当作 URL。
+func ParseCurl(command string) (*Request, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("not a curl command")
+	}
+
+	var (
+		req        = &Request{Header: make(http.Header)}
+		data       []string
+		forms      []FormPart
+		rawURL     string
+		useGet     bool
+		isJSON     bool
+		isHead     bool
+		hasData    bool
+		method     string
+		userPass   string
+		bearer     string
+		uploadFile string
+	)
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+
+		// 取出选项参数值，支持 -XPOST 这样的紧凑写法
+		var name, value string
+		var hasValue bool
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name = arg
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// 短选项可以组合，如 -sSL、-sXPOST，遇到需要参数值的选项时剩余部分即为参数值
+			flags := arg[1:]
+			for j, c := range flags {
+				if !strings.ContainsRune(curlShortValueFlags+curlShortFlags, c) {
+					return nil, fmt.Errorf("unsupported curl option -%c", c)
+				}
+				if strings.ContainsRune(curlShortValueFlags, c) {
+					name = "-" + string(c)
+					if rest := flags[j+1:]; rest != "" {
+						value, hasValue = rest, true
+					}
+					break
+				}
+				switch c {
+				case 'G':
+					useGet = true
+				case 'I':
+					isHead = true
+				}
+			}
+			if name == "" {
+				continue
+			}
+		default:
+			if rawURL == "" {
+				rawURL = arg
+			}
+			continue
+		}
+
+		takeValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("option %s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch name {
+		case "-X", "--request":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			method = strings.ToUpper(v)
+		case "-H", "--header":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			addCurlHeader(req.Header, v)
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw", "--data-urlencode":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			v, err = curlDataValue(name, v)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, v)
+			hasData = true
+		case "--json":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(v, "@") {
+				content, err := os.ReadFile(v[1:])
+				if err != nil {
+					return nil, err
+				}
+				v = string(content)
+			}
+			data = append(data, v)
+			hasData = true
+			isJSON = true
+		case "-F", "--form", "--form-string":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			part, err := parseCurlFormPart(v, name == "--form-string")
+			if err != nil {
+				return nil, err
+			}
+			forms = append(forms, part)
+		case "-u", "--user":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			userPass = v
+		case "--oauth2-bearer":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			bearer = v
+		case "-T", "--upload-file":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			if v == "-" || v == "." {
+				return nil, errors.New("uploading from stdin is not supported")
+			}
+			uploadFile = v
+		case "-A", "--user-agent":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("User-Agent", v)
+		case "-e", "--referer":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Referer", v)
+		case "-b", "--cookie":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			// 不含 = 的参数是 cookie 文件，忽略
+			if strings.Contains(v, "=") {
+				req.Header.Add("Cookie", v)
+			}
+		case "-r", "--range":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Range", "bytes="+v)
+		case "--url":
+			v, err := takeValue()
+			if err != nil {
+				return nil, err
+			}
+			rawURL = v
+		case "-G", "--get":
+			useGet = true
+		case "-I", "--head":
+			isHead = true
+		default:
+			switch {
+			case curlUnsupportedOptions[name]:
+				return nil, fmt.Errorf("curl option %s is not supported", name)
+			case curlValueOptions[name]:
+				if _, err := takeValue(); err != nil {
+					return nil, err
+				}
+			case curlFlagOptions[name], strings.HasPrefix(name, "--no-") && curlFlagOptions["--"+name[len("--no-"):]]:
+				// 对请求内容没有影响的开关（如 -s、-k、-L、--compressed），直接忽略
+			case strings.HasPrefix(name, "--"):
+				// 未知选项可能需要参数值，忽略后会把参数值当作 URL，直接报错
+				return nil, fmt.Errorf("unsupported curl option %s", name)
+			}
+		}
+	}
+
+	if rawURL == "" {
+		return nil, errors.New("no URL found in curl command")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	req.URL = rawURL
+
+	switch {
+	case userPass != "":
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(userPass)))
+	case bearer != "":
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	if uploadFile != "" {
+		content, err := os.ReadFile(uploadFile)
+		if err != nil {
+			return nil, err
+		}
+		// 与 curl 一致，URL 以 / 结尾时追加文件名
+		if strings.HasSuffix(req.URL, "/") {
+			req.URL += url.PathEscape(filepath.Base(uploadFile))
+		}
+		req.Body = string(content)
+	}
+
+	switch {
+	case useGet && hasData:
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return nil, err
+		}
+		query := strings.Join(data, "&")
+		if u.RawQuery != "" {
+			u.RawQuery += "&" + query
+		} else {
+			u.RawQuery = query
+		}
+		req.URL = u.String()
+	case len(forms) > 0:
+		req.Parts = forms
+	case isJSON:
+		req.Body = strings.Join(data, "")
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "application/json")
+		}
+	case hasData:
+		req.Body = strings.Join(data, "&")
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+
+	switch {
+	case method != "":
+		req.Method = method
+	case isHead:
+		req.Method = http.MethodHead
+	case useGet:
+		req.Method = http.MethodGet
+	case uploadFile != "":
+		req.Method = http.MethodPut
+	case hasData || len(forms) > 0:
+		req.Method = http.MethodPost
+	default:
+		req.Method = http.MethodGet
+	}
+
+	return req, nil
+}
+
+// addCurlHeader 解析 -H 参数，"Name;" 表示值为空的请求头
+func addCurlHeader(header http.Header, line string) {
+	if name, ok := strings.CutSuffix(strings.TrimSpace(line), ";"); ok && !strings.Contains(name, ":") {
+		key := textproto.CanonicalMIMEHeaderKey(name)
+		header[key] = append(header[key], "")
+		return
+	}
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	header.Add(name, strings.TrimSpace(value))
+}
+
+// curlDataValue 处理 -d 系列选项的 @file 和 url 编码
+func curlDataValue(option, value string) (string, error) {
+	switch option {
+	case "--data-raw":
+		return value, nil
+	case "--data-urlencode":
+		// 与 curl 一致：包含 = 时为 [name]=content，否则 [name]@file 从文件读取内容
+		name, content, hasName := strings.Cut(value, "=")
+		if !hasName {
+			var file string
+			var fromFile bool
+			if name, file, fromFile = strings.Cut(value, "@"); fromFile {
+				b, err := os.ReadFile(file)
+				if err != nil {
+					return "", err
+				}
+				content = string(b)
+			} else {
+				name, content = "", value
+			}
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	default:
+		if strings.HasPrefix(value, "@") {
+			b, err := os.ReadFile(value[1:])
+			if err != nil {
+				return "", err
+			}
+			value = string(b)
+			if option != "--data-binary" {
+				value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+			}
+		}
+		return value, nil
+	}
+}
+
+// parseCurlFormPart 解析 name=value、name=@file;type=...;filename=... 格式
+func parseCurlFormPart(value string, literal bool) (FormPart, error) {
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return FormPart{}, fmt.Errorf("invalid form field %q", value)
+	}
+	part := FormPart{Name: name}
+	if literal || (!strings.HasPrefix(content, "@") && !strings.HasPrefix(content, "<")) {
+		part.Value = content
+		return part, nil
+	}
+
+	attrs := strings.Split(content, ";")
+	for _, attr := range attrs[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(attr), "=")
+		switch k {
+		case "type":
+			part.ContentType = v
+		case "filename":
+			part.FileName = strings.Trim(v, `"`)
+		}
+	}
+	file := attrs[0]
+	if strings.HasPrefix(file, "<") {
+		// <file 表示以文件内容作为普通字段值
+		b, err := os.ReadFile(file[1:])
+		if err != nil {
+			return FormPart{}, err
+		}
+		part.Value = string(b)
+		return part, nil
+	}
+	part.FilePath = file[1:]
+	return part, nil
+}
+
+// splitShellWords 按 POSIX shell 规则切分命令行参数
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words   []string
+		current strings.Builder
+		inWord  bool
+	)
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 < len(runes) {
+				next := runes[i+1]
+				i++
+				// 反斜杠续行
+				if next == '\n' {
+					continue
+				}
+				if next == '\r' && i+1 < len(runes) && runes[i+1] == '\n' {
+					i++
+					continue
+				}
+				current.WriteRune(next)
+				inWord = true
+			}
+		case r == '\'':
+			end := indexRune(runes, '\'', i+1)
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			i = end
+			inWord = true
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			end, text, err := readANSIQuoted(runes, i+2)
+			if err != nil {
+				return nil, err
+			}
+			current.WriteString(text)
+			i = end
+			inWord = true
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					switch runes[j+1] {
+					case '"', '\\', '$', '`':
+						j++
+					case '\n':
+						j++
+						continue
+					}
+				}
+				current.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, errors.New("unterminated double quote")
+			}
+			i = j
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+func indexRune(runes []rune, r rune, from int) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// readANSIQuoted 读取 $'...' 引用的内容，返回结束引号的位置
+func readANSIQuoted(runes []rune, from int) (int, string, error) {
+	var sb strings.Builder
+	for i := from; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\'' {
+			return i, sb.String(), nil
+		}
+		if r != '\\' || i+1 >= len(runes) {
+			sb.WriteRune(r)
+			continue
+		}
+		i++
+		switch runes[i] {
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		case 'r':
+			sb.WriteRune('\r')
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return 0, "", errors.New("unterminated $' quote")
+}
@@ -0,0 +1,197 @@
+package httprequest
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"普通参数", `curl -X POST url`, []string{"curl", "-X", "POST", "url"}},
+		{"单引号", `curl -d '{"key": "value"}'`, []string{"curl", "-d", `{"key": "value"}`}},
+		{"双引号转义", `curl -d "{\"key\": \"$x\"}"`, []string{"curl", "-d", `{"key": "$x"}`}},
+		{"续行", "curl \\\n  -H 'A: 1' \\\r\n  url", []string{"curl", "-H", "A: 1", "url"}},
+		{"ANSI-C 引用", `curl -d $'a\nb\'c'`, []string{"curl", "-d", "a\nb'c"}},
+		{"相邻引用拼接", `curl -H 'X-A: '"b"c`, []string{"curl", "-H", "X-A: bc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShellWords(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := splitShellWords(`curl -d '{"a": 1}`)
+	assert.Error(t, err)
+}
+
+func TestParseCurl(t *testing.T) {
+	t.Run("POST JSON 保留末尾大括号和请求头", func(t *testing.T) {
+		req, err := ParseCurl(`curl -X POST https://api.example.com/data -H "Content-Type: application/json" -H 'X-Trace: a' -H 'X-Trace: b' -d '{"key": "value"}'`)
+		require.NoError(t, err)
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "https://api.example.com/data", req.URL)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, []string{"a", "b"}, req.Header.Values("X-Trace"))
+		assert.Equal(t, `{"key": "value"}`, req.Body)
+	})
+
+	t.Run("默认方法和表单编码", func(t *testing.T) {
+		req, err := ParseCurl(`curl api.example.com/login --data-raw 'a=1' --data-urlencode 'q=hello world'`)
+		require.NoError(t, err)
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "http://api.example.com/login", req.URL)
+		assert.Equal(t, "a=1&q=hello+world", req.Body)
+		assert.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+	})
+
+	t.Run("-G 将数据放入查询参数", func(t *testing.T) {
+		req, err := ParseCurl(`curl -G 'https://api.example.com/search?x=1' -d q=go -d page=2`)
+		require.NoError(t, err)
+		assert.Equal(t, "GET", req.Method)
+		assert.Equal(t, "https://api.example.com/search?x=1&q=go&page=2", req.URL)
+		assert.Empty(t, req.Body)
+	})
+
+	t.Run("--json 和组合短选项", func(t *testing.T) {
+		req, err := ParseCurl(`curl -sSLXPUT https://api.example.com/items/1 --json '{"a":1}' -u user:pass`)
+		require.NoError(t, err)
+		assert.Equal(t, "PUT", req.Method)
+		assert.Equal(t, `{"a":1}`, req.Body)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "application/json", req.Header.Get("Accept"))
+		assert.Equal(t, "Basic dXNlcjpwYXNz", req.Header.Get("Authorization"))
+	})
+
+	t.Run("-r 转为 Range 请求头", func(t *testing.T) {
+		req, err := ParseCurl(`curl -r 0-99 https://cdn.example.com/file.bin`)
+		require.NoError(t, err)
+		assert.Equal(t, "https://cdn.example.com/file.bin", req.URL)
+		assert.Equal(t, "bytes=0-99", req.Header.Get("Range"))
+
+		req, err = ParseCurl(`curl -sr100- https://cdn.example.com/file.bin`)
+		require.NoError(t, err)
+		assert.Equal(t, "bytes=100-", req.Header.Get("Range"))
+
+		req, err = ParseCurl(`curl --range 0-0,-1 https://cdn.example.com/file.bin`)
+		require.NoError(t, err)
+		assert.Equal(t, "bytes=0-0,-1", req.Header.Get("Range"))
+	})
+
+	t.Run("空值请求头和忽略的选项", func(t *testing.T) {
+		req, err := ParseCurl(`curl -k --compressed -m 10 -o out.txt -H 'X-Empty;' https://api.example.com`)
+		require.NoError(t, err)
+		assert.Equal(t, "GET", req.Method)
+		assert.Equal(t, "https://api.example.com", req.URL)
+		assert.Equal(t, []string{""}, req.Header.Values("X-Empty"))
+	})
+
+	t.Run("-F 上传文件", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "a.txt")
+		require.NoError(t, os.WriteFile(file, []byte("file content"), 0644))
+
+		req, err := ParseCurl(`curl https://api.example.com/upload -F name=demo -F "file=@` + file + `;type=text/plain"`)
+		require.NoError(t, err)
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, []FormPart{
+			{Name: "name", Value: "demo"},
+			{Name: "file", FilePath: file, ContentType: "text/plain"},
+		}, req.Parts)
+
+		body, contentType, err := req.bodyReader()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(contentType, "multipart/form-data; boundary="))
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `name="file"; filename="a.txt"`)
+		assert.Contains(t, string(data), "Content-Type: text/plain")
+		assert.Contains(t, string(data), "file content")
+	})
+
+	t.Run("带参数值的选项不会被当作 URL", func(t *testing.T) {
+		tests := []struct {
+			command string
+			auth    string
+		}{
+			{`curl --oauth2-bearer SECRET https://api.example.com/x`, "Bearer SECRET"},
+			{`curl -U u:p -x http://proxy:3128 https://api.example.com/x`, ""},
+			{`curl --proxy-user u:p --noproxy '*' https://api.example.com/x`, ""},
+			{`curl -C - -z 'Jan 1 2024' -y 30 -Y 100 https://api.example.com/x`, ""},
+			{`curl --connect-to api.example.com:443:127.0.0.1:8443 --interface eth0 https://api.example.com/x`, ""},
+			{`curl --unix-socket /var/run/docker.sock --user-agent demo/1.0 https://api.example.com/x`, ""},
+			{`curl -sSLC- --no-progress-meter https://api.example.com/x`, ""},
+		}
+		for _, tt := range tests {
+			req, err := ParseCurl(tt.command)
+			require.NoError(t, err, tt.command)
+			assert.Equal(t, "https://api.example.com/x", req.URL, tt.command)
+			assert.Equal(t, tt.auth, req.Header.Get("Authorization"), tt.command)
+		}
+	})
+
+	t.Run("-T 上传文件", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file.txt")
+		require.NoError(t, os.WriteFile(file, []byte("upload"), 0644))
+
+		req, err := ParseCurl(`curl -T ` + file + ` https://api.example.com/files/`)
+		require.NoError(t, err)
+		assert.Equal(t, "PUT", req.Method)
+		assert.Equal(t, "https://api.example.com/files/file.txt", req.URL)
+		assert.Equal(t, "upload", req.Body)
+	})
+
+	t.Run("未知或不支持的选项报错", func(t *testing.T) {
+		for _, command := range []string{
+			`curl --made-up-option value https://api.example.com/x`,
+			`curl -K curl.conf https://api.example.com/x`,
+			`curl -W https://api.example.com/x`,
+		} {
+			_, err := ParseCurl(command)
+			assert.Error(t, err, command)
+		}
+	})
+
+	t.Run("--data-urlencode 读取文件", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "q.txt")
+		require.NoError(t, os.WriteFile(file, []byte("a b&c"), 0644))
+
+		req, err := ParseCurl(`curl https://api.example.com/x --data-urlencode q@` + file + ` --data-urlencode @` + file + ` --data-urlencode 'e=x@y'`)
+		require.NoError(t, err)
+		assert.Equal(t, "q=a+b%26c&a+b%26c&e=x%40y", req.Body)
+	})
+
+	t.Run("非 curl 命令", func(t *testing.T) {
+		_, err := ParseCurl(`wget https://api.example.com`)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseRaw(t *testing.T) {
+	req, err := ParseRaw("POST https://api.example.com/data HTTP/1.1\r\nContent-Type: application/json\r\n\r\n{\n\t\"key\": \"value\"\n}\n")
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "https://api.example.com/data", req.URL)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "{\n\t\"key\": \"value\"\n}", req.Body)
+
+	req, err = ParseRaw("GET /users?id=1\nHost: api.example.com\n")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, req.Method)
+	assert.Equal(t, "https://api.example.com/users?id=1", req.URL)
+
+	req, err = ParseRaw("  curl https://api.example.com  ")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", req.URL)
+}
@@ -0,0 +1,169 @@
+package httprequest
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDiffLines 差异最多输出的行数
+const maxDiffLines = 200
+
+// arrayIndexPattern 匹配路径中的数组下标，用于忽略规则中的 [*]
+var arrayIndexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// jsonDiff 结构化 JSON 差异，每行形如 "~ $.a.b: 1 → 2"、"+ $.c: true"、"- $.d[0]: \"x\""
+type jsonDiff struct {
+	ignore map[string]bool
+	lines  []string
+	total  int
+}
+
+// diffJSON 比较两个 JSON 值，ignore 中的路径及其子路径不参与比较，路径中的数组下标可以写成 [*]
+func diffJSON(a, b any, ignore []string) ([]string, int) {
+	d := &jsonDiff{ignore: make(map[string]bool, len(ignore))}
+	for _, p := range ignore {
+		d.ignore[p] = true
+	}
+	d.compare("$", a, b)
+	return d.lines, d.total
+}
+
+func (d *jsonDiff) ignored(path string) bool {
+	return d.ignore[path] || d.ignore[arrayIndexPattern.ReplaceAllString(path, "[*]")]
+}
+
+func (d *jsonDiff) add(op, path, text string) {
+	d.total++
+	if len(d.lines) < maxDiffLines {
+		d.lines = append(d.lines, op+" "+path+": "+text)
+	}
+}
+
+func (d *jsonDiff) compare(path string, a, b any) {
+	if d.ignored(path) {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(av)+len(bv))
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		for _, k := range sortedKeys(keys) {
+			child := childPath(path, k)
+			if d.ignored(child) {
+				continue
+			}
+			x, inA := av[k]
+			y, inB := bv[k]
+			switch {
+			case !inA:
+				d.add("+", child, diffValue(y))
+			case !inB:
+				d.add("-", child, diffValue(x))
+			default:
+				d.compare(child, x, y)
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(av) || i < len(bv); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(av):
+				if !d.ignored(child) {
+					d.add("+", child, diffValue(bv[i]))
+				}
+			case i >= len(bv):
+				if !d.ignored(child) {
+					d.add("-", child, diffValue(av[i]))
+				}
+			default:
+				d.compare(child, av[i], bv[i])
+			}
+		}
+		return
+	default:
+		if a == b {
+			return
+		}
+	}
+	d.add("~", path, diffValue(a)+" → "+diffValue(b))
+}
+
+// childPath 拼接对象字段路径，非标识符字段使用 ["key"] 形式
+func childPath(path, key string) string {
+	if key != "" && identPattern.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$-]*$`)
+
+// diffValue 返回差异中展示的值，过长时截断
+func diffValue(v any) string {
+	data, _ := json.Marshal(v)
+	text, truncated := truncateUTF8(data, 120)
+	if truncated {
+		text += "..."
+	}
+	return text
+}
+
+// diffEntries 比较两条历史记录的状态码和响应体
+func diffEntries(a, b *HistoryEntry, ignore []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Diff %s → %s\n", entryLabel(a), entryLabel(b))
+	if a.Error != "" || b.Error != "" {
+		fmt.Fprintf(&sb, "Error: %q → %q\n", a.Error, b.Error)
+	}
+	if a.Status != b.Status {
+		fmt.Fprintf(&sb, "Status: %d → %d\n", a.Status, b.Status)
+	} else {
+		fmt.Fprintf(&sb, "Status: %d (相同)\n", a.Status)
+	}
+
+	if a.BodySHA256 == b.BodySHA256 {
+		sb.WriteString("Body: 相同")
+		return sb.String()
+	}
+
+	var av, bv any
+	errA := json.Unmarshal([]byte(a.Body), &av)
+	errB := json.Unmarshal([]byte(b.Body), &bv)
+	switch {
+	case a.BodyEncoding != "" || b.BodyEncoding != "":
+		fmt.Fprintf(&sb, "Body: 二进制内容不同 (%d bytes → %d bytes)", a.BodySize, b.BodySize)
+	case a.BodyTruncated || b.BodyTruncated:
+		fmt.Fprintf(&sb, "Body: 内容不同 (%d bytes → %d bytes)，响应体超过保存上限被截断，无法逐项比较", a.BodySize, b.BodySize)
+	case errA != nil || errB != nil:
+		fmt.Fprintf(&sb, "Body: 内容不同 (%d bytes → %d bytes)，响应体不是 JSON，无法逐项比较", a.BodySize, b.BodySize)
+	default:
+		lines, total := diffJSON(av, bv, ignore)
+		if total == 0 {
+			sb.WriteString("Body: JSON 内容相同（仅格式、字段顺序或忽略的字段不同）")
+			return sb.String()
+		}
+		fmt.Fprintf(&sb, "Body: %d 处差异\n%s", total, strings.Join(lines, "\n"))
+		if total > len(lines) {
+			fmt.Fprintf(&sb, "\n...(仅显示前 %d 处)", len(lines))
+		}
+	}
+	return sb.String()
+}
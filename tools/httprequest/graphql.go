@@ -0,0 +1,507 @@
+package httprequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// graphqlResponse GraphQL 响应
+type graphqlResponse struct {
+	Data       json.RawMessage `json:"data"`
+	Errors     []graphqlError  `json:"errors"`
+	Extensions map[string]any  `json:"extensions"`
+}
+
+type graphqlError struct {
+	Message   string `json:"message"`
+	Locations []struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"locations"`
+	Path       []any          `json:"path"`
+	Extensions map[string]any `json:"extensions"`
+}
+
+// graphqlTypeRef 字段或参数的类型，NON_NULL 和 LIST 通过 OfType 嵌套
+type graphqlTypeRef struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	OfType *graphqlTypeRef `json:"ofType"`
+}
+
+// String 返回 SDL 形式的类型，如 [User!]!
+func (t *graphqlTypeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+type graphqlInputValue struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Type         *graphqlTypeRef `json:"type"`
+	DefaultValue *string         `json:"defaultValue"`
+}
+
+type graphqlField struct {
+	Name              string              `json:"name"`
+	Description       string              `json:"description"`
+	Args              []graphqlInputValue `json:"args"`
+	Type              *graphqlTypeRef     `json:"type"`
+	IsDeprecated      bool                `json:"isDeprecated"`
+	DeprecationReason string              `json:"deprecationReason"`
+}
+
+type graphqlType struct {
+	Kind          string                  `json:"kind"`
+	Name          string                  `json:"name"`
+	Description   string                  `json:"description"`
+	Fields        []graphqlField          `json:"fields"`
+	InputFields   []graphqlInputValue     `json:"inputFields"`
+	EnumValues    []struct{ Name string } `json:"enumValues"`
+	PossibleTypes []struct{ Name string } `json:"possibleTypes"`
+	Interfaces    []struct{ Name string } `json:"interfaces"`
+}
+
+// graphqlSchema 内省得到的 schema
+type graphqlSchema struct {
+	QueryType        *struct{ Name string } `json:"queryType"`
+	MutationType     *struct{ Name string } `json:"mutationType"`
+	SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+	Types            []graphqlType          `json:"types"`
+
+	fetched time.Time
+}
+
+// graphqlIntrospectionQuery 标准内省查询，类型引用最多展开 7 层
+const graphqlIntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind name description
+      fields(includeDeprecated: true) {
+        name description
+        args { name description type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+        isDeprecated deprecationReason
+      }
+      inputFields { name description type { ...TypeRef } defaultValue }
+      interfaces { name }
+      enumValues(includeDeprecated: true) { name }
+      possibleTypes { name }
+    }
+  }
+}
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+// graphqlBuiltinScalars 内置标量类型，摘要中不单独列出
+var graphqlBuiltinScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+
+// graphqlSchemaCache 按 endpoint 和认证配置缓存内省结果
+type graphqlSchemaCache struct {
+	mu      sync.Mutex
+	schemas map[string]*graphqlSchema
+}
+
+var graphqlSchemas = &graphqlSchemaCache{schemas: make(map[string]*graphqlSchema)}
+
+func (c *graphqlSchemaCache) Get(key string) *graphqlSchema {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.schemas[key]
+}
+
+func (c *graphqlSchemaCache) Set(key string, schema *graphqlSchema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schemas[key] = schema
+}
+
+// graphqlRequestOptions GraphQL 工具共用的请求参数
+var graphqlRequestOptions = []mcp.ToolOption{
+	mcp.WithString("url",
+		mcp.Required(),
+		mcp.Description("GraphQL endpoint, e.g. https://api.example.com/graphql"),
+		mcp.Pattern("^https?://.*"),
+	),
+	mcp.WithObject("headers",
+		mcp.Description("Request headers, e.g. {\"X-Tenant\": \"demo\"}"),
+	),
+	profileToolOption,
+	sessionToolOption,
+}
+
+var graphqlRequestTool = mcp.NewTool("graphql_request",
+	append([]mcp.ToolOption{
+		mcp.WithDescription("执行 GraphQL 查询或变更，data 与 errors 分开输出；不清楚 schema 时先调用 graphql_schema"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("GraphQL 文档，如 query($id: ID!) { user(id: $id) { id name } }"),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("变量，如 {\"id\": \"42\"}"),
+		),
+		mcp.WithString("operationName",
+			mcp.Description("文档中包含多个操作时要执行的操作名称"),
+		),
+	}, graphqlRequestOptions...)...,
+)
+
+var graphqlSchemaTool = mcp.NewTool("graphql_schema",
+	append([]mcp.ToolOption{
+		mcp.WithDescription("通过内省查询获取 GraphQL schema 摘要：查询、变更、订阅及其参数类型，以及自定义类型；结果按 endpoint 缓存"),
+		mcp.WithString("type",
+			mcp.Description("只显示该类型的详情，包括字段说明和参数"),
+		),
+		mcp.WithBoolean("refresh",
+			mcp.Description("忽略缓存重新内省"),
+		),
+	}, graphqlRequestOptions...)...,
+)
+
+// sendGraphQL 发送 GraphQL 请求，返回 HTTP 状态码和解析后的响应
+func sendGraphQL(ctx context.Context, args map[string]any, payload map[string]any) (int, *graphqlResponse, []byte, error) {
+	req, err := buildRequest(map[string]any{
+		"method":  "POST",
+		"url":     args["url"],
+		"headers": args["headers"],
+		"json":    payload,
+		"profile": args["profile"],
+		"session": args["session"],
+	})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	}
+
+	resp, err := sendRequest(ctx, req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	var gr graphqlResponse
+	if err := json.Unmarshal(resp.Body, &gr); err != nil {
+		return resp.StatusCode, nil, resp.Body, nil
+	}
+	return resp.StatusCode, &gr, resp.Body, nil
+}
+
+func graphqlRequestHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	payload := map[string]any{"query": cast.ToString(args["query"])}
+	if v, ok := args["variables"].(map[string]any); ok && len(v) > 0 {
+		payload["variables"] = v
+	}
+	if name := cast.ToString(args["operationName"]); name != "" {
+		payload["operationName"] = name
+	}
+
+	status, gr, body, err := sendGraphQL(ctx, args, payload)
+	if err != nil {
+		return requestErrorResult(err), nil
+	}
+	if gr == nil {
+		text, _ := truncateUTF8(body, defaultMaxBodyBytes)
+		return mcp.NewToolResultError(fmt.Sprintf("Status: %d\n响应不是 GraphQL JSON: %s", status, text)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Status: %d\n", status)
+	if len(gr.Data) > 0 && string(gr.Data) != "null" {
+		var buf bytes.Buffer
+		json.Indent(&buf, gr.Data, "", "  ")
+		pretty := buf.Bytes()
+		text, truncated := truncateUTF8(pretty, defaultMaxBodyBytes)
+		fmt.Fprintf(&sb, "Data:\n%s\n", text)
+		if truncated {
+			fmt.Fprintf(&sb, "...(truncated, showing %d of %d bytes)\n", len(text), len(pretty))
+		}
+	} else {
+		sb.WriteString("Data: null\n")
+	}
+	if len(gr.Errors) > 0 {
+		fmt.Fprintf(&sb, "Errors (%d):\n%s", len(gr.Errors), formatGraphQLErrors(gr.Errors))
+	}
+
+	result := mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n"))
+	// 只有错误没有数据时视为调用失败
+	result.IsError = len(gr.Errors) > 0 && (len(gr.Data) == 0 || string(gr.Data) == "null")
+	return result, nil
+}
+
+// formatGraphQLErrors 每个错误一行，附带位置、路径和扩展信息
+func formatGraphQLErrors(errs []graphqlError) string {
+	var sb strings.Builder
+	for i, e := range errs {
+		fmt.Fprintf(&sb, "%d. %s", i+1, e.Message)
+		if len(e.Path) > 0 {
+			parts := make([]string, len(e.Path))
+			for j, p := range e.Path {
+				parts[j] = cast.ToString(p)
+			}
+			fmt.Fprintf(&sb, " (path: %s)", strings.Join(parts, "."))
+		}
+		for _, loc := range e.Locations {
+			fmt.Fprintf(&sb, " (line %d, column %d)", loc.Line, loc.Column)
+		}
+		if len(e.Extensions) > 0 {
+			ext, _ := json.Marshal(e.Extensions)
+			fmt.Fprintf(&sb, " extensions: %s", ext)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func graphqlSchemaHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	key, err := graphqlSchemaKey(args)
+	if err != nil {
+		return requestErrorResult(err), nil
+	}
+
+	schema := graphqlSchemas.Get(key)
+	if schema == nil || cast.ToBool(args["refresh"]) {
+		status, gr, body, err := sendGraphQL(ctx, args, map[string]any{"query": graphqlIntrospectionQuery, "operationName": "IntrospectionQuery"})
+		if err != nil {
+			return requestErrorResult(err), nil
+		}
+		if gr == nil {
+			text, _ := truncateUTF8(body, 1024)
+			return mcp.NewToolResultError(fmt.Sprintf("Status: %d\n响应不是 GraphQL JSON: %s", status, text)), nil
+		}
+		var data struct {
+			Schema *graphqlSchema `json:"__schema"`
+		}
+		if err := json.Unmarshal(gr.Data, &data); err != nil || data.Schema == nil {
+			msg := fmt.Sprintf("Status: %d\n内省查询失败，服务端可能关闭了内省", status)
+			if len(gr.Errors) > 0 {
+				msg += "\n" + formatGraphQLErrors(gr.Errors)
+			}
+			return mcp.NewToolResultError(strings.TrimRight(msg, "\n")), nil
+		}
+		schema = data.Schema
+		schema.fetched = time.Now()
+		graphqlSchemas.Set(key, schema)
+	}
+
+	if name := cast.ToString(args["type"]); name != "" {
+		t := schema.lookup(name)
+		if t == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("类型 %s 不存在", name)), nil
+		}
+		return mcp.NewToolResultText(formatGraphQLTypeDetail(t)), nil
+	}
+
+	text, truncated := truncateUTF8([]byte(schema.summary()), defaultMaxBodyBytes)
+	if truncated {
+		text += "\n...(truncated, 使用 type 参数查看单个类型)"
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// graphqlSchemaKey 返回 schema 缓存键
+//
+// 不同的请求头（如租户、按次传入的 Authorization）、认证配置和会话可能看到不同的 schema，
+// 因此都计入缓存键，请求头按排序后的哈希计入，避免在键中保存令牌。
+func graphqlSchemaKey(args map[string]any) (string, error) {
+	req, err := buildRequest(map[string]any{"url": args["url"], "headers": args["headers"]})
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for name, values := range req.Header {
+		for _, v := range values {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join([]string{
+		req.URL,
+		strings.ToLower(cast.ToString(args["profile"])),
+		cast.ToString(args["session"]),
+		sha256Hex([]byte(strings.Join(lines, "\n"))),
+	}, "#"), nil
+}
+
+func (s *graphqlSchema) lookup(name string) *graphqlType {
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+// summary 返回 schema 摘要：根操作及其参数，然后按种类列出自定义类型
+func (s *graphqlSchema) summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Schema (fetched %s)\n", s.fetched.Format(time.RFC3339))
+
+	roots := map[string]bool{}
+	for _, root := range []struct {
+		title string
+		ref   *struct{ Name string }
+	}{
+		{"Queries", s.QueryType},
+		{"Mutations", s.MutationType},
+		{"Subscriptions", s.SubscriptionType},
+	} {
+		if root.ref == nil {
+			continue
+		}
+		roots[root.ref.Name] = true
+		t := s.lookup(root.ref.Name)
+		if t == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n%s (%s):\n", root.title, t.Name)
+		for _, f := range t.Fields {
+			fmt.Fprintf(&sb, "  %s\n", formatGraphQLField(f))
+		}
+	}
+
+	kinds := map[string][]string{}
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") || roots[t.Name] || graphqlBuiltinScalars[t.Name] {
+			continue
+		}
+		var line string
+		switch t.Kind {
+		case "OBJECT", "INTERFACE":
+			fields := make([]string, len(t.Fields))
+			for i, f := range t.Fields {
+				fields[i] = f.Name + ": " + f.Type.String()
+			}
+			line = fmt.Sprintf("%s { %s }", t.Name, strings.Join(fields, ", "))
+		case "INPUT_OBJECT":
+			fields := make([]string, len(t.InputFields))
+			for i, f := range t.InputFields {
+				fields[i] = f.Name + ": " + f.Type.String()
+			}
+			line = fmt.Sprintf("%s { %s }", t.Name, strings.Join(fields, ", "))
+		case "ENUM":
+			values := make([]string, len(t.EnumValues))
+			for i, v := range t.EnumValues {
+				values[i] = v.Name
+			}
+			line = fmt.Sprintf("%s = %s", t.Name, strings.Join(values, " | "))
+		case "UNION":
+			types := make([]string, len(t.PossibleTypes))
+			for i, v := range t.PossibleTypes {
+				types[i] = v.Name
+			}
+			line = fmt.Sprintf("%s = %s", t.Name, strings.Join(types, " | "))
+		default:
+			line = t.Name
+		}
+		kinds[t.Kind] = append(kinds[t.Kind], line)
+	}
+
+	for _, kind := range []struct{ kind, title string }{
+		{"OBJECT", "Types"},
+		{"INTERFACE", "Interfaces"},
+		{"INPUT_OBJECT", "Inputs"},
+		{"ENUM", "Enums"},
+		{"UNION", "Unions"},
+		{"SCALAR", "Scalars"},
+	} {
+		lines := kinds[kind.kind]
+		if len(lines) == 0 {
+			continue
+		}
+		sort.Strings(lines)
+		fmt.Fprintf(&sb, "\n%s:\n", kind.title)
+		for _, line := range lines {
+			fmt.Fprintf(&sb, "  %s\n", line)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatGraphQLField 返回 SDL 形式的字段，如 user(id: ID!): User
+func formatGraphQLField(f graphqlField) string {
+	var sb strings.Builder
+	sb.WriteString(f.Name)
+	if len(f.Args) > 0 {
+		args := make([]string, len(f.Args))
+		for i, a := range f.Args {
+			args[i] = a.Name + ": " + a.Type.String()
+			if a.DefaultValue != nil {
+				args[i] += " = " + *a.DefaultValue
+			}
+		}
+		fmt.Fprintf(&sb, "(%s)", strings.Join(args, ", "))
+	}
+	sb.WriteString(": " + f.Type.String())
+	if f.IsDeprecated {
+		sb.WriteString(" @deprecated")
+		if f.DeprecationReason != "" {
+			fmt.Fprintf(&sb, "(reason: %q)", f.DeprecationReason)
+		}
+	}
+	return sb.String()
+}
+
+// formatGraphQLTypeDetail 返回单个类型的详情，包含说明
+func formatGraphQLTypeDetail(t *graphqlType) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s", strings.ToLower(t.Kind), t.Name)
+	if len(t.Interfaces) > 0 {
+		names := make([]string, len(t.Interfaces))
+		for i, v := range t.Interfaces {
+			names[i] = v.Name
+		}
+		fmt.Fprintf(&sb, " implements %s", strings.Join(names, " & "))
+	}
+	sb.WriteString("\n")
+	if t.Description != "" {
+		fmt.Fprintf(&sb, "# %s\n", t.Description)
+	}
+	for _, f := range t.Fields {
+		fmt.Fprintf(&sb, "  %s", formatGraphQLField(f))
+		if f.Description != "" {
+			fmt.Fprintf(&sb, "  # %s", f.Description)
+		}
+		sb.WriteString("\n")
+	}
+	for _, f := range t.InputFields {
+		fmt.Fprintf(&sb, "  %s: %s", f.Name, f.Type.String())
+		if f.DefaultValue != nil {
+			fmt.Fprintf(&sb, " = %s", *f.DefaultValue)
+		}
+		if f.Description != "" {
+			fmt.Fprintf(&sb, "  # %s", f.Description)
+		}
+		sb.WriteString("\n")
+	}
+	for _, v := range t.EnumValues {
+		fmt.Fprintf(&sb, "  %s\n", v.Name)
+	}
+	for _, v := range t.PossibleTypes {
+		fmt.Fprintf(&sb, "  | %s\n", v.Name)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,160 @@
+package httprequest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphqlTestSchema 测试用的内省结果
+const graphqlTestSchema = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"mutationType": {"name": "Mutation"},
+	"subscriptionType": null,
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "user", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}], "type": {"kind": "OBJECT", "name": "User"}},
+			{"name": "users", "args": [{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "10"}], "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "User"}}}}}
+		]},
+		{"kind": "OBJECT", "name": "Mutation", "fields": [
+			{"name": "createUser", "args": [{"name": "input", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "CreateUserInput"}}}], "type": {"kind": "OBJECT", "name": "User"}}
+		]},
+		{"kind": "OBJECT", "name": "User", "description": "系统用户", "fields": [
+			{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "role", "args": [], "type": {"kind": "ENUM", "name": "Role"}},
+			{"name": "login", "args": [], "type": {"kind": "SCALAR", "name": "String"}, "isDeprecated": true, "deprecationReason": "use email"}
+		]},
+		{"kind": "INPUT_OBJECT", "name": "CreateUserInput", "inputFields": [
+			{"name": "email", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+			{"name": "role", "type": {"kind": "ENUM", "name": "Role"}, "defaultValue": "USER"}
+		]},
+		{"kind": "ENUM", "name": "Role", "enumValues": [{"name": "ADMIN"}, {"name": "USER"}]},
+		{"kind": "SCALAR", "name": "DateTime"},
+		{"kind": "SCALAR", "name": "String"},
+		{"kind": "OBJECT", "name": "__Type", "fields": []}
+	]
+}}}`
+
+func newGraphQLServer(introspections *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Query         string         `json:"query"`
+			Variables     map[string]any `json:"variables"`
+			OperationName string         `json:"operationName"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case payload.OperationName == "IntrospectionQuery":
+			introspections.Add(1)
+			w.Write([]byte(graphqlTestSchema))
+		case strings.Contains(payload.Query, "broken"):
+			w.Write([]byte(`{"errors": [{"message": "Cannot query field \"broken\"", "locations": [{"line": 1, "column": 3}]}]}`))
+		default:
+			data, _ := json.Marshal(map[string]any{
+				"data": map[string]any{"user": map[string]any{"id": payload.Variables["id"], "balance": int64(9007199254740993), "secret": nil}},
+				"errors": []any{map[string]any{
+					"message":    "not authorized",
+					"path":       []any{"user", "secret"},
+					"extensions": map[string]any{"code": "FORBIDDEN"},
+				}},
+			})
+			w.Write(data)
+		}
+	}))
+}
+
+func TestGraphQLRequestHandler(t *testing.T) {
+	var introspections atomic.Int32
+	srv := newGraphQLServer(&introspections)
+	defer srv.Close()
+
+	result, err := graphqlRequestHandler(context.Background(), newCallToolRequest("graphql_request", map[string]any{
+		"url":       srv.URL + "/graphql",
+		"query":     "query($id: ID!) { user(id: $id) { id secret } }",
+		"variables": map[string]any{"id": "42"},
+	}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError, "部分字段出错时仍返回数据")
+	assert.Equal(t, `Status: 200
+Data:
+{
+  "user": {
+    "balance": 9007199254740993,
+    "id": "42",
+    "secret": null
+  }
+}
+Errors (1):
+1. not authorized (path: user.secret) extensions: {"code":"FORBIDDEN"}`, resultText(t, result))
+
+	result, err = graphqlRequestHandler(context.Background(), newCallToolRequest("graphql_request", map[string]any{
+		"url":   srv.URL + "/graphql",
+		"query": "{ broken }",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "1. Cannot query field \"broken\" (line 1, column 3)")
+}
+
+func TestGraphQLSchemaHandler(t *testing.T) {
+	var introspections atomic.Int32
+	srv := newGraphQLServer(&introspections)
+	defer srv.Close()
+	orig := graphqlSchemas
+	graphqlSchemas = &graphqlSchemaCache{schemas: make(map[string]*graphqlSchema)}
+	t.Cleanup(func() { graphqlSchemas = orig })
+
+	call := func(args map[string]any) string {
+		args["url"] = srv.URL + "/graphql"
+		result, err := graphqlSchemaHandler(context.Background(), newCallToolRequest("graphql_schema", args))
+		require.NoError(t, err)
+		return resultText(t, result)
+	}
+
+	text := call(map[string]any{})
+	assert.Contains(t, text, `
+Queries (Query):
+  user(id: ID!): User
+  users(first: Int = 10): [User!]!
+
+Mutations (Mutation):
+  createUser(input: CreateUserInput!): User
+
+Types:
+  User { id: ID!, role: Role, login: String }
+
+Inputs:
+  CreateUserInput { email: String!, role: Role }
+
+Enums:
+  Role = ADMIN | USER
+
+Scalars:
+  DateTime`)
+	assert.NotContains(t, text, "__Type")
+
+	text = call(map[string]any{"type": "User"})
+	assert.Equal(t, "object User\n# 系统用户\n  id: ID!\n  role: Role\n  login: String @deprecated(reason: \"use email\")", text)
+	assert.Equal(t, int32(1), introspections.Load(), "同一 endpoint 的 schema 被缓存")
+
+	call(map[string]any{"refresh": true})
+	assert.Equal(t, int32(2), introspections.Load())
+
+	// 请求头或会话不同时不共用缓存，请求头顺序不影响缓存键
+	call(map[string]any{"headers": map[string]any{"X-Tenant": "a", "X-Trace": "1"}})
+	assert.Equal(t, int32(3), introspections.Load())
+	call(map[string]any{"headers": "X-Trace: 1\nX-Tenant: a"})
+	assert.Equal(t, int32(3), introspections.Load())
+	call(map[string]any{"headers": map[string]any{"X-Tenant": "b"}})
+	assert.Equal(t, int32(4), introspections.Load())
+	call(map[string]any{"session": "s1"})
+	assert.Equal(t, int32(5), introspections.Load())
+}
@@ -0,0 +1,451 @@
+package httprequest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// HAR 1.2 格式，参见 http://www.softwareishard.com/blog/har-12-spec/
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // 毫秒
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string     `json:"mimeType"`
+	Text     string     `json:"text,omitempty"`
+	Params   []harParam `json:"params,omitempty"`
+}
+
+type harParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// harTimings 历史记录只有总耗时，全部计入 wait
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// newHAR 把历史记录转换为 HAR，redact 为 true 时对请求头、URL 和请求/响应体脱敏
+func newHAR(entries []*HistoryEntry, redact bool) *harFile {
+	har := &harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "mcpservers/httprequest", Version: "1.0"},
+		Entries: make([]harEntry, 0, len(entries)),
+	}}
+	for _, e := range entries {
+		har.Log.Entries = append(har.Log.Entries, harEntryFromHistory(e, redact))
+	}
+	return har
+}
+
+func harEntryFromHistory(e *HistoryEntry, redact bool) harEntry {
+	req := e.Request.Clone()
+	respHeader := http.Header(e.Header)
+	respBody := e.Body
+	if redact {
+		req.URL = helper.RedactURL(req.URL)
+		req.Header = helper.RedactHeader(req.Header)
+		if req.Body != "" {
+			req.Body = helper.RedactBody([]byte(req.Body))
+		}
+		respHeader = helper.RedactHeader(respHeader)
+		if e.BodyEncoding == "" && respBody != "" {
+			respBody = helper.RedactBody([]byte(respBody))
+		}
+	}
+
+	entry := harEntry{
+		StartedDateTime: e.Time,
+		Time:            float64(e.DurationMs),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: harQuery(req.URL),
+			HeadersSize: -1,
+			BodySize:    len(req.Body),
+		},
+		Response: harResponse{
+			Status:      e.Status,
+			StatusText:  http.StatusText(e.Status),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(respHeader),
+			Content: harContent{
+				Size:     e.BodySize,
+				MimeType: respHeader.Get("Content-Type"),
+				Text:     respBody,
+				Encoding: e.BodyEncoding,
+			},
+			RedirectURL: respHeader.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    e.BodySize,
+		},
+		Timings: harTimings{Send: 0, Wait: float64(e.DurationMs), Receive: 0},
+		Comment: fmt.Sprintf("history #%d", e.ID),
+	}
+
+	switch {
+	case len(req.Parts) > 0:
+		pd := &harPostData{MimeType: "multipart/form-data"}
+		for _, p := range req.Parts {
+			pd.Params = append(pd.Params, harParam{Name: p.Name, Value: p.Value, FileName: p.FileName, ContentType: p.ContentType})
+			if p.FilePath != "" && p.FileName == "" {
+				pd.Params[len(pd.Params)-1].FileName = filepath.Base(p.FilePath)
+			}
+		}
+		entry.Request.PostData = pd
+	case req.Body != "":
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: req.Body}
+	}
+
+	switch {
+	case e.Error != "":
+		entry.Response.Content.Comment = "error: " + e.Error
+	case e.BodyTruncated:
+		entry.Response.Content.Comment = fmt.Sprintf("body truncated, original size %d bytes", e.BodySize)
+	}
+	return entry
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	out := []harNameValue{}
+	for _, k := range sortedKeys(header) {
+		for _, v := range header[k] {
+			out = append(out, harNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func harQuery(rawURL string) []harNameValue {
+	out := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return out
+	}
+	query := u.Query()
+	for _, k := range sortedKeys(query) {
+		for _, v := range query[k] {
+			out = append(out, harNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+// harSkipHeaders 重新执行 HAR 请求时不发送的请求头，由客户端根据实际请求生成
+var harSkipHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Host":              true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// toRequest 把 HAR 请求转换为可执行的请求
+func (r *harRequest) toRequest() (*Request, error) {
+	if r.Method == "" || r.URL == "" {
+		return nil, fmt.Errorf("缺少 method 或 url")
+	}
+	req := &Request{Method: strings.ToUpper(r.Method), URL: r.URL, Header: make(http.Header)}
+	for _, h := range r.Headers {
+		// 浏览器导出的 HTTP/2 伪首部（:authority 等）不能作为请求头发送
+		if strings.HasPrefix(h.Name, ":") || harSkipHeaders[http.CanonicalHeaderKey(h.Name)] {
+			continue
+		}
+		req.Header.Add(h.Name, h.Value)
+	}
+	if len(r.Cookies) > 0 && req.Header.Get("Cookie") == "" {
+		cookies := make([]string, len(r.Cookies))
+		for i, c := range r.Cookies {
+			cookies[i] = c.Name + "=" + c.Value
+		}
+		req.Header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+
+	if pd := r.PostData; pd != nil {
+		mediaType, _, _ := mime.ParseMediaType(pd.MimeType)
+		switch {
+		case pd.Text != "":
+			req.Body = pd.Text
+		case mediaType == "multipart/form-data":
+			req.Header.Del("Content-Type")
+			for _, p := range pd.Params {
+				if p.FileName != "" {
+					return nil, fmt.Errorf("multipart 文件字段 %s 没有文件内容，无法重新执行", p.Name)
+				}
+				req.Parts = append(req.Parts, FormPart{Name: p.Name, Value: p.Value})
+			}
+		case len(pd.Params) > 0:
+			values := make(url.Values)
+			for _, p := range pd.Params {
+				values.Add(p.Name, p.Value)
+			}
+			req.Body = values.Encode()
+		}
+		if req.Header.Get("Content-Type") == "" && pd.MimeType != "" && len(req.Parts) == 0 {
+			req.Header.Set("Content-Type", pd.MimeType)
+		}
+	}
+	return req, nil
+}
+
+// readHAR 读取 HAR 文件
+func readHAR(path string) (*harFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("解析 HAR 文件失败: %w", err)
+	}
+	return &har, nil
+}
+
+var httpHarExportTool = mcp.NewTool("http_har_export",
+	mcp.WithDescription("把请求历史导出为 HAR 1.2 文件（包含耗时、请求头、请求体和响应体），便于在浏览器开发者工具或其他团队之间共享"),
+	mcp.WithString("path",
+		mcp.Required(),
+		mcp.Description("输出文件的绝对路径，如 /tmp/repro.har；文件已存在时报错，不会覆盖"),
+	),
+	mcp.WithArray("ids",
+		mcp.Items(map[string]any{"type": "number"}),
+		mcp.Description("要导出的历史记录编号，默认导出全部"),
+	),
+	mcp.WithString("filter",
+		mcp.Description("只导出方法或 URL 包含该文本的记录"),
+	),
+	mcp.WithBoolean("redact",
+		mcp.Description("是否对 Authorization、Cookie、token 等敏感信息脱敏，默认 true"),
+	),
+)
+
+var httpHarImportTool = mcp.NewTool("http_har_import",
+	mcp.WithDescription("读取 HAR 文件：未指定 entries 时列出其中的请求，指定 entries 时按顺序重新执行这些请求并与文件中记录的状态码对比"),
+	mcp.WithString("path",
+		mcp.Required(),
+		mcp.Description("HAR 文件的绝对路径"),
+	),
+	mcp.WithArray("entries",
+		mcp.Items(map[string]any{"type": "number"}),
+		mcp.Description("要重新执行的请求序号（从 1 开始，与列表中的序号一致）"),
+	),
+	mcp.WithString("filter",
+		mcp.Description("只列出方法或 URL 包含该文本的请求"),
+	),
+	mcp.WithString("baseUrl",
+		mcp.Description("重新执行时替换请求的协议、主机和端口，如 http://localhost:8080"),
+	),
+	profileToolOption,
+	sessionToolOption,
+)
+
+func httpHarExportHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	path := cast.ToString(args["path"])
+	if path == "" {
+		return mcp.NewToolResultError("path 参数不能为空"), nil
+	}
+
+	ids := make(map[int]bool)
+	for _, id := range cast.ToIntSlice(args["ids"]) {
+		ids[id] = true
+	}
+	filter := strings.ToLower(cast.ToString(args["filter"]))
+
+	var entries []*HistoryEntry
+	list := getHistory().List()
+	for i := len(list) - 1; i >= 0; i-- {
+		e := list[i]
+		if len(ids) > 0 && !ids[e.ID] {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(e.Request.Method+" "+e.Request.URL), filter) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return mcp.NewToolResultError("没有可导出的请求历史"), nil
+	}
+
+	redact := true
+	if v, ok := args["redact"]; ok {
+		redact = cast.ToBool(v)
+	}
+	data, err := json.MarshalIndent(newHAR(entries, redact), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("生成 HAR 失败", err), nil
+	}
+	if err := writeNewFile(path, data, 0o600); err != nil {
+		return mcp.NewToolResultErrorFromErr("写入 HAR 文件失败", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已导出 %d 条请求到 %s", len(entries), path)), nil
+}
+
+// writeNewFile 创建新文件并写入 data，文件已存在时报错而不是覆盖，写入失败时删除不完整的文件
+func writeNewFile(path string, data []byte, perm os.FileMode) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("文件 %s 已存在，请换一个路径", path)
+		}
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	_, err = f.Write(data)
+	return err
+}
+
+func httpHarImportHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	har, err := readHAR(cast.ToString(args["path"]))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("读取 HAR 文件失败", err), nil
+	}
+	entries := har.Log.Entries
+
+	selected := cast.ToIntSlice(args["entries"])
+	if len(selected) == 0 {
+		filter := strings.ToLower(cast.ToString(args["filter"]))
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "共 %d 个请求\n", len(entries))
+		for i, e := range entries {
+			if filter != "" && !strings.Contains(strings.ToLower(e.Request.Method+" "+e.Request.URL), filter) {
+				continue
+			}
+			fmt.Fprintf(&sb, "[%d] %s %s -> %d (%.0fms)\n", i+1, e.Request.Method, helper.RedactURL(e.Request.URL), e.Response.Status, e.Time)
+		}
+		return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+	}
+
+	base := cast.ToString(args["baseUrl"])
+	var sb strings.Builder
+	for _, n := range selected {
+		if n < 1 || n > len(entries) {
+			fmt.Fprintf(&sb, "[%d] 序号超出范围 1-%d\n", n, len(entries))
+			continue
+		}
+		e := entries[n-1]
+		req, err := e.Request.toRequest()
+		if err == nil && base != "" {
+			req.URL, err = rebaseURL(req.URL, base)
+		}
+		if err != nil {
+			fmt.Fprintf(&sb, "[%d] %s %s: %v\n", n, e.Request.Method, helper.RedactURL(e.Request.URL), err)
+			continue
+		}
+		req.Profile = cast.ToString(args["profile"])
+		req.Session = cast.ToString(args["session"])
+
+		resp, err := sendRequest(ctx, req)
+		if err != nil {
+			fmt.Fprintf(&sb, "[%d] %s %s: %v\n", n, req.Method, helper.RedactURL(req.URL), helper.RedactError(err))
+			continue
+		}
+		fmt.Fprintf(&sb, "[%d] %s %s -> %d", n, req.Method, helper.RedactURL(req.URL), resp.StatusCode)
+		if e.Response.Status != 0 && e.Response.Status != resp.StatusCode {
+			fmt.Fprintf(&sb, " (HAR 中为 %d)", e.Response.Status)
+		}
+		text, truncated := truncateUTF8(resp.Body, 512)
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if isBinaryBody(mediaType, resp.Body) {
+			text, truncated = fmt.Sprintf("<binary %d bytes>", len(resp.Body)), false
+		}
+		fmt.Fprintf(&sb, "\n%s", text)
+		if truncated {
+			fmt.Fprintf(&sb, "...(共 %d bytes)", len(resp.Body))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("重新执行的请求已记录到请求历史，可用 http_history_list 查看完整响应")
+	return mcp.NewToolResultText(sb.String()), nil
+}
@@ -0,0 +1,85 @@
+package httprequest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpHarExportImport(t *testing.T) {
+	resetHistory(t, HistoryConfig{})
+	srv := newEchoServer()
+	defer srv.Close()
+
+	_, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+		"method":  "POST",
+		"url":     srv.URL + "/items?page=2&token=abc",
+		"headers": map[string]any{"Authorization": "Bearer abc", "X-Trace": "t1"},
+		"json":    map[string]any{"name": "demo", "password": "p@ss"},
+	}))
+	require.NoError(t, err)
+	_, err = httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+		"method": "GET",
+		"url":    srv.URL + "/health",
+	}))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "repro.har")
+	result, err := httpHarExportHandler(context.Background(), newCallToolRequest("http_har_export", map[string]any{"path": path}))
+	require.NoError(t, err)
+	assert.Equal(t, "已导出 2 条请求到 "+path, resultText(t, result))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// 不覆盖已存在的文件
+	result, err = httpHarExportHandler(context.Background(), newCallToolRequest("http_har_export", map[string]any{"path": path, "redact": false}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "已存在")
+	unchanged, _ := os.ReadFile(path)
+	assert.Equal(t, data, unchanged)
+
+	assert.NotContains(t, string(data), "abc")
+	assert.NotContains(t, string(data), "p@ss")
+
+	har, err := readHAR(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", har.Log.Version)
+	require.Len(t, har.Log.Entries, 2)
+	first := har.Log.Entries[0]
+	assert.Equal(t, "POST", first.Request.Method)
+	assert.Contains(t, first.Request.Headers, harNameValue{Name: "X-Trace", Value: "t1"})
+	assert.Contains(t, first.Request.QueryString, harNameValue{Name: "page", Value: "2"})
+	require.NotNil(t, first.Request.PostData)
+	assert.Equal(t, "application/json", first.Request.PostData.MimeType)
+	assert.JSONEq(t, `{"name": "demo", "password": "***"}`, first.Request.PostData.Text)
+	assert.Equal(t, 200, first.Response.Status)
+	assert.Equal(t, "text/plain", first.Response.Content.MimeType)
+	assert.Contains(t, first.Response.Content.Text, "POST /items?page=2")
+
+	result, err = httpHarImportHandler(context.Background(), newCallToolRequest("http_har_import", map[string]any{"path": path}))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.Contains(t, text, "共 2 个请求")
+	assert.Contains(t, text, "[2] GET "+srv.URL+"/health -> 200")
+
+	other := newEchoServer()
+	defer other.Close()
+	result, err = httpHarImportHandler(context.Background(), newCallToolRequest("http_har_import", map[string]any{
+		"path":    path,
+		"entries": []any{float64(1), float64(3)},
+		"baseUrl": other.URL,
+	}))
+	require.NoError(t, err)
+	text = resultText(t, result)
+	assert.Contains(t, text, "[1] POST "+other.URL+"/items?page=2&token=*** -> 200")
+	assert.Contains(t, text, "X-Trace: t1")
+	assert.Contains(t, text, `{"name":"demo","password":"***"}`)
+	assert.Contains(t, text, "[3] 序号超出范围 1-2")
+	assert.Len(t, getHistory().List(), 3, "重新执行的请求记录到历史")
+}
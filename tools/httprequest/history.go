@@ -0,0 +1,481 @@
+package httprequest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// HistoryConfig 请求历史配置，对应 http.yaml 的 history 区块
+type HistoryConfig struct {
+	Disable      bool   `mapstructure:"disable" json:"disable" yaml:"disable"`                      // 关闭请求历史记录
+	Persist      bool   `mapstructure:"persist" json:"persist" yaml:"persist"`                      // 是否保存到磁盘，进程重启后仍可重放；记录中包含请求头和请求/响应体，默认关闭
+	File         string `mapstructure:"file" json:"file" yaml:"file"`                               // 历史文件，默认 config/http/history.jsonl
+	Limit        int    `mapstructure:"limit" json:"limit" yaml:"limit"`                            // 保留的最大条数
+	MaxBodyBytes int    `mapstructure:"max_body_bytes" json:"max_body_bytes" yaml:"max_body_bytes"` // 每条记录保存的请求体和响应体最大字节数
+}
+
+// DefaultHistoryConfig 返回默认的请求历史配置
+func DefaultHistoryConfig() HistoryConfig {
+	return HistoryConfig{
+		Limit:        200,
+		MaxBodyBytes: 32 * 1024,
+	}
+}
+
+// HistoryEntry 一次请求的历史记录
+//
+// Request 为注入认证信息之前的请求，重放时会重新应用认证配置，因此记录中不包含配置里的密钥。
+type HistoryEntry struct {
+	ID               int                 `json:"id"`
+	Time             time.Time           `json:"time"`
+	DurationMs       int64               `json:"durationMs"`
+	Request          Request             `json:"request"`
+	RequestTruncated bool                `json:"requestTruncated,omitempty"` // 请求体超过保存上限，不能重放
+	Status           int                 `json:"status,omitempty"`
+	Header           map[string][]string `json:"header,omitempty"`
+	BodySize         int                 `json:"bodySize"`
+	BodySHA256       string              `json:"bodySha256,omitempty"`
+	Body             string              `json:"body,omitempty"`         // 截断后的响应体
+	BodyEncoding     string              `json:"bodyEncoding,omitempty"` // 二进制响应体为 base64
+	BodyTruncated    bool                `json:"bodyTruncated,omitempty"`
+	Error            string              `json:"error,omitempty"`
+}
+
+// historyStore 请求历史，保留最近 Limit 条，配置了持久化时追加写入 JSON Lines 文件
+type historyStore struct {
+	mu       sync.Mutex
+	cfg      HistoryConfig
+	loaded   bool
+	entries  []*HistoryEntry
+	nextID   int
+	appended int // 上次整理文件后追加的条数，超过 Limit 时重写文件去掉旧记录
+}
+
+func newHistoryStore(cfg HistoryConfig) *historyStore {
+	defaults := DefaultHistoryConfig()
+	if cfg.Limit <= 0 {
+		cfg.Limit = defaults.Limit
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaults.MaxBodyBytes
+	}
+	if !cfg.Persist {
+		cfg.File = ""
+	} else if cfg.File == "" {
+		cfg.File = filepath.Join(helper.GetConfigDir("http"), "history.jsonl")
+	}
+	return &historyStore{cfg: cfg, nextID: 1}
+}
+
+// getHistory 返回请求历史，测试中可替换
+var getHistory = sync.OnceValue(func() *historyStore {
+	cfg := DefaultHistoryConfig()
+	if err := helper.HttpConfig().UnmarshalKey("history", &cfg); err != nil {
+		log.Printf("解析请求历史配置失败，使用默认配置: %v", err)
+		cfg = DefaultHistoryConfig()
+	}
+	return newHistoryStore(cfg)
+})
+
+// load 首次访问时从文件加载历史，调用方需持有锁
+func (s *historyStore) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	if s.cfg.File == "" {
+		return
+	}
+
+	f, err := os.Open(s.cfg.File)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("读取请求历史失败: %v", err)
+		}
+		return
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for {
+		var e HistoryEntry
+		if err := dec.Decode(&e); err != nil {
+			if err != io.EOF {
+				log.Printf("请求历史文件 %s 格式错误，忽略后续记录: %v", s.cfg.File, err)
+			}
+			break
+		}
+		s.entries = append(s.entries, &e)
+		if e.ID >= s.nextID {
+			s.nextID = e.ID + 1
+		}
+	}
+	if len(s.entries) > s.cfg.Limit {
+		s.entries = s.entries[len(s.entries)-s.cfg.Limit:]
+	}
+}
+
+// newEntry 根据请求和响应生成历史记录，请求体和响应体按 MaxBodyBytes 截断
+func (s *historyStore) newEntry(req *Request, resp *helper.HttpResponse, err error, start time.Time, d time.Duration) *HistoryEntry {
+	e := &HistoryEntry{
+		Time:       start,
+		DurationMs: d.Milliseconds(),
+		Request:    *req.Clone(),
+	}
+	e.Request.Body, e.RequestTruncated = truncateUTF8([]byte(req.Body), s.cfg.MaxBodyBytes)
+	if err != nil {
+		e.Error = helper.RedactError(err).Error()
+		return e
+	}
+
+	e.Status = resp.StatusCode
+	e.Header = resp.Header.Clone()
+	e.BodySize = len(resp.Body)
+	e.BodySHA256 = sha256Hex(resp.Body)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if isBinaryBody(mediaType, resp.Body) {
+		body := resp.Body
+		if len(body) > s.cfg.MaxBodyBytes {
+			body, e.BodyTruncated = body[:s.cfg.MaxBodyBytes], true
+		}
+		e.Body, e.BodyEncoding = base64.StdEncoding.EncodeToString(body), "base64"
+	} else {
+		e.Body, e.BodyTruncated = truncateUTF8(resp.Body, s.cfg.MaxBodyBytes)
+	}
+	return e
+}
+
+// Record 记录一次请求，返回分配了编号的历史记录
+func (s *historyStore) Record(req *Request, resp *helper.HttpResponse, err error, start time.Time, d time.Duration) *HistoryEntry {
+	if s.cfg.Disable {
+		return nil
+	}
+	e := s.newEntry(req, resp, err, start, d)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	e.ID = s.nextID
+	s.nextID++
+	s.entries = append(s.entries, e)
+	if len(s.entries) > s.cfg.Limit {
+		s.entries = s.entries[len(s.entries)-s.cfg.Limit:]
+	}
+	if err := s.persist(e); err != nil {
+		log.Printf("保存请求历史失败: %v", err)
+	}
+	return e
+}
+
+// persist 追加写入一条记录，调用方需持有锁
+func (s *historyStore) persist(e *HistoryEntry) error {
+	if s.cfg.File == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cfg.File), 0o700); err != nil {
+		return err
+	}
+
+	s.appended++
+	if s.appended > s.cfg.Limit {
+		s.appended = 0
+		return s.rewrite()
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// 记录中可能包含请求头里的凭证，文件只允许当前用户读写
+	f, err := os.OpenFile(s.cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rewrite 用内存中的记录重写历史文件，调用方需持有锁
+func (s *historyStore) rewrite() error {
+	var sb strings.Builder
+	for _, e := range s.entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+		sb.WriteByte('\n')
+	}
+	tmp := s.cfg.File + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.cfg.File)
+}
+
+// Get 按编号返回历史记录
+func (s *historyStore) Get(id int) (*HistoryEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	for _, e := range s.entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// List 返回最近的历史记录，最新的排在前面
+func (s *historyStore) List() []*HistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	out := make([]*HistoryEntry, len(s.entries))
+	for i, e := range s.entries {
+		out[len(s.entries)-1-i] = e
+	}
+	return out
+}
+
+var httpHistoryListTool = mcp.NewTool("http_history_list",
+	mcp.WithDescription("列出最近执行过的 HTTP 请求（http_request、http_request_raw、http_file_run），指定 id 时显示该记录的请求、响应头和保存的响应体"),
+	mcp.WithNumber("id",
+		mcp.Description("历史记录编号，指定时显示详情"),
+	),
+	mcp.WithString("filter",
+		mcp.Description("只列出方法或 URL 包含该文本的记录"),
+	),
+	mcp.WithNumber("limit",
+		mcp.Description("最多列出的条数，默认 20"),
+	),
+)
+
+var httpHistoryReplayTool = mcp.NewTool("http_history_replay",
+	mcp.WithDescription("重新执行一条历史请求，可替换为其他环境的 baseUrl，并与原响应做结构化 JSON 对比；重放结果同样记录到历史中"),
+	mcp.WithNumber("id",
+		mcp.Required(),
+		mcp.Description("历史记录编号"),
+	),
+	mcp.WithString("baseUrl",
+		mcp.Description("替换请求的协议、主机和端口，如 https://staging.example.com；带路径时作为前缀拼接到原路径前"),
+	),
+	mcp.WithBoolean("diff",
+		mcp.Description("是否输出与原响应的差异，默认 true"),
+	),
+	mcp.WithArray("ignore",
+		mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("对比时忽略的 JSON 路径，如 [\"$.data.updatedAt\", \"$.items[*].id\"]"),
+	),
+)
+
+var httpHistoryDiffTool = mcp.NewTool("http_history_diff",
+	mcp.WithDescription("比较两条历史记录的状态码和 JSON 响应体，按路径列出新增(+)、删除(-)和修改(~)的字段"),
+	mcp.WithNumber("left",
+		mcp.Required(),
+		mcp.Description("旧的历史记录编号"),
+	),
+	mcp.WithNumber("right",
+		mcp.Required(),
+		mcp.Description("新的历史记录编号"),
+	),
+	mcp.WithArray("ignore",
+		mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("忽略的 JSON 路径，如 [\"$.data.updatedAt\", \"$.items[*].id\"]"),
+	),
+)
+
+func httpHistoryListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	store := getHistory()
+	if id := cast.ToInt(args["id"]); id > 0 {
+		e, ok := store.Get(id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("历史记录 #%d 不存在", id)), nil
+		}
+		return mcp.NewToolResultText(formatHistoryEntry(e)), nil
+	}
+
+	limit := cast.ToInt(args["limit"])
+	if limit <= 0 {
+		limit = 20
+	}
+	filter := strings.ToLower(cast.ToString(args["filter"]))
+
+	var sb strings.Builder
+	n := 0
+	for _, e := range store.List() {
+		if n >= limit {
+			break
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(e.Request.Method+" "+e.Request.URL), filter) {
+			continue
+		}
+		n++
+		fmt.Fprintf(&sb, "#%d %s %s %s -> %s (%dms)\n", e.ID, e.Time.Format(time.RFC3339), e.Request.Method,
+			helper.RedactURL(e.Request.URL), historyResult(e), e.DurationMs)
+	}
+	if n == 0 {
+		return mcp.NewToolResultText("没有请求历史"), nil
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+func httpHistoryReplayHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	id := cast.ToInt(args["id"])
+	orig, ok := getHistory().Get(id)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("历史记录 #%d 不存在", id)), nil
+	}
+	if orig.RequestTruncated {
+		return mcp.NewToolResultError(fmt.Sprintf("历史记录 #%d 的请求体超过保存上限，无法重放", id)), nil
+	}
+
+	req := orig.Request.Clone()
+	if base := cast.ToString(args["baseUrl"]); base != "" {
+		u, err := rebaseURL(req.URL, base)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("baseUrl 无效", err), nil
+		}
+		req.URL = u
+	}
+
+	start := time.Now()
+	resp, err := sendRequest(ctx, req)
+	if err != nil {
+		return requestErrorResult(err), nil
+	}
+	result, err := formatResponse(resp, responseOptionsFromArgs(args))
+	if err != nil || result.IsError {
+		return result, err
+	}
+
+	if diff, ok := args["diff"]; !ok || cast.ToBool(diff) {
+		replayed := getHistory().newEntry(req, resp, nil, start, time.Since(start))
+		result.Content = append(result.Content, mcp.NewTextContent(diffEntries(orig, replayed, cast.ToStringSlice(args["ignore"]))))
+	}
+	return result, nil
+}
+
+func httpHistoryDiffHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	store := getHistory()
+	var entries [2]*HistoryEntry
+	for i, key := range []string{"left", "right"} {
+		id := cast.ToInt(args[key])
+		e, ok := store.Get(id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("历史记录 #%d 不存在", id)), nil
+		}
+		entries[i] = e
+	}
+	return mcp.NewToolResultText(diffEntries(entries[0], entries[1], cast.ToStringSlice(args["ignore"]))), nil
+}
+
+// entryLabel 返回差异中显示的记录名称，未记录到历史的重放结果没有编号
+func entryLabel(e *HistoryEntry) string {
+	if e.ID == 0 {
+		return "重放"
+	}
+	return fmt.Sprintf("#%d", e.ID)
+}
+
+// historyResult 返回列表中显示的请求结果
+func historyResult(e *HistoryEntry) string {
+	if e.Error != "" {
+		return "error: " + e.Error
+	}
+	return fmt.Sprintf("%d, %d bytes", e.Status, e.BodySize)
+}
+
+// formatHistoryEntry 返回历史记录详情，请求头和 URL 中的敏感信息会被脱敏
+func formatHistoryEntry(e *HistoryEntry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%d %s (%dms)\n", e.ID, e.Time.Format(time.RFC3339), e.DurationMs)
+	fmt.Fprintf(&sb, "Request: %s %s\n", e.Request.Method, helper.RedactURL(e.Request.URL))
+	reqHeader := helper.RedactHeader(e.Request.Header)
+	for _, k := range sortedKeys(reqHeader) {
+		for _, v := range reqHeader[k] {
+			fmt.Fprintf(&sb, "  %s: %s\n", k, v)
+		}
+	}
+	if e.Request.Profile != "" {
+		fmt.Fprintf(&sb, "Profile: %s\n", e.Request.Profile)
+	}
+	if e.Request.Session != "" {
+		fmt.Fprintf(&sb, "Session: %s\n", e.Request.Session)
+	}
+	for _, p := range e.Request.Parts {
+		if p.FilePath != "" {
+			fmt.Fprintf(&sb, "Part: %s=@%s\n", p.Name, p.FilePath)
+		} else {
+			fmt.Fprintf(&sb, "Part: %s=%s\n", p.Name, p.Value)
+		}
+	}
+	if e.Request.Body != "" {
+		fmt.Fprintf(&sb, "Request Body: %s\n", helper.RedactBody([]byte(e.Request.Body)))
+	}
+
+	if e.Error != "" {
+		fmt.Fprintf(&sb, "Error: %s", e.Error)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "Status: %d\nHeaders:\n", e.Status)
+	respHeader := helper.RedactHeader(e.Header)
+	for _, k := range sortedKeys(respHeader) {
+		for _, v := range respHeader[k] {
+			fmt.Fprintf(&sb, "  %s: %s\n", k, v)
+		}
+	}
+	fmt.Fprintf(&sb, "Body (%d bytes, sha256: %s)", e.BodySize, e.BodySHA256)
+	switch {
+	case e.BodyEncoding != "":
+		sb.WriteString(": 二进制内容")
+	case e.BodyTruncated:
+		fmt.Fprintf(&sb, ", 仅保存前 %d bytes:\n%s", len(e.Body), e.Body)
+	default:
+		fmt.Fprintf(&sb, ":\n%s", e.Body)
+	}
+	return sb.String()
+}
+
+// rebaseURL 把请求 URL 的协议、主机和端口替换为 base 的，base 带路径时拼接为前缀
+func rebaseURL(rawURL, base string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if b.Scheme == "" || b.Host == "" {
+		return "", fmt.Errorf("%s 缺少协议或主机", base)
+	}
+	u.Scheme, u.Host, u.User = b.Scheme, b.Host, b.User
+	if prefix := strings.TrimSuffix(b.Path, "/"); prefix != "" {
+		u.Path = prefix + u.Path
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,148 @@
+package httprequest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetHistory 在测试期间使用独立的请求历史
+func resetHistory(t *testing.T, cfg HistoryConfig) *historyStore {
+	t.Helper()
+	store := newHistoryStore(cfg)
+	orig := getHistory
+	getHistory = func() *historyStore { return store }
+	t.Cleanup(func() { getHistory = orig })
+	return store
+}
+
+func TestHttpHistory(t *testing.T) {
+	resetHistory(t, HistoryConfig{})
+	var version atomic.Int32
+	version.Store(1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if version.Load() == 1 {
+			w.Write([]byte(`{"id": 1, "name": "demo", "tags": ["a", "b"], "updatedAt": "t1"}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1, "name": "demo2", "tags": ["a"], "owner": "bob", "updatedAt": "t2"}`))
+	}))
+	defer srv.Close()
+
+	_, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+		"method":  "GET",
+		"url":     srv.URL + "/items/1?token=secret",
+		"headers": map[string]any{"Authorization": "Bearer secret"},
+	}))
+	require.NoError(t, err)
+
+	result, err := httpHistoryListHandler(context.Background(), newCallToolRequest("http_history_list", nil))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.Contains(t, text, "#1 ")
+	assert.Contains(t, text, "GET "+srv.URL+"/items/1?token=***")
+	assert.Contains(t, text, "-> 200, 64 bytes")
+
+	result, err = httpHistoryListHandler(context.Background(), newCallToolRequest("http_history_list", map[string]any{"id": 1}))
+	require.NoError(t, err)
+	text = resultText(t, result)
+	assert.Contains(t, text, "Authorization: ***")
+	assert.NotContains(t, text, "secret")
+	assert.Contains(t, text, `"name": "demo"`)
+
+	version.Store(2)
+	result, err = httpHistoryReplayHandler(context.Background(), newCallToolRequest("http_history_replay", map[string]any{
+		"id":     1,
+		"ignore": []any{"$.updatedAt"},
+	}))
+	require.NoError(t, err)
+	require.Len(t, result.Content, 2)
+	assert.Contains(t, resultText(t, result), "demo2")
+	diff, ok := result.Content[1].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Equal(t, "Diff #1 → 重放\nStatus: 200 (相同)\nBody: 3 处差异\n~ $.name: \"demo\" → \"demo2\"\n+ $.owner: \"bob\"\n- $.tags[1]: \"b\"", diff.Text)
+
+	result, err = httpHistoryDiffHandler(context.Background(), newCallToolRequest("http_history_diff", map[string]any{
+		"left":   1,
+		"right":  2,
+		"ignore": []any{"$.updatedAt"},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, `Diff #1 → #2
+Status: 200 (相同)
+Body: 3 处差异
+~ $.name: "demo" → "demo2"
++ $.owner: "bob"
+- $.tags[1]: "b"`, resultText(t, result))
+
+	result, err = httpHistoryReplayHandler(context.Background(), newCallToolRequest("http_history_replay", map[string]any{"id": 99}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHistoryStore_Persist(t *testing.T) {
+	assert.False(t, DefaultHistoryConfig().Persist, "历史记录包含请求头和响应体，默认不写入磁盘")
+
+	file := filepath.Join(t.TempDir(), "history.jsonl")
+	cfg := HistoryConfig{Persist: true, File: file, Limit: 3, MaxBodyBytes: 8}
+	store := newHistoryStore(cfg)
+	for i := 0; i < 5; i++ {
+		req := &Request{Method: "POST", URL: "https://api.example.com/items", Body: strings.Repeat("x", i*3)}
+		resp := &helper.HttpResponse{StatusCode: 200, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"n": 12345678}`)}
+		store.Record(req, resp, nil, time.Now(), 0)
+	}
+
+	loaded := newHistoryStore(cfg)
+	entries := loaded.List()
+	require.Len(t, entries, 3)
+	assert.Equal(t, []int{5, 4, 3}, []int{entries[0].ID, entries[1].ID, entries[2].ID})
+	assert.True(t, entries[0].RequestTruncated)
+	assert.False(t, entries[2].RequestTruncated)
+	assert.True(t, entries[0].BodyTruncated)
+	assert.Equal(t, `{"n": 12`, entries[0].Body)
+	assert.Equal(t, 15, entries[0].BodySize)
+
+	e := loaded.Record(&Request{Method: "GET", URL: "https://api.example.com"}, nil, assert.AnError, time.Now(), 0)
+	assert.Equal(t, 6, e.ID)
+	assert.Equal(t, assert.AnError.Error(), e.Error)
+}
+
+func TestDiffJSON(t *testing.T) {
+	var a, b any
+	require.NoError(t, json.Unmarshal([]byte(`{"a": 1, "list": [{"id": 1, "v": "x"}, {"id": 2, "v": "y"}], "obj": {"k": 1}, "weird key": true}`), &a))
+	require.NoError(t, json.Unmarshal([]byte(`{"a": 1.5, "list": [{"id": 9, "v": "x"}, {"id": 8, "v": "z"}], "obj": [1], "weird key": false}`), &b))
+
+	lines, total := diffJSON(a, b, []string{"$.list[*].id"})
+	assert.Equal(t, 4, total)
+	assert.Equal(t, []string{
+		`~ $.a: 1 → 1.5`,
+		`~ $.list[1].v: "y" → "z"`,
+		`~ $.obj: {"k":1} → [1]`,
+		`~ $["weird key"]: true → false`,
+	}, lines)
+}
+
+func TestRebaseURL(t *testing.T) {
+	u, err := rebaseURL("http://localhost:8080/api/items?q=1", "https://staging.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com/api/items?q=1", u)
+
+	u, err = rebaseURL("http://localhost:8080/items", "https://example.com/v2/")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v2/items", u)
+
+	_, err = rebaseURL("http://localhost/items", "example.com")
+	assert.Error(t, err)
+}
@@ -0,0 +1,504 @@
+package httprequest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// httpFileMethods .http 文件请求行中可识别的 HTTP 方法
+var httpFileMethods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+	"HEAD": true, "OPTIONS": true, "TRACE": true, "CONNECT": true,
+}
+
+var (
+	httpFileVarPattern  = regexp.MustCompile(`^@([A-Za-z0-9_.\-]+)\s*=\s*(.*)$`)
+	httpFileNamePattern = regexp.MustCompile(`^(?:#|//)\s*@name\s+(\S+)`)
+	templatePattern     = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+)
+
+// HTTPFile 表示解析后的 .http / .rest 文件
+type HTTPFile struct {
+	Dir       string            // 文件所在目录，用于查找环境文件和 < 引用的文件
+	Variables map[string]string // @var = value 声明的文件变量（未替换）
+	Requests  []HTTPFileRequest // 文件中的请求
+
+	envValues []string // 构造请求时读取的进程环境变量值，输出时脱敏
+}
+
+// HTTPFileRequest 表示 .http 文件中的一个请求，各字段中可能包含 {{var}} 模板
+type HTTPFileRequest struct {
+	Index    int
+	Name     string
+	Method   string
+	URL      string
+	Headers  [][2]string
+	Body     string
+	BodyFile string // 请求体来自 "< path" 引用的文件
+}
+
+var httpFileListTool = mcp.NewTool("http_file_list",
+	mcp.WithDescription("列出 VS Code REST Client / IntelliJ HTTP Client 格式的 .http 文件中的所有请求（以 ### 分隔）。"),
+	mcp.WithString("path",
+		mcp.Required(),
+		mcp.Description(".http 文件的绝对路径"),
+	),
+	mcp.WithString("env",
+		mcp.Description("环境名称，对应 http-client.env.json 中的键，用于展示替换变量后的 URL"),
+	),
+	mcp.WithString("envFile",
+		mcp.Description("环境文件路径，默认使用 .http 文件同目录下的 http-client.env.json 和 http-client.private.env.json"),
+	),
+)
+
+var httpFileRunTool = mcp.NewTool("http_file_run",
+	mcp.WithDescription(`执行 .http 文件中的一个或全部请求，并报告每个请求的状态码。
+- 支持 @var = value 变量声明和 {{var}} 变量替换;
+- 支持 http-client.env.json 环境文件（含 $shared 公共变量）;
+- 支持 {{$timestamp}}、{{$isoTimestamp}}、{{$uuid}}、{{$randomInt min max}}、{{$processEnv NAME}} 系统变量，其中环境变量只能读取 http.yaml 中 http_file.env_allowlist 列出的变量，输出中会脱敏。`),
+	mcp.WithString("path",
+		mcp.Required(),
+		mcp.Description(".http 文件的绝对路径"),
+	),
+	mcp.WithString("request",
+		mcp.Description("要执行的请求名称或序号（从 1 开始），为空时按顺序执行全部请求"),
+	),
+	mcp.WithString("env",
+		mcp.Description("环境名称，对应 http-client.env.json 中的键"),
+	),
+	mcp.WithString("envFile",
+		mcp.Description("环境文件路径，默认使用 .http 文件同目录下的 http-client.env.json 和 http-client.private.env.json"),
+	),
+	profileToolOption,
+	sessionToolOption,
+)
+
+func httpFileListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	file, vars, err := loadHTTPFileWithEnv(cast.ToString(args["path"]), cast.ToString(args["env"]), cast.ToString(args["envFile"]))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("加载 .http 文件失败", err), nil
+	}
+	if len(file.Requests) == 0 {
+		return mcp.NewToolResultText("文件中没有请求"), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共 %d 个请求:\n", len(file.Requests))
+	for _, r := range file.Requests {
+		target := r.URL
+		if resolved, err := file.resolve(r.URL, vars); err == nil {
+			target = resolved
+		}
+		fmt.Fprintf(&sb, "[%d] %s: %s %s\n", r.Index, r.Name, r.Method, target)
+	}
+	return mcp.NewToolResultText(file.redact(strings.TrimRight(sb.String(), "\n"))), nil
+}
+
+func httpFileRunHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	file, vars, err := loadHTTPFileWithEnv(cast.ToString(args["path"]), cast.ToString(args["env"]), cast.ToString(args["envFile"]))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("加载 .http 文件失败", err), nil
+	}
+
+	requests := file.Requests
+	if selector := cast.ToString(args["request"]); selector != "" {
+		r, err := file.Find(selector)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		requests = []HTTPFileRequest{*r}
+	}
+	if len(requests) == 0 {
+		return mcp.NewToolResultText("文件中没有请求"), nil
+	}
+
+	var sb strings.Builder
+	for _, r := range requests {
+		fmt.Fprintf(&sb, "[%d] %s: ", r.Index, r.Name)
+		req, err := file.Build(r, vars)
+		if err != nil {
+			fmt.Fprintf(&sb, "构造请求失败: %v\n\n", err)
+			continue
+		}
+		req.Profile = cast.ToString(args["profile"])
+		req.Session = cast.ToString(args["session"])
+		resp, err := sendRequest(ctx, req)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s %s\n执行请求失败: %v\n\n", req.Method, req.URL, err)
+			continue
+		}
+
+		body := string(resp.Body)
+		if len(requests) > 1 {
+			if short, truncated := truncateUTF8(resp.Body, 500); truncated {
+				body = short + "...(truncated)"
+			}
+		}
+		fmt.Fprintf(&sb, "%s %s\nStatus: %d\nBody: %s\n\n", req.Method, req.URL, resp.StatusCode, body)
+	}
+	return mcp.NewToolResultText(file.redact(strings.TrimRight(sb.String(), "\n"))), nil
+}
+
+// loadHTTPFileWithEnv 读取 .http 文件和环境变量，返回文件及合并后的变量
+func loadHTTPFileWithEnv(path, env, envFile string) (*HTTPFile, map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	file := ParseHTTPFile(string(content))
+	file.Dir = filepath.Dir(path)
+
+	envVars, err := loadHTTPEnv(file.Dir, env, envFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 文件变量优先级高于环境变量
+	vars := make(map[string]string, len(envVars)+len(file.Variables))
+	for k, v := range envVars {
+		vars[k] = v
+	}
+	for k, v := range file.Variables {
+		vars[k] = v
+	}
+	return file, vars, nil
+}
+
+// ParseHTTPFile 解析 .http 文件内容
+func ParseHTTPFile(content string) *HTTPFile {
+	file := &HTTPFile{Variables: make(map[string]string)}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var (
+		blockName string
+		block     []string
+	)
+	flush := func() {
+		if r, ok := parseHTTPFileBlock(blockName, block, file.Variables); ok {
+			r.Index = len(file.Requests) + 1
+			if r.Name == "" {
+				r.Name = fmt.Sprintf("#%d", r.Index)
+			}
+			file.Requests = append(file.Requests, r)
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "###") {
+			flush()
+			blockName = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			block = nil
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+	return file
+}
+
+// parseHTTPFileBlock 解析 ### 之间的一个区块，区块中的变量声明写入 vars
+func parseHTTPFileBlock(name string, lines []string, vars map[string]string) (HTTPFileRequest, bool) {
+	r := HTTPFileRequest{Name: name}
+
+	i := 0
+	// 请求行之前：空行、注释、@name 和变量声明
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if m := httpFileNamePattern.FindStringSubmatch(line); m != nil {
+			r.Name = m[1]
+			continue
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if m := httpFileVarPattern.FindStringSubmatch(line); m != nil {
+			vars[m[1]] = strings.TrimSpace(m[2])
+			continue
+		}
+		break
+	}
+	if i >= len(lines) {
+		return r, false
+	}
+
+	// 请求行，METHOD 省略时默认为 GET，末尾的 HTTP 版本忽略
+	fields := strings.Fields(strings.TrimSpace(lines[i]))
+	if httpFileMethods[strings.ToUpper(fields[0])] && len(fields) > 1 {
+		r.Method = strings.ToUpper(fields[0])
+		fields = fields[1:]
+	} else {
+		r.Method = http.MethodGet
+	}
+	if len(fields) > 1 && strings.HasPrefix(strings.ToUpper(fields[len(fields)-1]), "HTTP/") {
+		fields = fields[:len(fields)-1]
+	}
+	r.URL = strings.Join(fields, " ")
+	i++
+
+	// 以 ? 或 & 开头的行是多行查询参数
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		r.URL += line
+	}
+
+	// 请求头，直到空行
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			r.Headers = append(r.Headers, [2]string{strings.TrimSpace(k), strings.TrimSpace(v)})
+		}
+	}
+
+	// 请求体
+	if i < len(lines) {
+		body := strings.Trim(strings.Join(lines[i:], "\n"), "\n")
+		if path, ok := strings.CutPrefix(body, "< "); ok && !strings.Contains(path, "\n") {
+			r.BodyFile = strings.TrimSpace(path)
+		} else {
+			r.Body = body
+		}
+	}
+	return r, true
+}
+
+// Find 按名称或从 1 开始的序号查找请求
+func (f *HTTPFile) Find(selector string) (*HTTPFileRequest, error) {
+	for i := range f.Requests {
+		if f.Requests[i].Name == selector {
+			return &f.Requests[i], nil
+		}
+	}
+	if idx, err := strconv.Atoi(selector); err == nil && idx >= 1 && idx <= len(f.Requests) {
+		return &f.Requests[idx-1], nil
+	}
+	return nil, fmt.Errorf("请求 %s 不存在", selector)
+}
+
+// Build 替换变量并构造可执行的请求
+func (f *HTTPFile) Build(r HTTPFileRequest, vars map[string]string) (*Request, error) {
+	req := &Request{Method: r.Method, Header: make(http.Header)}
+
+	var err error
+	if req.URL, err = f.resolve(r.URL, vars); err != nil {
+		return nil, err
+	}
+	for _, h := range r.Headers {
+		value, err := f.resolve(h[1], vars)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add(h[0], value)
+	}
+
+	body := r.Body
+	if r.BodyFile != "" {
+		path := r.BodyFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(f.Dir, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		body = string(content)
+	}
+	if req.Body, err = f.resolve(body, vars); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// resolve 替换模板变量，并记录读取的进程环境变量值
+func (f *HTTPFile) resolve(text string, vars map[string]string) (string, error) {
+	return resolveTemplateDepth(text, vars, 0, &f.envValues)
+}
+
+// redact 把输出中读取过的进程环境变量值替换为掩码
+func (f *HTTPFile) redact(s string) string {
+	for _, v := range f.envValues {
+		if v != "" {
+			s = strings.ReplaceAll(s, v, helper.RedactMask)
+		}
+	}
+	return s
+}
+
+// loadHTTPEnv 读取环境文件中指定环境的变量，$shared 中的变量对所有环境生效
+func loadHTTPEnv(dir, env, envFile string) (map[string]string, error) {
+	vars := make(map[string]string)
+	if env == "" {
+		return vars, nil
+	}
+
+	files := []string{envFile}
+	if envFile == "" {
+		files = []string{
+			filepath.Join(dir, "http-client.env.json"),
+			filepath.Join(dir, "http-client.private.env.json"),
+		}
+	}
+
+	found := false
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) && envFile == "" {
+				continue
+			}
+			return nil, err
+		}
+		var envs map[string]map[string]any
+		if err := json.Unmarshal(content, &envs); err != nil {
+			return nil, fmt.Errorf("解析环境文件 %s 失败: %w", path, err)
+		}
+		for _, name := range []string{"$shared", env} {
+			values, ok := envs[name]
+			if !ok {
+				continue
+			}
+			found = found || name == env
+			for k, v := range values {
+				vars[k] = cast.ToString(v)
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("环境 %s 不存在", env)
+	}
+	return vars, nil
+}
+
+// resolveTemplate 替换文本中的 {{var}}，变量值中可以继续引用其他变量
+func resolveTemplate(text string, vars map[string]string) (string, error) {
+	return resolveTemplateDepth(text, vars, 0, nil)
+}
+
+// resolveTemplateDepth 替换模板变量，envValues 不为 nil 时追加读取的进程环境变量值
+func resolveTemplateDepth(text string, vars map[string]string, depth int, envValues *[]string) (string, error) {
+	if depth > 10 {
+		return "", errors.New("变量引用层级过深，可能存在循环引用")
+	}
+
+	var missing []string
+	var firstErr error
+	result := templatePattern.ReplaceAllStringFunc(text, func(m string) string {
+		name := templatePattern.FindStringSubmatch(m)[1]
+		if strings.HasPrefix(name, "$") {
+			v, fromEnv, err := systemVariable(name)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if fromEnv && envValues != nil {
+				*envValues = append(*envValues, v)
+			}
+			return v
+		}
+		v, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			return m
+		}
+		resolved, err := resolveTemplateDepth(v, vars, depth+1, envValues)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return resolved
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("未定义的变量: %s", strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+// systemVariable 计算 {{$xxx}} 系统变量，第二个返回值表示值是否来自进程环境变量
+func systemVariable(expr string) (string, bool, error) {
+	fields := strings.Fields(expr)
+	switch fields[0] {
+	case "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), false, nil
+	case "$isoTimestamp":
+		return time.Now().UTC().Format(time.RFC3339), false, nil
+	case "$uuid", "$random.uuid":
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", false, err
+		}
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		h := hex.EncodeToString(b)
+		return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], false, nil
+	case "$randomInt":
+		lo, hi := int64(0), int64(1000)
+		if len(fields) == 3 {
+			lo, hi = cast.ToInt64(fields[1]), cast.ToInt64(fields[2])
+		}
+		if hi <= lo {
+			return "", false, fmt.Errorf("invalid %s", expr)
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(hi-lo))
+		if err != nil {
+			return "", false, err
+		}
+		return strconv.FormatInt(lo+n.Int64(), 10), false, nil
+	case "$processEnv":
+		if len(fields) != 2 {
+			return "", false, fmt.Errorf("invalid %s", expr)
+		}
+		return processEnv(fields[1])
+	}
+	if name, ok := strings.CutPrefix(fields[0], "$env."); ok {
+		return processEnv(name)
+	}
+	return "", false, fmt.Errorf("不支持的系统变量: %s", expr)
+}
+
+// getEnvAllowlist 返回允许在 .http 文件中读取的进程环境变量，对应 http.yaml 的 http_file.env_allowlist，测试中可替换
+var getEnvAllowlist = sync.OnceValue(func() []string {
+	return helper.HttpConfig().GetStringSlice("http_file.env_allowlist")
+})
+
+// processEnv 读取允许列表中的进程环境变量，避免 .http 文件读取服务进程的任意密钥
+func processEnv(name string) (string, bool, error) {
+	for _, pattern := range getEnvAllowlist() {
+		if ok, _ := path.Match(pattern, name); ok {
+			return os.Getenv(name), true, nil
+		}
+	}
+	return "", false, fmt.Errorf("环境变量 %s 不在 http.yaml 的 http_file.env_allowlist 中", name)
+}
@@ -0,0 +1,179 @@
+package httprequest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleHTTPFile = `@host = {{baseUrl}}/api
+@token = abc
+
+### 登录
+POST {{host}}/login HTTP/1.1
+Content-Type: application/json
+
+{
+  "user": "{{user}}"
+}
+
+###
+# @name listItems
+GET {{host}}/items
+    ?page=1
+    &size=10
+Authorization: Bearer {{token}}
+
+### 上传
+PUT {{host}}/upload
+Content-Type: text/plain
+
+< ./payload.txt
+`
+
+func TestParseHTTPFile(t *testing.T) {
+	file := ParseHTTPFile(sampleHTTPFile)
+
+	assert.Equal(t, map[string]string{"host": "{{baseUrl}}/api", "token": "abc"}, file.Variables)
+	require.Len(t, file.Requests, 3)
+
+	assert.Equal(t, "登录", file.Requests[0].Name)
+	assert.Equal(t, "POST", file.Requests[0].Method)
+	assert.Equal(t, "{{host}}/login", file.Requests[0].URL)
+	assert.Equal(t, [][2]string{{"Content-Type", "application/json"}}, file.Requests[0].Headers)
+	assert.Equal(t, "{\n  \"user\": \"{{user}}\"\n}", file.Requests[0].Body)
+
+	assert.Equal(t, "listItems", file.Requests[1].Name)
+	assert.Equal(t, "GET", file.Requests[1].Method)
+	assert.Equal(t, "{{host}}/items?page=1&size=10", file.Requests[1].URL)
+	assert.Empty(t, file.Requests[1].Body)
+
+	assert.Equal(t, "./payload.txt", file.Requests[2].BodyFile)
+
+	r, err := file.Find("2")
+	require.NoError(t, err)
+	assert.Equal(t, "listItems", r.Name)
+	_, err = file.Find("missing")
+	assert.Error(t, err)
+}
+
+func TestResolveTemplate(t *testing.T) {
+	vars := map[string]string{"host": "{{baseUrl}}/api", "baseUrl": "http://localhost"}
+
+	got, err := resolveTemplate("{{ host }}/items", vars)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/api/items", got)
+
+	_, err = resolveTemplate("{{missing}}", vars)
+	assert.ErrorContains(t, err, "missing")
+
+	_, err = resolveTemplate("{{a}}", map[string]string{"a": "{{b}}", "b": "{{a}}"})
+	assert.Error(t, err)
+
+	got, err = resolveTemplate("{{$uuid}}", nil)
+	require.NoError(t, err)
+	assert.Len(t, got, 36)
+
+	t.Setenv("HTTP_FILE_TEST", "from-env")
+	_, err = resolveTemplate("{{$processEnv HTTP_FILE_TEST}}", nil)
+	assert.ErrorContains(t, err, "环境变量 HTTP_FILE_TEST 不在 http.yaml 的 http_file.env_allowlist 中")
+
+	setEnvAllowlist(t, "HTTP_FILE_*")
+	got, err = resolveTemplate("{{$processEnv HTTP_FILE_TEST}}/{{$env.HTTP_FILE_TEST}}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "from-env/from-env", got)
+
+	_, err = resolveTemplate("{{$env.HOME}}", nil)
+	assert.ErrorContains(t, err, "环境变量 HOME 不在")
+}
+
+// setEnvAllowlist 在测试期间替换允许读取的环境变量
+func setEnvAllowlist(t *testing.T, patterns ...string) {
+	t.Helper()
+	orig := getEnvAllowlist
+	getEnvAllowlist = func() []string { return patterns }
+	t.Cleanup(func() { getEnvAllowlist = orig })
+}
+
+func TestHttpFileRunHandler(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.http")
+	require.NoError(t, os.WriteFile(path, []byte(sampleHTTPFile), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "payload.txt"), []byte("hello {{user}}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "http-client.env.json"), []byte(`{
+		"$shared": {"user": "alice"},
+		"dev": {"baseUrl": "`+srv.URL+`"}
+	}`), 0644))
+
+	result, err := httpFileRunHandler(context.Background(), newCallToolRequest("http_file_run", map[string]any{
+		"path": path, "env": "dev",
+	}))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.Contains(t, text, "[1] 登录: POST "+srv.URL+"/api/login\nStatus: 200")
+	assert.Contains(t, text, `"user": "alice"`)
+	assert.Contains(t, text, "Authorization: Bearer abc")
+	assert.Contains(t, text, "GET /api/items?page=1&size=10")
+	assert.Contains(t, text, "PUT /api/upload\nhello alice")
+
+	result, err = httpFileListHandler(context.Background(), newCallToolRequest("http_file_list", map[string]any{
+		"path": path, "env": "dev",
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, resultText(t, result), "[2] listItems: GET "+srv.URL+"/api/items?page=1&size=10")
+
+	result, err = httpFileRunHandler(context.Background(), newCallToolRequest("http_file_run", map[string]any{
+		"path": path, "env": "prod",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestHttpFileRunHandler_TruncateUTF8(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a" + strings.Repeat("中文", 300)))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cn.http")
+	require.NoError(t, os.WriteFile(path, []byte("GET "+srv.URL+"/a\n\n###\n\nGET "+srv.URL+"/b\n"), 0644))
+
+	result, err := httpFileRunHandler(context.Background(), newCallToolRequest("http_file_run", map[string]any{"path": path}))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.Contains(t, text, "...(truncated)")
+	assert.True(t, utf8.ValidString(text), "截断不能切开多字节字符")
+}
+
+func TestHttpFileRunHandler_ProcessEnv(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+	t.Setenv("HTTP_FILE_TOKEN", "env-secret-123")
+	setEnvAllowlist(t, "HTTP_FILE_TOKEN")
+
+	path := filepath.Join(t.TempDir(), "env.http")
+	require.NoError(t, os.WriteFile(path, []byte("GET "+srv.URL+"/items?token={{$env.HTTP_FILE_TOKEN}}\n"+
+		"X-Token: {{$processEnv HTTP_FILE_TOKEN}}\n"), 0644))
+
+	result, err := httpFileRunHandler(context.Background(), newCallToolRequest("http_file_run", map[string]any{"path": path}))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.NotContains(t, text, "env-secret-123")
+	assert.Contains(t, text, "GET "+srv.URL+"/items?token=***\nStatus: 200")
+	assert.Contains(t, text, "X-Token: ***")
+
+	result, err = httpFileListHandler(context.Background(), newCallToolRequest("http_file_list", map[string]any{"path": path}))
+	require.NoError(t, err)
+	assert.Equal(t, "共 1 个请求:\n[1] #1: GET "+srv.URL+"/items?token=***", resultText(t, result))
+}
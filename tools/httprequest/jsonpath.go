@@ -0,0 +1,163 @@
+package httprequest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathToken 表示 JSONPath 表达式中的一段
+type pathToken struct {
+	kind  string // key | index | wildcard | recursive
+	key   string
+	index int
+}
+
+// EvalJSONPath 在 JSON 数据上执行 JSONPath/jq 风格的表达式
+//
+// 支持 $.a.b、.a.b、a.b、['a']、[0]、[-1]、[*]、[]、.* 以及 ..key 递归查找。
+// 表达式中含通配或递归时返回 []any，否则返回单个值。
+func EvalJSONPath(data any, expr string) (any, error) {
+	tokens, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	multi := false
+	current := []any{data}
+	for _, tok := range tokens {
+		next := make([]any, 0, len(current))
+		for _, node := range current {
+			switch tok.kind {
+			case "key":
+				if m, ok := node.(map[string]any); ok {
+					if v, ok := m[tok.key]; ok {
+						next = append(next, v)
+					}
+				}
+			case "index":
+				if list, ok := node.([]any); ok {
+					idx := tok.index
+					if idx < 0 {
+						idx += len(list)
+					}
+					if idx >= 0 && idx < len(list) {
+						next = append(next, list[idx])
+					}
+				}
+			case "wildcard":
+				multi = true
+				switch v := node.(type) {
+				case []any:
+					next = append(next, v...)
+				case map[string]any:
+					for _, k := range sortedKeys(v) {
+						next = append(next, v[k])
+					}
+				}
+			case "recursive":
+				multi = true
+				collectRecursive(node, tok.key, &next)
+			}
+		}
+		current = next
+	}
+
+	if multi {
+		return current, nil
+	}
+	if len(current) == 0 {
+		return nil, fmt.Errorf("path %s not found", expr)
+	}
+	return current[0], nil
+}
+
+// parseJSONPath 将表达式切分为 token
+func parseJSONPath(expr string) ([]pathToken, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+
+	var tokens []pathToken
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], ".."):
+			i += 2
+			key, n := readPathKey(s[i:])
+			if key == "" {
+				return nil, fmt.Errorf("invalid path %q: missing key after ..", expr)
+			}
+			tokens = append(tokens, pathToken{kind: "recursive", key: key})
+			i += n
+		case s[i] == '.':
+			i++
+			if i < len(s) && s[i] == '*' {
+				tokens = append(tokens, pathToken{kind: "wildcard"})
+				i++
+				continue
+			}
+			key, n := readPathKey(s[i:])
+			if key == "" {
+				if i == len(s) || s[i] == '[' {
+					continue
+				}
+				return nil, fmt.Errorf("invalid path %q at %d", expr, i)
+			}
+			tokens = append(tokens, pathToken{kind: "key", key: key})
+			i += n
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", expr)
+			}
+			inner := strings.TrimSpace(s[i+1 : i+end])
+			i += end + 1
+			switch {
+			case inner == "" || inner == "*":
+				tokens = append(tokens, pathToken{kind: "wildcard"})
+			case (strings.HasPrefix(inner, "'") && strings.HasSuffix(inner, "'")) ||
+				(strings.HasPrefix(inner, `"`) && strings.HasSuffix(inner, `"`)):
+				tokens = append(tokens, pathToken{kind: "key", key: inner[1 : len(inner)-1]})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: bad index %q", expr, inner)
+				}
+				tokens = append(tokens, pathToken{kind: "index", index: idx})
+			}
+		default:
+			// 允许省略开头的点，如 data.items
+			key, n := readPathKey(s[i:])
+			if key == "" {
+				return nil, fmt.Errorf("invalid path %q at %d", expr, i)
+			}
+			tokens = append(tokens, pathToken{kind: "key", key: key})
+			i += n
+		}
+	}
+	return tokens, nil
+}
+
+// readPathKey 读取到下一个 . 或 [ 之前的字段名
+func readPathKey(s string) (string, int) {
+	n := strings.IndexAny(s, ".[")
+	if n < 0 {
+		n = len(s)
+	}
+	return s[:n], n
+}
+
+func collectRecursive(node any, key string, out *[]any) {
+	switch v := node.(type) {
+	case map[string]any:
+		if val, ok := v[key]; ok {
+			*out = append(*out, val)
+		}
+		for _, k := range sortedKeys(v) {
+			collectRecursive(v[k], key, out)
+		}
+	case []any:
+		for _, item := range v {
+			collectRecursive(item, key, out)
+		}
+	}
+}
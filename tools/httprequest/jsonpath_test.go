@@ -0,0 +1,48 @@
+package httprequest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalJSONPath(t *testing.T) {
+	var data any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"data": {
+			"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b", "tags": {"id": 9}}],
+			"total": 2,
+			"dotted.key": true
+		}
+	}`), &data))
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"$.data.total", float64(2)},
+		{".data.total", float64(2)},
+		{"data.total", float64(2)},
+		{"$.data.items[0].name", "a"},
+		{"$.data.items[-1].id", float64(2)},
+		{"$['data']['dotted.key']", true},
+		{".data.items[].id", []any{float64(1), float64(2)}},
+		{"$.data.items[*].name", []any{"a", "b"}},
+		{"$..id", []any{float64(1), float64(2), float64(9)}},
+		{"$", data},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalJSONPath(data, tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := EvalJSONPath(data, "$.data.missing")
+	assert.Error(t, err)
+	_, err = EvalJSONPath(data, "$.data.items[x]")
+	assert.Error(t, err)
+}
@@ -0,0 +1,341 @@
+package httprequest
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+)
+
+// maxSchemaDepth $ref 嵌套的最大深度，防止循环引用
+const maxSchemaDepth = 64
+
+// ValidateJSONSchema 按 JSON Schema 校验数据，返回全部不满足的约束，为空表示校验通过
+//
+// 支持 draft-07 / 2020-12 的常用关键字: type、enum、const、properties、required、
+// additionalProperties、patternProperties、items、minItems、maxItems、uniqueItems、minLength、
+// maxLength、pattern、minimum、maximum、exclusiveMinimum、exclusiveMaximum、multipleOf、
+// minProperties、maxProperties、allOf、anyOf、oneOf、not，以及文档内的 $ref（如 #/definitions/User、#/$defs/User）。
+// format 等注解性关键字会被忽略。
+func ValidateJSONSchema(schema, data any) []string {
+	v := &schemaValidator{root: schema}
+	v.validate("$", schema, data, 0)
+	return v.errs
+}
+
+type schemaValidator struct {
+	root any
+	errs []string
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// matches 判断数据是否满足子 schema，不记录错误
+func (v *schemaValidator) matches(path string, schema, data any, depth int) bool {
+	sub := &schemaValidator{root: v.root}
+	sub.validate(path, schema, data, depth)
+	return len(sub.errs) == 0
+}
+
+func (v *schemaValidator) validate(path string, schema, data any, depth int) {
+	if b, ok := schema.(bool); ok {
+		if !b {
+			v.fail(path, "schema 为 false，不允许任何值")
+		}
+		return
+	}
+	s, ok := schema.(map[string]any)
+	if !ok {
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxSchemaDepth {
+			v.fail(path, "$ref 嵌套过深: %s", ref)
+			return
+		}
+		target, err := v.resolveRef(ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		v.validate(path, target, data, depth+1)
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, data) {
+		v.fail(path, "类型应为 %s，实际为 %s", typeNames(t), jsonTypeOf(data))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, data) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "值 %s 不在 enum %s 中", diffValue(data), diffValue(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !jsonEqual(c, data) {
+		v.fail(path, "值应为 %s，实际为 %s", diffValue(c), diffValue(data))
+	}
+
+	switch d := data.(type) {
+	case map[string]any:
+		v.validateObject(path, s, d, depth)
+	case []any:
+		v.validateArray(path, s, d, depth)
+	case string:
+		n := utf8.RuneCountInString(d)
+		if min, ok := s["minLength"]; ok && n < cast.ToInt(min) {
+			v.fail(path, "长度 %d 小于 minLength %d", n, cast.ToInt(min))
+		}
+		if max, ok := s["maxLength"]; ok && n > cast.ToInt(max) {
+			v.fail(path, "长度 %d 大于 maxLength %d", n, cast.ToInt(max))
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				v.fail(path, "pattern %q 无效: %v", pattern, err)
+			} else if !re.MatchString(d) {
+				v.fail(path, "值 %q 不匹配 pattern %q", d, pattern)
+			}
+		}
+	case float64:
+		v.validateNumber(path, s, d)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			v.validate(path, sub, data, depth+1)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.matches(path, sub, data, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "不满足 anyOf 中的任何一个 schema")
+		}
+	}
+	if one, ok := s["oneOf"].([]any); ok {
+		n := 0
+		for _, sub := range one {
+			if v.matches(path, sub, data, depth+1) {
+				n++
+			}
+		}
+		if n != 1 {
+			v.fail(path, "应恰好满足 oneOf 中的一个 schema，实际满足 %d 个", n)
+		}
+	}
+	if not, ok := s["not"]; ok && v.matches(path, not, data, depth+1) {
+		v.fail(path, "不应满足 not 中的 schema")
+	}
+}
+
+func (v *schemaValidator) validateObject(path string, s map[string]any, d map[string]any, depth int) {
+	if required, ok := s["required"].([]any); ok {
+		for _, r := range required {
+			if _, ok := d[cast.ToString(r)]; !ok {
+				v.fail(path, "缺少必需字段 %s", cast.ToString(r))
+			}
+		}
+	}
+	if min, ok := s["minProperties"]; ok && len(d) < cast.ToInt(min) {
+		v.fail(path, "字段数 %d 小于 minProperties %d", len(d), cast.ToInt(min))
+	}
+	if max, ok := s["maxProperties"]; ok && len(d) > cast.ToInt(max) {
+		v.fail(path, "字段数 %d 大于 maxProperties %d", len(d), cast.ToInt(max))
+	}
+
+	props, _ := s["properties"].(map[string]any)
+	patternProps, _ := s["patternProperties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+	for _, k := range sortedKeys(d) {
+		child := childPath(path, k)
+		known := false
+		if sub, ok := props[k]; ok {
+			known = true
+			v.validate(child, sub, d[k], depth+1)
+		}
+		for pattern, sub := range patternProps {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(k) {
+				known = true
+				v.validate(child, sub, d[k], depth+1)
+			}
+		}
+		if known || !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			v.fail(path, "不允许额外字段 %s", k)
+			continue
+		}
+		v.validate(child, additional, d[k], depth+1)
+	}
+}
+
+func (v *schemaValidator) validateArray(path string, s map[string]any, d []any, depth int) {
+	if min, ok := s["minItems"]; ok && len(d) < cast.ToInt(min) {
+		v.fail(path, "元素数 %d 小于 minItems %d", len(d), cast.ToInt(min))
+	}
+	if max, ok := s["maxItems"]; ok && len(d) > cast.ToInt(max) {
+		v.fail(path, "元素数 %d 大于 maxItems %d", len(d), cast.ToInt(max))
+	}
+	if cast.ToBool(s["uniqueItems"]) {
+		for i := range d {
+			for j := i + 1; j < len(d); j++ {
+				if jsonEqual(d[i], d[j]) {
+					v.fail(path, "元素 [%d] 与 [%d] 重复", i, j)
+				}
+			}
+		}
+	}
+	if items, ok := s["items"]; ok {
+		for i, item := range d {
+			v.validate(fmt.Sprintf("%s[%d]", path, i), items, item, depth+1)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(path string, s map[string]any, d float64) {
+	if min, ok := s["minimum"]; ok && d < cast.ToFloat64(min) {
+		v.fail(path, "值 %v 小于 minimum %v", d, min)
+	}
+	if max, ok := s["maximum"]; ok && d > cast.ToFloat64(max) {
+		v.fail(path, "值 %v 大于 maximum %v", d, max)
+	}
+	// draft-04 中 exclusiveMinimum/exclusiveMaximum 为布尔值，修饰 minimum/maximum
+	switch ex := s["exclusiveMinimum"].(type) {
+	case bool:
+		if min, ok := s["minimum"]; ex && ok && d == cast.ToFloat64(min) {
+			v.fail(path, "值 %v 应大于 %v", d, min)
+		}
+	case float64:
+		if d <= ex {
+			v.fail(path, "值 %v 应大于 %v", d, ex)
+		}
+	}
+	switch ex := s["exclusiveMaximum"].(type) {
+	case bool:
+		if max, ok := s["maximum"]; ex && ok && d == cast.ToFloat64(max) {
+			v.fail(path, "值 %v 应小于 %v", d, max)
+		}
+	case float64:
+		if d >= ex {
+			v.fail(path, "值 %v 应小于 %v", d, ex)
+		}
+	}
+	if m, ok := s["multipleOf"]; ok {
+		if mf := cast.ToFloat64(m); mf > 0 {
+			if q := d / mf; math.Abs(q-math.Round(q)) > 1e-9 {
+				v.fail(path, "值 %v 不是 %v 的倍数", d, m)
+			}
+		}
+	}
+}
+
+// resolveRef 解析文档内的 JSON Pointer 引用
+func (v *schemaValidator) resolveRef(ref string) (any, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("只支持文档内的 $ref，不支持 %s", ref)
+	}
+	node := v.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("$ref %s 不存在", ref)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("$ref %s 不存在", ref)
+		}
+	}
+	return node, nil
+}
+
+// matchesType 判断数据是否为 schema 的 type 之一
+func matchesType(t any, data any) bool {
+	names := cast.ToStringSlice(t)
+	if s, ok := t.(string); ok {
+		names = []string{s}
+	}
+	actual := jsonTypeOf(data)
+	for _, name := range names {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeNames(t any) string {
+	if s, ok := t.(string); ok {
+		return s
+	}
+	return strings.Join(cast.ToStringSlice(t), "|")
+}
+
+// jsonTypeOf 返回 JSON 值的类型名称，整数值返回 integer
+func jsonTypeOf(data any) string {
+	switch d := data.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if d == math.Trunc(d) && !math.IsInf(d, 0) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", data)
+}
+
+// jsonEqual 判断两个 JSON 值是否相等，数字按数值比较
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+// normalizeJSON 把整数等数字类型统一为 float64，便于与 encoding/json 解析出的值比较
+func normalizeJSON(v any) any {
+	switch d := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(d))
+		for k, val := range d {
+			out[k] = normalizeJSON(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(d))
+		for i, val := range d {
+			out[i] = normalizeJSON(val)
+		}
+		return out
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return cast.ToFloat64(d)
+	}
+	return v
+}
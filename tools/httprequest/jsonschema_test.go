@@ -0,0 +1,71 @@
+package httprequest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	var schema any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["id", "user"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"status": {"enum": ["active", "disabled"]},
+			"score": {"type": "number", "exclusiveMaximum": 100, "multipleOf": 0.5},
+			"tags": {"type": "array", "items": {"type": "string", "minLength": 1}, "uniqueItems": true, "maxItems": 3},
+			"user": {"$ref": "#/$defs/user"},
+			"contact": {"oneOf": [{"type": "string", "pattern": "^\\+\\d+$"}, {"type": "null"}]}
+		},
+		"$defs": {
+			"user": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}
+		}
+	}`), &schema))
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "通过",
+			data: `{"id": 1, "status": "active", "score": 99.5, "tags": ["a"], "user": {"name": "bob"}, "contact": null}`,
+		},
+		{
+			name: "类型和必需字段",
+			data: `{"id": "1", "user": {}}`,
+			want: []string{
+				`$.id: 类型应为 integer，实际为 string`,
+				`$.user: 缺少必需字段 name`,
+			},
+		},
+		{
+			name: "取值范围",
+			data: `{"id": 0, "status": "deleted", "score": 100, "tags": ["a", "a", "", "b"], "user": {"name": "bob"}, "contact": "123", "extra": 1}`,
+			want: []string{
+				`$.contact: 应恰好满足 oneOf 中的一个 schema，实际满足 0 个`,
+				`$: 不允许额外字段 extra`,
+				`$.id: 值 0 小于 minimum 1`,
+				`$.score: 值 100 应小于 100`,
+				`$.status: 值 "deleted" 不在 enum ["active","disabled"] 中`,
+				`$.tags: 元素数 4 大于 maxItems 3`,
+				`$.tags: 元素 [0] 与 [1] 重复`,
+				`$.tags[2]: 长度 0 小于 minLength 1`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data any
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &data))
+			assert.Equal(t, tt.want, ValidateJSONSchema(schema, data))
+		})
+	}
+
+	assert.Equal(t, []string{"$: $ref #/definitions/missing 不存在"}, ValidateJSONSchema(map[string]any{"$ref": "#/definitions/missing"}, 1))
+}
@@ -0,0 +1,348 @@
+package httprequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// LoadConfig 压测的安全上限，对应 http.yaml 的 load 区块，工具参数超出时按上限执行
+type LoadConfig struct {
+	MaxRequests        int     `mapstructure:"max_requests" json:"max_requests" yaml:"max_requests"`                         // 单次压测的最大请求数
+	MaxConcurrency     int     `mapstructure:"max_concurrency" json:"max_concurrency" yaml:"max_concurrency"`                // 最大并发数
+	MaxRate            float64 `mapstructure:"max_rate" json:"max_rate" yaml:"max_rate"`                                     // 每秒最大请求数，0 表示不限制
+	MaxDurationSeconds int     `mapstructure:"max_duration_seconds" json:"max_duration_seconds" yaml:"max_duration_seconds"` // 单次压测的最长时间（秒）
+}
+
+// DefaultLoadConfig 返回默认的压测上限
+func DefaultLoadConfig() LoadConfig {
+	return LoadConfig{
+		MaxRequests:        1000,
+		MaxConcurrency:     20,
+		MaxRate:            100,
+		MaxDurationSeconds: 60,
+	}
+}
+
+// getLoadConfig 返回压测上限配置，测试中可替换
+var getLoadConfig = sync.OnceValue(func() LoadConfig {
+	cfg := DefaultLoadConfig()
+	if err := helper.HttpConfig().UnmarshalKey("load", &cfg); err != nil {
+		log.Printf("解析压测配置失败，使用默认配置: %v", err)
+		cfg = DefaultLoadConfig()
+	}
+	defaults := DefaultLoadConfig()
+	if cfg.MaxRequests <= 0 {
+		cfg.MaxRequests = defaults.MaxRequests
+	}
+	if cfg.MaxConcurrency <= 0 {
+		cfg.MaxConcurrency = defaults.MaxConcurrency
+	}
+	if cfg.MaxDurationSeconds <= 0 {
+		cfg.MaxDurationSeconds = defaults.MaxDurationSeconds
+	}
+	return cfg
+})
+
+// maxLoadErrorSamples 报告中保留的不同错误数量
+const maxLoadErrorSamples = 5
+
+// LoadOptions 压测参数，Requests 和 Duration 任一达到即停止发送
+type LoadOptions struct {
+	Requests    int
+	Concurrency int
+	Rate        float64 // 每秒请求数，0 表示不限制
+	Duration    time.Duration
+	Notes       []string // 参数被安全上限调整的说明
+}
+
+// loadOptionsFromArgs 从工具参数读取压测参数并应用安全上限
+func loadOptionsFromArgs(args map[string]any, cfg LoadConfig) LoadOptions {
+	opts := LoadOptions{
+		Requests:    cast.ToInt(args["requests"]),
+		Concurrency: cast.ToInt(args["concurrency"]),
+		Rate:        cast.ToFloat64(args["rate"]),
+		Duration:    time.Duration(cast.ToFloat64(args["durationSeconds"]) * float64(time.Second)),
+	}
+	if opts.Requests <= 0 {
+		// 只指定时长时在时长内持续发送
+		opts.Requests = 10
+		if opts.Duration > 0 {
+			opts.Requests = cfg.MaxRequests
+		}
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Rate < 0 {
+		opts.Rate = 0
+	}
+
+	maxDuration := time.Duration(cfg.MaxDurationSeconds) * time.Second
+	if opts.Requests > cfg.MaxRequests {
+		opts.Notes = append(opts.Notes, fmt.Sprintf("请求数 %d 超过上限 %d", opts.Requests, cfg.MaxRequests))
+		opts.Requests = cfg.MaxRequests
+	}
+	if opts.Concurrency > cfg.MaxConcurrency {
+		opts.Notes = append(opts.Notes, fmt.Sprintf("并发数 %d 超过上限 %d", opts.Concurrency, cfg.MaxConcurrency))
+		opts.Concurrency = cfg.MaxConcurrency
+	}
+	opts.Concurrency = min(opts.Concurrency, opts.Requests)
+	if cfg.MaxRate > 0 && (opts.Rate == 0 || opts.Rate > cfg.MaxRate) {
+		if opts.Rate > cfg.MaxRate {
+			opts.Notes = append(opts.Notes, fmt.Sprintf("速率 %g/s 超过上限 %g/s", opts.Rate, cfg.MaxRate))
+		}
+		opts.Rate = cfg.MaxRate
+	}
+	if opts.Duration > maxDuration {
+		opts.Notes = append(opts.Notes, fmt.Sprintf("时长 %s 超过上限 %s", opts.Duration, maxDuration))
+	}
+	if opts.Duration <= 0 || opts.Duration > maxDuration {
+		opts.Duration = maxDuration
+	}
+	// 速率过低时请求间隔超过时长（极小值还会让间隔溢出为负数），时长内只能发出第一个请求
+	if opts.Rate > 0 && opts.Rate*opts.Duration.Seconds() < 1 {
+		opts.Notes = append(opts.Notes, fmt.Sprintf("速率 %g/s 过低，%s 内只能发送 1 个请求", opts.Rate, opts.Duration))
+		opts.Requests, opts.Concurrency, opts.Rate = 1, 1, 0
+	}
+	return opts
+}
+
+// LoadReport 压测结果
+type LoadReport struct {
+	Options   LoadOptions
+	Sent      int
+	Elapsed   time.Duration
+	Latencies []time.Duration // 收到响应的请求耗时，已排序
+	Status    map[string]int  // 状态码或 error 的次数
+	Errors    []loadErrorSample
+	Bytes     int64
+	firstErr  error
+}
+
+type loadErrorSample struct {
+	Message string
+	Count   int
+}
+
+// recordError 按错误信息归并，最多保留 maxLoadErrorSamples 种
+func (r *LoadReport) recordError(err error) {
+	if r.firstErr == nil {
+		r.firstErr = err
+	}
+	r.Status["error"]++
+	msg := err.Error()
+	for i := range r.Errors {
+		if r.Errors[i].Message == msg {
+			r.Errors[i].Count++
+			return
+		}
+	}
+	if len(r.Errors) < maxLoadErrorSamples {
+		r.Errors = append(r.Errors, loadErrorSample{Message: msg, Count: 1})
+	}
+}
+
+// percentile 按最近秩法计算已排序耗时的百分位
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(0, min(idx, len(sorted)-1))]
+}
+
+// runLoad 并发发送请求，按速率限制发放请求，达到请求数或时长后等待进行中的请求完成
+//
+// 压测请求不重试、不记录到请求历史。onProgress 在每个请求完成后调用。
+func runLoad(ctx context.Context, req *Request, opts LoadOptions, onProgress func(done int)) *LoadReport {
+	ctx = helper.WithoutRetry(ctx)
+	report := &LoadReport{Options: opts, Status: make(map[string]int)}
+	start := time.Now()
+
+	// 发放器：控制请求总数、速率和时长
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		deadline := time.NewTimer(opts.Duration)
+		defer deadline.Stop()
+		var tick <-chan time.Time
+		if opts.Rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for i := 0; i < opts.Requests; i++ {
+			if tick != nil && i > 0 {
+				select {
+				case <-tick:
+				case <-deadline.C:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-deadline.C:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				begin := time.Now()
+				resp, err := send(ctx, req.Clone())
+				d := time.Since(begin)
+
+				mu.Lock()
+				report.Sent++
+				if err != nil {
+					report.recordError(err)
+				} else {
+					report.Latencies = append(report.Latencies, d)
+					report.Status[strconv.Itoa(resp.StatusCode)]++
+					report.Bytes += int64(len(resp.Body))
+				}
+				done := report.Sent
+				mu.Unlock()
+				onProgress(done)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report.Elapsed = time.Since(start)
+	slices.Sort(report.Latencies)
+	return report
+}
+
+// String 返回压测报告文本
+func (r *LoadReport) String() string {
+	var sb strings.Builder
+	opts := r.Options
+	rate := "不限"
+	if opts.Rate > 0 {
+		rate = fmt.Sprintf("%g/s", opts.Rate)
+	}
+	fmt.Fprintf(&sb, "Requests: %d (成功 %d，失败 %d)，并发 %d，速率 %s\n",
+		r.Sent, len(r.Latencies), r.Status["error"], opts.Concurrency, rate)
+	throughput := 0.0
+	if r.Elapsed > 0 {
+		throughput = float64(r.Sent) / r.Elapsed.Seconds()
+	}
+	fmt.Fprintf(&sb, "Duration: %.3fs, Throughput: %.1f req/s, Received: %d bytes\n", r.Elapsed.Seconds(), throughput, r.Bytes)
+
+	if n := len(r.Latencies); n > 0 {
+		var total time.Duration
+		for _, d := range r.Latencies {
+			total += d
+		}
+		fmt.Fprintf(&sb, "Latency: min %s, avg %s, p50 %s, p90 %s, p99 %s, max %s\n",
+			formatLatency(r.Latencies[0]), formatLatency(total/time.Duration(n)),
+			formatLatency(percentile(r.Latencies, 50)), formatLatency(percentile(r.Latencies, 90)),
+			formatLatency(percentile(r.Latencies, 99)), formatLatency(r.Latencies[n-1]))
+	}
+
+	sb.WriteString("Status:\n")
+	keys := make([]string, 0, len(r.Status))
+	for k := range r.Status {
+		keys = append(keys, k)
+	}
+	// 状态码按数值排列，error 放在最后
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == "error") != (keys[j] == "error") {
+			return keys[j] == "error"
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "  %s: %d\n", k, r.Status[k])
+	}
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(&sb, "Errors (%d):\n", r.Status["error"])
+		for _, e := range r.Errors {
+			fmt.Fprintf(&sb, "  %d× %s\n", e.Count, e.Message)
+		}
+	}
+	if r.Sent < opts.Requests {
+		fmt.Fprintf(&sb, "Stopped: 达到时长 %s，计划 %d 个请求\n", opts.Duration, opts.Requests)
+	}
+	if len(opts.Notes) > 0 {
+		fmt.Fprintf(&sb, "Notes: %s，已按上限执行\n", strings.Join(opts.Notes, "；"))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatLatency 以毫秒显示耗时
+func formatLatency(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 1, 64) + "ms"
+}
+
+var httpLoadTool = withRequestParams(mcp.NewTool("http_load",
+	mcp.WithDescription(`对 HTTP 接口做小规模压测：按指定并发和速率重复发送同一请求，达到请求数或时长任一限制即停止。
+返回延迟分位数（p50/p90/p99）、状态码分布、错误样例和吞吐量。请求数、并发、速率和时长受 http.yaml 中 load 配置的上限约束，超出时按上限执行。
+压测请求同样经过出站策略检查，但失败时不重试，也不记录到请求历史。请求参数与 http_request 相同。`),
+	mcp.WithNumber("requests",
+		mcp.Description("请求总数，默认 10；只指定 durationSeconds 时在时长内持续发送，直到达到请求数上限"),
+	),
+	mcp.WithNumber("concurrency",
+		mcp.Description("并发数，默认 1"),
+	),
+	mcp.WithNumber("rate",
+		mcp.Description("每秒最多发送的请求数，默认使用配置的上限"),
+	),
+	mcp.WithNumber("durationSeconds",
+		mcp.Description("最长压测时间（秒），默认使用配置的上限"),
+	),
+))
+
+func httpLoadHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	req, err := buildRequest(args)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("解析请求参数失败", err), nil
+	}
+	if req.Session != "" {
+		return mcp.NewToolResultError("http_load 不支持 session 参数，并发请求会同时读写同一会话"), nil
+	}
+	opts := loadOptionsFromArgs(args, getLoadConfig())
+
+	step := max(1, opts.Requests/20)
+	report := runLoad(ctx, req, opts, func(done int) {
+		if done%step == 0 || done == opts.Requests {
+			helper.NotifyProgress(ctx, request, float64(done), float64(opts.Requests), fmt.Sprintf("sent %d requests", done))
+		}
+	})
+
+	// 全部失败且为策略拒绝等确定性错误时直接返回错误
+	if len(report.Latencies) == 0 && report.firstErr != nil {
+		var policyErr *helper.PolicyError
+		if errors.As(report.firstErr, &policyErr) {
+			return requestErrorResult(report.firstErr), nil
+		}
+		return mcp.NewToolResultError(report.String()), nil
+	}
+	return mcp.NewToolResultText(report.String()), nil
+}
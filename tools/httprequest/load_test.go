@@ -0,0 +1,134 @@
+package httprequest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOptionsFromArgs(t *testing.T) {
+	cfg := LoadConfig{MaxRequests: 100, MaxConcurrency: 4, MaxRate: 50, MaxDurationSeconds: 10}
+
+	opts := loadOptionsFromArgs(map[string]any{}, cfg)
+	assert.Equal(t, LoadOptions{Requests: 10, Concurrency: 1, Rate: 50, Duration: 10 * time.Second}, opts)
+
+	opts = loadOptionsFromArgs(map[string]any{"durationSeconds": 2}, cfg)
+	assert.Equal(t, 100, opts.Requests, "只指定时长时请求数取上限")
+	assert.Equal(t, 2*time.Second, opts.Duration)
+
+	opts = loadOptionsFromArgs(map[string]any{"requests": 500, "concurrency": 10, "rate": 200, "durationSeconds": 30}, cfg)
+	assert.Equal(t, LoadOptions{Requests: 100, Concurrency: 4, Rate: 50, Duration: 10 * time.Second, Notes: []string{
+		"请求数 500 超过上限 100", "并发数 10 超过上限 4", "速率 200/s 超过上限 50/s", "时长 30s 超过上限 10s",
+	}}, opts)
+
+	opts = loadOptionsFromArgs(map[string]any{"requests": 3, "concurrency": 4}, LoadConfig{MaxRequests: 10, MaxConcurrency: 8, MaxDurationSeconds: 5})
+	assert.Equal(t, 3, opts.Concurrency, "并发数不超过请求数")
+	assert.Zero(t, opts.Rate, "未配置速率上限时不限速")
+
+	// 极小的速率会让请求间隔溢出为负数，NewTicker 会 panic
+	opts = loadOptionsFromArgs(map[string]any{"requests": 5, "concurrency": 2, "rate": 1e-10}, cfg)
+	assert.Equal(t, LoadOptions{Requests: 1, Concurrency: 1, Duration: 10 * time.Second, Notes: []string{
+		"速率 1e-10/s 过低，10s 内只能发送 1 个请求",
+	}}, opts)
+	opts = loadOptionsFromArgs(map[string]any{"requests": 5, "rate": 0.5, "durationSeconds": 4}, cfg)
+	assert.Equal(t, 5, opts.Requests, "时长内能发送多个请求时不调整")
+	assert.Equal(t, 0.5, opts.Rate)
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 90*time.Millisecond, percentile(sorted, 90))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, 7*time.Millisecond, percentile([]time.Duration{7 * time.Millisecond}, 99))
+	assert.Zero(t, percentile(nil, 50))
+}
+
+func TestHttpLoadHandler(t *testing.T) {
+	var hits, inflight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		cur := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if n%5 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	history := resetHistory(t, HistoryConfig{})
+	orig := getLoadConfig
+	getLoadConfig = func() LoadConfig {
+		return LoadConfig{MaxRequests: 20, MaxConcurrency: 4, MaxDurationSeconds: 10}
+	}
+	t.Cleanup(func() { getLoadConfig = orig })
+
+	result, err := httpLoadHandler(context.Background(), newCallToolRequest("http_load", map[string]any{
+		"url":         srv.URL,
+		"requests":    50,
+		"concurrency": 4,
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	text := resultText(t, result)
+	assert.Equal(t, int32(20), hits.Load())
+	assert.LessOrEqual(t, peak.Load(), int32(4))
+	assert.Contains(t, text, "Requests: 20 (成功 20，失败 0)，并发 4，速率 不限\n")
+	assert.Regexp(t, `Latency: min \d+\.\dms, avg \d+\.\dms, p50 \d+\.\dms, p90 \d+\.\dms, p99 \d+\.\dms, max \d+\.\dms`, text)
+	assert.Contains(t, text, "Status:\n  200: 16\n  503: 4\n")
+	assert.Contains(t, text, "Notes: 请求数 50 超过上限 20，已按上限执行")
+	assert.Empty(t, history.List(), "压测请求不记录历史")
+
+	t.Run("速率和时长限制", func(t *testing.T) {
+		hits.Store(0)
+		start := time.Now()
+		result, err := httpLoadHandler(context.Background(), newCallToolRequest("http_load", map[string]any{
+			"url":             srv.URL,
+			"rate":            20,
+			"durationSeconds": 0.3,
+		}))
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.InDelta(t, 7, hits.Load(), 2, "0.3 秒内以 20/s 发送约 7 个请求")
+		assert.Contains(t, resultText(t, result), "Stopped: 达到时长 300ms，计划 20 个请求")
+	})
+
+	t.Run("极小速率只发送一个请求", func(t *testing.T) {
+		hits.Store(0)
+		result, err := httpLoadHandler(context.Background(), newCallToolRequest("http_load", map[string]any{
+			"url":      srv.URL,
+			"requests": 5,
+			"rate":     1e-10,
+		}))
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, hits.Load())
+		assert.Contains(t, resultText(t, result), "速率 1e-10/s 过低")
+	})
+
+	t.Run("请求失败时给出错误样例", func(t *testing.T) {
+		result, err := httpLoadHandler(context.Background(), newCallToolRequest("http_load", map[string]any{
+			"url":      "http://127.0.0.1:1/",
+			"requests": 3,
+		}))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		text := resultText(t, result)
+		assert.Contains(t, text, "Status:\n  error: 3\nErrors (3):\n  3× ")
+	})
+}
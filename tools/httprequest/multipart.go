@@ -0,0 +1,153 @@
+package httprequest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// FormPart multipart/form-data 中的一个字段，FilePath 非空时为文件字段
+type FormPart struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FilePath    string `json:"path,omitempty"`        // 本地文件路径，发送时从磁盘流式读取
+	FileName    string `json:"filename,omitempty"`    // 文件名，默认取 FilePath 的文件名
+	ContentType string `json:"contentType,omitempty"` // 文件内容类型，默认按扩展名推断
+}
+
+// quoteEscaper 转义 Content-Disposition 中的引号和反斜杠
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// parseFormArg 将 form 参数编码为 application/x-www-form-urlencoded 请求体，值可以是字符串或数组
+func parseFormArg(form map[string]any) string {
+	values := make(url.Values, len(form))
+	for k, v := range form {
+		if list, ok := v.([]any); ok {
+			for _, item := range list {
+				values.Add(k, cast.ToString(item))
+			}
+			continue
+		}
+		values.Add(k, cast.ToString(v))
+	}
+	return values.Encode()
+}
+
+// parseMultipartArg 解析 multipart 参数，每一项为 {name, value} 或 {name, path, filename, contentType}
+func parseMultipartArg(arg any) ([]FormPart, error) {
+	items, ok := arg.([]any)
+	if !ok {
+		return nil, errors.New("multipart 参数必须是数组")
+	}
+
+	parts := make([]FormPart, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("multipart 第 %d 项必须是对象", i+1)
+		}
+		part := FormPart{
+			Name:        cast.ToString(m["name"]),
+			Value:       cast.ToString(m["value"]),
+			FilePath:    cast.ToString(m["path"]),
+			FileName:    cast.ToString(m["filename"]),
+			ContentType: cast.ToString(m["contentType"]),
+		}
+		if part.Name == "" {
+			return nil, fmt.Errorf("multipart 第 %d 项缺少 name", i+1)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// bodyReader 返回请求体及其 Content-Type
+//
+// multipart 请求先检查文件是否可读，再通过管道边读文件边发送，不会把文件整体读入内存，
+// 返回的管道实现了 io.Closer，请求失败时调用方需要关闭以结束写入的 goroutine；
+// 其他请求返回 *strings.Reader 以便设置 Content-Length，Content-Type 为空表示沿用请求头。
+func (r *Request) bodyReader() (io.Reader, string, error) {
+	if len(r.Parts) == 0 {
+		return strings.NewReader(r.Body), "", nil
+	}
+
+	for _, p := range r.Parts {
+		if p.FilePath == "" {
+			continue
+		}
+		info, err := os.Stat(p.FilePath)
+		if err != nil {
+			return nil, "", fmt.Errorf("读取上传文件失败: %w", err)
+		}
+		if info.IsDir() {
+			return nil, "", fmt.Errorf("上传文件 %s 是目录", p.FilePath)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipart(w, r.Parts))
+	}()
+	return pr, w.FormDataContentType(), nil
+}
+
+// writeMultipart 依次写入各字段并结束 multipart 请求体
+func writeMultipart(w *multipart.Writer, parts []FormPart) error {
+	for _, p := range parts {
+		if p.FilePath == "" {
+			if err := w.WriteField(p.Name, p.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeFilePart(w, p); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func writeFilePart(w *multipart.Writer, p FormPart) error {
+	f, err := os.Open(p.FilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fileName, contentType := p.fileInfo()
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(p.Name), quoteEscaper.Replace(fileName)))
+	h.Set("Content-Type", contentType)
+	fw, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, f)
+	return err
+}
+
+// fileInfo 返回文件字段发送时使用的文件名和内容类型
+func (p FormPart) fileInfo() (fileName, contentType string) {
+	fileName = p.FileName
+	if fileName == "" {
+		fileName = filepath.Base(p.FilePath)
+	}
+	contentType = p.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(fileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return fileName, contentType
+}
@@ -0,0 +1,107 @@
+package httprequest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpHandler_Multipart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		io.WriteString(w, "title="+r.FormValue("title")+"\n")
+		io.WriteString(w, "filename="+h.Filename+"\n")
+		io.WriteString(w, "type="+h.Header.Get("Content-Type")+"\n")
+		io.WriteString(w, "content="+string(data))
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "report.csv")
+	require.NoError(t, os.WriteFile(file, []byte("a,b\n1,2\n"), 0o644))
+
+	result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+		"method": "POST",
+		"url":    srv.URL + "/upload",
+		"multipart": []any{
+			map[string]any{"name": "title", "value": "demo"},
+			map[string]any{"name": "file", "path": file, "filename": "data.csv", "contentType": "text/csv"},
+		},
+	}))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.Contains(t, text, "Status: 200")
+	assert.Contains(t, text, "title=demo\nfilename=data.csv\ntype=text/csv\ncontent=a,b\n1,2\n")
+
+	result, err = httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+		"method":    "POST",
+		"url":       srv.URL + "/upload",
+		"multipart": []any{map[string]any{"name": "file", "path": filepath.Join(t.TempDir(), "missing.txt")}},
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "读取上传文件失败")
+}
+
+func TestHttpHandler_MultipartNoLeak(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0o644))
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+			"method":    "POST",
+			"url":       "http://169.254.169.254/upload",
+			"multipart": []any{map[string]any{"name": "file", "path": file}},
+		}))
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+	}
+	// 请求被策略拒绝时写入请求体的 goroutine 应当退出
+	assert.Eventually(t, func() bool { return runtime.NumGoroutine() <= before+2 }, 2*time.Second, 20*time.Millisecond,
+		"goroutines: before %d, now %d", before, runtime.NumGoroutine())
+}
+
+func TestBuildRequest_Form(t *testing.T) {
+	req, err := buildRequest(map[string]any{
+		"method": "POST",
+		"url":    "https://api.example.com/login",
+		"form":   map[string]any{"user": "alice", "tag": []any{"a", "b"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "tag=a&tag=b&user=alice", req.Body)
+	assert.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+
+	_, err = buildRequest(map[string]any{
+		"method": "POST",
+		"url":    "https://api.example.com/login",
+		"form":   map[string]any{"user": "alice"},
+		"json":   map[string]any{"user": "alice"},
+	})
+	assert.Error(t, err)
+
+	_, err = buildRequest(map[string]any{
+		"method":    "POST",
+		"url":       "https://api.example.com/upload",
+		"multipart": []any{map[string]any{"value": "x"}},
+	})
+	assert.ErrorContains(t, err, "缺少 name")
+}
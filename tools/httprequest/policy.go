@@ -0,0 +1,89 @@
+package httprequest
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kugouming/mcpservers/helper"
+)
+
+// getURLPolicy 返回当前的出站策略，测试中可替换
+var getURLPolicy = helper.GetURLPolicy
+
+// policyTransport 在每次请求（包括每次重定向）前检查目标 URL
+type policyTransport struct {
+	base   http.RoundTripper
+	policy func() *helper.URLPolicy
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy().CheckURL(req.Context(), req.URL); err != nil {
+		// RoundTripper 出错时同样需要关闭请求体
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// proxyAddrs 返回配置和环境变量中的代理地址，这些地址由管理员配置，建立连接时不受策略限制
+func proxyAddrs(cfg *helper.HttpClientConfig) map[string]bool {
+	addrs := make(map[string]bool)
+	candidates := []string{cfg.Proxy}
+	for _, env := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"} {
+		candidates = append(candidates, os.Getenv(env))
+	}
+	for _, raw := range candidates {
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "://") {
+			raw = "http://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"https": "443", "socks5": "1080"}[u.Scheme]
+			if port == "" {
+				port = "80"
+			}
+		}
+		addrs[net.JoinHostPort(u.Hostname(), port)] = true
+	}
+	return addrs
+}
+
+// getHttpClient 返回带出站策略检查的共享客户端，测试中可替换
+var getHttpClient = sync.OnceValue(func() *http.Client {
+	cfg, err := helper.LoadHttpClientConfig()
+	if err != nil {
+		log.Printf("读取 HTTP 客户端配置失败，使用默认配置: %v", err)
+		cfg = helper.DefaultHttpClientConfig()
+	}
+	policy := func() *helper.URLPolicy { return getURLPolicy() }
+	client, err := newPolicyHttpClient(cfg, policy)
+	if err != nil {
+		log.Printf("创建 HTTP 客户端失败，使用默认配置: %v", err)
+		client, _ = newPolicyHttpClient(helper.DefaultHttpClientConfig(), policy)
+	}
+	return client
+})
+
+// newPolicyHttpClient 创建在请求、重定向和建立连接时都检查出站策略的客户端
+func newPolicyHttpClient(cfg *helper.HttpClientConfig, policy func() *helper.URLPolicy) (*http.Client, error) {
+	return helper.NewHttpClient(cfg,
+		helper.WithTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return &policyTransport{base: rt, policy: policy}
+		}),
+		helper.WithDialWrapper(helper.PolicyDialer(policy, proxyAddrs(cfg))),
+	)
+}
@@ -0,0 +1,118 @@
+package httprequest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain 测试服务监听在回环地址上，默认放行回环地址；请求历史只保存在内存中
+func TestMain(m *testing.M) {
+	policy, err := helper.NewURLPolicy(&helper.URLPolicyConfig{AllowCIDRs: []string{"127.0.0.0/8", "::1"}})
+	if err != nil {
+		panic(err)
+	}
+	getURLPolicy = func() *helper.URLPolicy { return policy }
+	history := newHistoryStore(HistoryConfig{})
+	getHistory = func() *historyStore { return history }
+	os.Exit(m.Run())
+}
+
+// setURLPolicy 在测试期间替换出站策略
+func setURLPolicy(t *testing.T, cfg *helper.URLPolicyConfig, hosts map[string][]string) *helper.URLPolicy {
+	t.Helper()
+	policy, err := helper.NewURLPolicy(cfg)
+	require.NoError(t, err)
+	if hosts != nil {
+		policy.Lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+			var addrs []netip.Addr
+			for _, ip := range hosts[host] {
+				addrs = append(addrs, netip.MustParseAddr(ip))
+			}
+			return addrs, nil
+		}
+	}
+	orig := getURLPolicy
+	getURLPolicy = func() *helper.URLPolicy { return policy }
+	t.Cleanup(func() { getURLPolicy = orig })
+	return policy
+}
+
+func TestHttpHandler_Policy(t *testing.T) {
+	t.Run("默认拒绝回环地址并返回规则名称", func(t *testing.T) {
+		srv := newEchoServer()
+		defer srv.Close()
+		setURLPolicy(t, nil, nil)
+
+		result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+			"method": "GET",
+			"url":    srv.URL + "/admin",
+		}))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, resultText(t, result), "规则 default:loopback 禁止访问 127.0.0.1")
+	})
+
+	t.Run("重定向到被禁止的地址时拒绝", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/metadata":
+				http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			case "/local":
+				http.Redirect(w, r, "http://localhost/admin", http.StatusFound)
+			}
+		}))
+		defer srv.Close()
+		setURLPolicy(t, &helper.URLPolicyConfig{
+			AllowCIDRs: []string{"127.0.0.1"},
+			DenyHosts:  []string{"localhost"},
+		}, nil)
+
+		tests := map[string]string{
+			"/metadata": "规则 default:metadata 禁止访问 169.254.169.254",
+			"/local":    "规则 deny_hosts:localhost 禁止访问 localhost",
+		}
+		for path, want := range tests {
+			result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+				"method": "GET",
+				"url":    srv.URL + path,
+			}))
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+			assert.Contains(t, resultText(t, result), want)
+		}
+	})
+}
+
+// closeTracker 记录是否被关闭的请求体
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestPolicyTransport_ClosesBody(t *testing.T) {
+	policy := setURLPolicy(t, nil, nil)
+	transport := &policyTransport{base: http.DefaultTransport, policy: func() *helper.URLPolicy { return policy }}
+
+	body := &closeTracker{Reader: strings.NewReader("data")}
+	req, err := http.NewRequest(http.MethodPost, "http://169.254.169.254/upload", body)
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	var policyErr *helper.PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.True(t, body.closed, "策略拒绝时关闭请求体")
+}
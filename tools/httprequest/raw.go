@@ -0,0 +1,93 @@
+package httprequest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// httpRawTool 直接执行原始 curl 命令或 HTTP 报文，由服务端负责解析
+var httpRawTool = mcp.NewTool("http_request_raw",
+	mcp.WithDescription(`执行用户粘贴的原始 CURL 命令或原始 HTTP 请求报文，由服务端解析 method/url/headers/body 后发起请求。
+- 当用户输入的是完整的 CURL 命令或 HTTP 报文时优先使用本工具，原样传入即可，无需自行拆分参数;
+- 支持 CURL 选项: -X, -H, -d/--data/--data-raw/--data-binary/--data-urlencode, -u, -F, --json, -G, -I, -A, -e, -b;
+- 支持反斜杠续行、单引号、双引号以及 $'...' 引用;
+- HTTP 报文格式: 第一行为 "METHOD URL [HTTP/1.1]", 随后为请求头, 空行之后为请求体; URL 为路径时使用 Host 请求头补全。`),
+	mcp.WithString("raw",
+		mcp.Required(),
+		mcp.MinLength(1),
+		mcp.Description("原始 CURL 命令或 HTTP 请求报文，保持用户输入原样"),
+	),
+	profileToolOption,
+	sessionToolOption,
+)
+
+func httpRawHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	raw := cast.ToString(request.GetArguments()["raw"])
+
+	req, err := ParseRaw(raw)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("解析请求失败", err), nil
+	}
+	req.Profile = cast.ToString(request.GetArguments()["profile"])
+	req.Session = cast.ToString(request.GetArguments()["session"])
+
+	return doRequest(ctx, req, responseOptionsFromArgs(request.GetArguments()))
+}
+
+// ParseRaw 解析 curl 命令或原始 HTTP 报文
+func ParseRaw(raw string) (*Request, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "curl" || strings.HasPrefix(raw, "curl ") || strings.HasPrefix(raw, "curl\t") {
+		return ParseCurl(raw)
+	}
+	return ParseHTTPMessage(raw)
+}
+
+// ParseHTTPMessage 解析 "METHOD URL HTTP/1.1" 形式的原始 HTTP 报文
+func ParseHTTPMessage(raw string) (*Request, error) {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	head, body, _ := strings.Cut(raw, "\n\n")
+
+	scanner := bufio.NewScanner(strings.NewReader(head))
+	if !scanner.Scan() {
+		return nil, errors.New("empty http message")
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid request line %q", scanner.Text())
+	}
+
+	req := &Request{
+		Method: strings.ToUpper(fields[0]),
+		URL:    fields[1],
+		Header: make(http.Header),
+		Body:   strings.TrimRight(body, "\n"),
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header line %q", line)
+		}
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	if strings.HasPrefix(req.URL, "/") {
+		host := req.Header.Get("Host")
+		if host == "" {
+			return nil, errors.New("request target is a path but Host header is missing")
+		}
+		req.URL = "https://" + host + req.URL
+	}
+	return req, nil
+}
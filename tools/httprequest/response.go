@@ -0,0 +1,231 @@
+package httprequest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// defaultMaxBodyBytes 响应体默认最大输出字节数
+const defaultMaxBodyBytes = 64 * 1024
+
+// ResponseOptions 控制响应内容的输出方式
+type ResponseOptions struct {
+	Headers  []string // 需要输出的响应头，"*" 表示全部
+	Pretty   bool     // 格式化 JSON 响应体
+	Extract  string   // JSONPath 表达式，只输出提取的值
+	MaxBytes int      // 响应体最大输出字节数
+	Binary   string   // 二进制响应体的输出方式: summary | base64
+}
+
+// responseToolOptions 响应处理相关的工具参数
+var responseToolOptions = []mcp.ToolOption{
+	mcp.WithArray("responseHeaders",
+		mcp.Items(map[string]any{"type": "string"}),
+		mcp.Description("需要输出的响应头名称，[\"*\"] 表示输出全部响应头"),
+	),
+	mcp.WithBoolean("pretty",
+		mcp.Description("是否格式化输出 JSON 响应体"),
+	),
+	mcp.WithString("extract",
+		mcp.Description("JSONPath/jq 风格表达式，只输出提取到的值，如 $.data.items[0].id、.data.items[].name、$..id"),
+	),
+	mcp.WithNumber("maxBodyBytes",
+		mcp.Description(fmt.Sprintf("响应体最大输出字节数，超出部分截断，默认 %d", defaultMaxBodyBytes)),
+	),
+	mcp.WithString("binary",
+		mcp.Enum("summary", "base64"),
+		mcp.Description("二进制响应体的输出方式: summary 仅输出大小和摘要（默认），base64 输出 base64 编码内容；图片响应以图片内容返回"),
+	),
+}
+
+// withResponseOptions 为工具追加响应处理参数
+func withResponseOptions(tool mcp.Tool) mcp.Tool {
+	for _, opt := range responseToolOptions {
+		opt(&tool)
+	}
+	return tool
+}
+
+// responseOptionsFromArgs 从工具参数中读取响应处理选项
+func responseOptionsFromArgs(args map[string]any) ResponseOptions {
+	opts := ResponseOptions{
+		Headers:  cast.ToStringSlice(args["responseHeaders"]),
+		Pretty:   cast.ToBool(args["pretty"]),
+		Extract:  cast.ToString(args["extract"]),
+		MaxBytes: cast.ToInt(args["maxBodyBytes"]),
+		Binary:   cast.ToString(args["binary"]),
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultMaxBodyBytes
+	}
+	return opts
+}
+
+// formatResponse 按选项生成工具结果
+func formatResponse(resp *helper.HttpResponse, opts ResponseOptions) (*mcp.CallToolResult, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Status: %d\n", resp.StatusCode)
+	if headers := selectHeaders(resp.Header, opts.Headers); headers != "" {
+		fmt.Fprintf(&sb, "Headers:\n%s", headers)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	if isBinaryBody(mediaType, resp.Body) {
+		summary := fmt.Sprintf("Binary body: %d bytes, Content-Type: %s, sha256: %s", len(resp.Body), contentType, sha256Hex(resp.Body))
+		switch {
+		case len(resp.Body) > opts.MaxBytes:
+			fmt.Fprintf(&sb, "%s (exceeds maxBodyBytes %d)", summary, opts.MaxBytes)
+		case strings.HasPrefix(mediaType, "image/"):
+			return mcp.NewToolResultImage(sb.String()+summary, base64.StdEncoding.EncodeToString(resp.Body), mediaType), nil
+		case opts.Binary == "base64":
+			fmt.Fprintf(&sb, "%s\nBody (base64): %s", summary, base64.StdEncoding.EncodeToString(resp.Body))
+		default:
+			sb.WriteString(summary)
+		}
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+
+	body := resp.Body
+	switch {
+	case opts.Extract != "":
+		var data any
+		if err := decodeJSON(body, &data); err != nil {
+			return mcp.NewToolResultErrorFromErr(fmt.Sprintf("%s响应体不是 JSON，无法提取 %s", sb.String(), opts.Extract), err), nil
+		}
+		value, err := EvalJSONPath(data, opts.Extract)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr(sb.String()+"提取失败", err), nil
+		}
+		body = encodeJSON(value, opts.Pretty)
+	case opts.Pretty:
+		// 直接在原始内容上缩进，保留数字精度和键的顺序；不是 JSON 时原样输出
+		var buf bytes.Buffer
+		if json.Indent(&buf, body, "", "  ") == nil {
+			body = buf.Bytes()
+		}
+	}
+
+	text, truncated := truncateUTF8(body, opts.MaxBytes)
+	fmt.Fprintf(&sb, "Body: %s", text)
+	if truncated {
+		fmt.Fprintf(&sb, "\n...(truncated, showing %d of %d bytes)", len(text), len(body))
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// decodeJSON 解析 JSON，数字保留为 json.Number 以免大整数丢失精度
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("JSON 之后有多余的内容")
+	}
+	return nil
+}
+
+// encodeJSON 序列化 JSON，不转义 <、>、& 等 HTML 字符
+func encodeJSON(v any, pretty bool) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	enc.Encode(v)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// selectHeaders 按名称选择响应头，返回每行一个的文本
+func selectHeaders(header http.Header, names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var keys []string
+	if len(names) == 1 && names[0] == "*" {
+		keys = sortedKeys(header)
+	} else {
+		for _, name := range names {
+			keys = append(keys, http.CanonicalHeaderKey(name))
+		}
+	}
+
+	var sb strings.Builder
+	for _, k := range keys {
+		for _, v := range header.Values(k) {
+			fmt.Fprintf(&sb, "  %s: %s\n", k, v)
+		}
+	}
+	return sb.String()
+}
+
+// isBinaryBody 根据 Content-Type 和内容判断响应体是否为二进制
+func isBinaryBody(mediaType string, body []byte) bool {
+	switch {
+	case mediaType == "":
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/json",
+		mediaType == "application/xml",
+		mediaType == "application/javascript",
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/x-ndjson":
+		return false
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"),
+		mediaType == "application/octet-stream",
+		mediaType == "application/pdf",
+		mediaType == "application/zip",
+		mediaType == "application/gzip":
+		return true
+	}
+	return !utf8.Valid(body) || bytes.IndexByte(body, 0) >= 0
+}
+
+// truncateUTF8 截断到不超过 max 字节，且不截断多字节字符
+func truncateUTF8(b []byte, max int) (string, bool) {
+	if len(b) <= max {
+		return string(b), false
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(b[cut]) {
+		cut--
+	}
+	return string(b[:cut]), true
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
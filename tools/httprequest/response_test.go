@@ -0,0 +1,90 @@
+package httprequest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatResponse(t *testing.T) {
+	jsonResp := &helper.HttpResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"r1"}, "Set-Cookie": {"a=1", "b=2"}},
+		Body:       []byte(`{"data":{"items":[{"id":1},{"id":2}]}}`),
+	}
+
+	t.Run("输出选定响应头并提取 JSON", func(t *testing.T) {
+		result, err := formatResponse(jsonResp, ResponseOptions{
+			Headers:  []string{"x-request-id", "set-cookie"},
+			Extract:  "$.data.items[*].id",
+			MaxBytes: defaultMaxBodyBytes,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Status: 200\nHeaders:\n  X-Request-Id: r1\n  Set-Cookie: a=1\n  Set-Cookie: b=2\nBody: [1,2]", resultText(t, result))
+	})
+
+	t.Run("格式化 JSON", func(t *testing.T) {
+		result, err := formatResponse(jsonResp, ResponseOptions{Pretty: true, Extract: "data.items[0]", MaxBytes: defaultMaxBodyBytes})
+		require.NoError(t, err)
+		assert.Equal(t, "Status: 200\nBody: {\n  \"id\": 1\n}", resultText(t, result))
+	})
+
+	t.Run("保留大整数、键的顺序和 HTML 字符", func(t *testing.T) {
+		resp := &helper.HttpResponse{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       []byte(`{"z":9007199254740993,"a":"<b>&</b>","price":1.10}`),
+		}
+		result, err := formatResponse(resp, ResponseOptions{Pretty: true, MaxBytes: defaultMaxBodyBytes})
+		require.NoError(t, err)
+		assert.Equal(t, "Status: 200\nBody: {\n  \"z\": 9007199254740993,\n  \"a\": \"<b>&</b>\",\n  \"price\": 1.10\n}", resultText(t, result))
+
+		result, err = formatResponse(resp, ResponseOptions{Extract: "$.z", MaxBytes: defaultMaxBodyBytes})
+		require.NoError(t, err)
+		assert.Equal(t, "Status: 200\nBody: 9007199254740993", resultText(t, result))
+
+		result, err = formatResponse(resp, ResponseOptions{Extract: "$.a", MaxBytes: defaultMaxBodyBytes})
+		require.NoError(t, err)
+		assert.Equal(t, "Status: 200\nBody: \"<b>&</b>\"", resultText(t, result))
+	})
+
+	t.Run("截断响应体且不破坏多字节字符", func(t *testing.T) {
+		resp := &helper.HttpResponse{StatusCode: 200, Header: http.Header{}, Body: []byte("你好世界")}
+		result, err := formatResponse(resp, ResponseOptions{MaxBytes: 7})
+		require.NoError(t, err)
+		assert.Equal(t, "Status: 200\nBody: 你好\n...(truncated, showing 6 of 12 bytes)", resultText(t, result))
+	})
+
+	t.Run("二进制响应体输出摘要或 base64", func(t *testing.T) {
+		resp := &helper.HttpResponse{StatusCode: 200, Header: http.Header{"Content-Type": {"application/octet-stream"}}, Body: []byte{0, 1, 2}}
+		result, err := formatResponse(resp, ResponseOptions{MaxBytes: defaultMaxBodyBytes})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(resultText(t, result), "Status: 200\nBinary body: 3 bytes, Content-Type: application/octet-stream, sha256: "))
+
+		result, err = formatResponse(resp, ResponseOptions{MaxBytes: defaultMaxBodyBytes, Binary: "base64"})
+		require.NoError(t, err)
+		assert.Contains(t, resultText(t, result), "Body (base64): AAEC")
+	})
+
+	t.Run("图片响应返回图片内容", func(t *testing.T) {
+		resp := &helper.HttpResponse{StatusCode: 200, Header: http.Header{"Content-Type": {"image/png"}}, Body: []byte("\x89PNG\r\n")}
+		result, err := formatResponse(resp, ResponseOptions{MaxBytes: defaultMaxBodyBytes})
+		require.NoError(t, err)
+		require.Len(t, result.Content, 2)
+		image, ok := result.Content[1].(mcp.ImageContent)
+		require.True(t, ok)
+		assert.Equal(t, "image/png", image.MIMEType)
+	})
+
+	t.Run("非 JSON 响应无法提取", func(t *testing.T) {
+		resp := &helper.HttpResponse{StatusCode: 200, Header: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("plain")}
+		result, err := formatResponse(resp, ResponseOptions{Extract: "$.a", MaxBytes: defaultMaxBodyBytes})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,285 @@
+package httprequest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// sessionNamePattern 会话名称只允许字母、数字、下划线、点和横线，用作文件名
+var sessionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Session 命名会话，多次请求之间共享 Cookie
+type Session struct {
+	Name string
+	jar  *cookieJar
+
+	mu      sync.Mutex // 保护 updated，并串行化会话文件的写入
+	updated time.Time
+}
+
+// Updated 返回会话最近一次保存的时间
+func (sess *Session) Updated() time.Time {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.updated
+}
+
+// sessionStore 进程内的会话集合，配置了保存目录时会话会持久化到磁盘
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+var sessions = &sessionStore{sessions: make(map[string]*Session)}
+
+// getSessionDir 返回会话保存目录，为空表示不保存到磁盘，测试中可替换
+var getSessionDir = func() string {
+	cfg := helper.HttpConfig()
+	if !cfg.GetBool("session.persist") {
+		return ""
+	}
+	if dir := cfg.GetString("session.dir"); dir != "" {
+		return dir
+	}
+	return filepath.Join(helper.GetConfigDir("http"), "sessions")
+}
+
+// sessionFile 会话在磁盘上的文件格式
+type sessionFile struct {
+	Name    string          `json:"name"`
+	Updated time.Time       `json:"updated"`
+	Cookies []SessionCookie `json:"cookies"`
+}
+
+// Get 返回会话，不存在时从磁盘加载或新建
+func (s *sessionStore) Get(name string) (*Session, error) {
+	if !sessionNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("会话名称 %q 无效，只能包含字母、数字、下划线、点和横线", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[name]; ok {
+		return sess, nil
+	}
+
+	sess := &Session{Name: name, jar: newCookieJar()}
+	if dir := getSessionDir(); dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+		switch {
+		case err == nil:
+			var file sessionFile
+			if err := json.Unmarshal(data, &file); err != nil {
+				return nil, fmt.Errorf("读取会话 %s 失败: %w", name, err)
+			}
+			sess.jar.Load(file.Cookies)
+			sess.updated = file.Updated
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+	}
+	s.sessions[name] = sess
+	return sess, nil
+}
+
+// List 返回内存中和磁盘上的全部会话名称
+func (s *sessionStore) List() []string {
+	s.mu.Lock()
+	names := make(map[string]bool, len(s.sessions))
+	for name := range s.sessions {
+		names[name] = true
+	}
+	s.mu.Unlock()
+
+	if dir := getSessionDir(); dir != "" {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, f := range files {
+			names[strings.TrimSuffix(filepath.Base(f), ".json")] = true
+		}
+	}
+	return sortedKeys(names)
+}
+
+// Save 配置了保存目录时把会话写入磁盘
+func (s *sessionStore) Save(sess *Session) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.updated = time.Now()
+	dir := getSessionDir()
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sessionFile{Name: sess.Name, Updated: sess.updated, Cookies: sess.jar.All("")}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Cookie 中通常带有登录凭证，文件只允许当前用户读写
+	return os.WriteFile(filepath.Join(dir, sess.Name+".json"), data, 0o600)
+}
+
+// Delete 删除会话及其磁盘文件
+func (s *sessionStore) Delete(name string) error {
+	s.mu.Lock()
+	delete(s.sessions, name)
+	s.mu.Unlock()
+
+	if dir := getSessionDir(); dir != "" {
+		if err := os.Remove(filepath.Join(dir, name+".json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Client 返回使用会话 Cookie 的客户端，与 base 共享连接和策略检查
+func (sess *Session) Client(base *http.Client) *http.Client {
+	client := *base
+	client.Jar = sess.jar
+	return &client
+}
+
+// sessionToolOption 请求工具的 session 参数
+var sessionToolOption = mcp.WithString("session",
+	mcp.Description("会话名称，同一会话的请求之间自动保存和发送 Cookie（如先登录再访问受保护接口），不存在时自动创建"),
+)
+
+var httpSessionListTool = mcp.NewTool("http_session_list",
+	mcp.WithDescription("列出全部 HTTP 会话及其 Cookie 数量和涉及的域名"),
+)
+
+var httpSessionCookiesTool = mcp.NewTool("http_session_cookies",
+	mcp.WithDescription("查看 HTTP 会话中保存的 Cookie"),
+	mcp.WithString("session",
+		mcp.Required(),
+		mcp.Description("会话名称"),
+	),
+	mcp.WithString("domain",
+		mcp.Description("只显示该域名及其子域名的 Cookie"),
+	),
+)
+
+var httpSessionClearTool = mcp.NewTool("http_session_clear",
+	mcp.WithDescription("清除 HTTP 会话中的 Cookie；未指定 domain 时删除整个会话（包括磁盘上保存的文件）"),
+	mcp.WithString("session",
+		mcp.Required(),
+		mcp.Description("会话名称"),
+	),
+	mcp.WithString("domain",
+		mcp.Description("只清除该域名及其子域名的 Cookie"),
+	),
+)
+
+func httpSessionListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	names := sessions.List()
+	if len(names) == 0 {
+		return mcp.NewToolResultText("没有会话，调用 http_request 时传入 session 参数即可创建"), nil
+	}
+
+	var sb strings.Builder
+	for _, name := range names {
+		sess, err := sessions.Get(name)
+		if err != nil {
+			fmt.Fprintf(&sb, "%s: %v\n", name, err)
+			continue
+		}
+		cookies := sess.jar.All("")
+		domains := make(map[string]bool)
+		for _, c := range cookies {
+			domains[c.Domain] = true
+		}
+		fmt.Fprintf(&sb, "%s: %d cookies", name, len(cookies))
+		if len(domains) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(sortedKeys(domains), ", "))
+		}
+		if updated := sess.Updated(); !updated.IsZero() {
+			fmt.Fprintf(&sb, " updated %s", updated.Format(time.RFC3339))
+		}
+		sb.WriteString("\n")
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+func httpSessionCookiesHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	sess, err := sessions.Get(cast.ToString(args["session"]))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("读取会话失败", err), nil
+	}
+
+	cookies := sess.jar.All(cast.ToString(args["domain"]))
+	if len(cookies) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("会话 %s 中没有 Cookie", sess.Name)), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "会话 %s 共 %d 个 Cookie:\n", sess.Name, len(cookies))
+	for _, c := range cookies {
+		fmt.Fprintf(&sb, "%s=%s; Domain=%s; Path=%s", c.Name, c.Value, c.Domain, c.Path)
+		if c.Expires.IsZero() {
+			sb.WriteString("; Session")
+		} else {
+			fmt.Fprintf(&sb, "; Expires=%s", c.Expires.Format(time.RFC3339))
+		}
+		flags := []string{}
+		if c.HostOnly {
+			flags = append(flags, "HostOnly")
+		}
+		if c.Secure {
+			flags = append(flags, "Secure")
+		}
+		if c.HttpOnly {
+			flags = append(flags, "HttpOnly")
+		}
+		if c.SameSite != "" {
+			flags = append(flags, "SameSite="+c.SameSite)
+		}
+		sort.Strings(flags)
+		for _, f := range flags {
+			sb.WriteString("; " + f)
+		}
+		sb.WriteString("\n")
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+func httpSessionClearHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := cast.ToString(args["session"])
+	domain := cast.ToString(args["domain"])
+
+	sess, err := sessions.Get(name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("读取会话失败", err), nil
+	}
+
+	if domain == "" {
+		n := sess.jar.Clear("")
+		if err := sessions.Delete(name); err != nil {
+			return mcp.NewToolResultErrorFromErr("删除会话失败", err), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("已删除会话 %s（%d 个 Cookie）", name, n)), nil
+	}
+
+	n := sess.jar.Clear(domain)
+	if err := sessions.Save(sess); err != nil {
+		return mcp.NewToolResultErrorFromErr("保存会话失败", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已清除会话 %s 中 %s 的 %d 个 Cookie", name, domain, n)), nil
+}
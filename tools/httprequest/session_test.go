@@ -0,0 +1,197 @@
+package httprequest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetSessions 在测试期间使用独立的会话集合和保存目录
+func resetSessions(t *testing.T, dir string) {
+	t.Helper()
+	origSessions, origDir := sessions, getSessionDir
+	sessions = &sessionStore{sessions: make(map[string]*Session)}
+	getSessionDir = func() string { return dir }
+	t.Cleanup(func() {
+		sessions, getSessionDir = origSessions, origDir
+	})
+}
+
+// newLoginServer 返回一个 /login 设置 Cookie、/me 校验 Cookie 的测试服务
+func newLoginServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc123", Path: "/", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "pref", Value: "dark", Path: "/settings", MaxAge: 3600})
+			http.Redirect(w, r, "/me", http.StatusFound)
+		case "/logout":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "", Path: "/", MaxAge: -1})
+		case "/me":
+			if c, err := r.Cookie("sid"); err == nil && c.Value == "abc123" {
+				w.Write([]byte("hello user"))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+}
+
+func TestHttpHandler_Session(t *testing.T) {
+	dir := t.TempDir()
+	resetSessions(t, dir)
+	srv := newLoginServer()
+	defer srv.Close()
+
+	call := func(path, session string) string {
+		result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+			"method":  "GET",
+			"url":     srv.URL + path,
+			"session": session,
+		}))
+		require.NoError(t, err)
+		return resultText(t, result)
+	}
+
+	// 登录响应中的 Cookie 在重定向时即生效
+	assert.Contains(t, call("/login", "alice"), "hello user")
+	assert.Contains(t, call("/me", "alice"), "hello user")
+	assert.Contains(t, call("/me", "bob"), "Status: 401", "不同会话的 Cookie 互不影响")
+	assert.Contains(t, call("/me", ""), "Status: 401", "未指定会话时不携带 Cookie")
+
+	// 会话保存到磁盘，重新加载后仍然有效
+	info, err := os.Stat(filepath.Join(dir, "alice.json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	resetSessions(t, dir)
+	assert.Contains(t, call("/me", "alice"), "hello user")
+
+	call("/logout", "alice")
+	assert.Contains(t, call("/me", "alice"), "Status: 401")
+}
+
+func TestHttpHandler_SessionConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	resetSessions(t, dir)
+	srv := newLoginServer()
+	defer srv.Close()
+
+	// 同一会话的并发请求和会话列表同时读写保存时间，在 -race 下检查数据竞争
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+				"method":  "GET",
+				"url":     srv.URL + "/login",
+				"session": "shared",
+			}))
+			assert.NoError(t, err)
+			assert.False(t, result.IsError)
+		}()
+		go func() {
+			defer wg.Done()
+			httpSessionListHandler(context.Background(), newCallToolRequest("http_session_list", nil))
+		}()
+	}
+	wg.Wait()
+
+	sess, err := sessions.Get("shared")
+	require.NoError(t, err)
+	assert.False(t, sess.Updated().IsZero())
+	data, err := os.ReadFile(filepath.Join(dir, "shared.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"name": "sid"`)
+}
+
+func TestHttpSessionTools(t *testing.T) {
+	resetSessions(t, t.TempDir())
+	srv := newLoginServer()
+	defer srv.Close()
+
+	_, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+		"method":  "GET",
+		"url":     srv.URL + "/login",
+		"session": "alice",
+	}))
+	require.NoError(t, err)
+
+	result, err := httpSessionListHandler(context.Background(), newCallToolRequest("http_session_list", nil))
+	require.NoError(t, err)
+	assert.Contains(t, resultText(t, result), "alice: 2 cookies [127.0.0.1]")
+
+	result, err = httpSessionCookiesHandler(context.Background(), newCallToolRequest("http_session_cookies", map[string]any{"session": "alice"}))
+	require.NoError(t, err)
+	text := resultText(t, result)
+	assert.Contains(t, text, "sid=abc123; Domain=127.0.0.1; Path=/; Session; HostOnly; HttpOnly")
+	assert.Contains(t, text, "pref=dark; Domain=127.0.0.1; Path=/settings; Expires=")
+
+	result, err = httpSessionClearHandler(context.Background(), newCallToolRequest("http_session_clear", map[string]any{"session": "alice", "domain": "example.com"}))
+	require.NoError(t, err)
+	assert.Contains(t, resultText(t, result), "0 个 Cookie")
+
+	result, err = httpSessionClearHandler(context.Background(), newCallToolRequest("http_session_clear", map[string]any{"session": "alice"}))
+	require.NoError(t, err)
+	assert.Equal(t, "已删除会话 alice（2 个 Cookie）", resultText(t, result))
+
+	result, err = httpSessionListHandler(context.Background(), newCallToolRequest("http_session_list", nil))
+	require.NoError(t, err)
+	assert.NotContains(t, resultText(t, result), "alice")
+
+	result, err = httpSessionCookiesHandler(context.Background(), newCallToolRequest("http_session_cookies", map[string]any{"session": "../etc"}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestCookieJar(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jar := newCookieJar()
+	jar.now = func() time.Time { return now }
+
+	mustURL := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		require.NoError(t, err)
+		return u
+	}
+	names := func(u string) []string {
+		var out []string
+		for _, c := range jar.Cookies(mustURL(u)) {
+			out = append(out, c.Name)
+		}
+		return out
+	}
+
+	jar.SetCookies(mustURL("https://api.example.com/v1/login"), []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "secure", Value: "3", Path: "/", Secure: true},
+		{Name: "short", Value: "4", Path: "/", MaxAge: 60},
+		{Name: "public", Value: "5", Domain: "com"},
+		{Name: "other", Value: "6", Domain: "other.com"},
+	})
+
+	assert.Equal(t, []string{"host", "domain", "secure", "short"}, names("https://api.example.com/v1/items"))
+	assert.Equal(t, []string{"domain", "short"}, names("http://api.example.com/"), "Secure Cookie 只通过 https 发送，默认路径为 /v1")
+	assert.Equal(t, []string{"domain"}, names("https://www.example.com/"), "HostOnly Cookie 不发送给其他子域名")
+	assert.Empty(t, names("https://other.com/"))
+	assert.Empty(t, names("https://api.example.com.evil.com/"))
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, []string{"domain", "secure"}, names("https://api.example.com/"), "过期的 Cookie 不再发送")
+
+	assert.Equal(t, 2, jar.Clear("api.example.com"))
+	assert.Len(t, jar.All(""), 1)
+	assert.Equal(t, 1, jar.Clear(".example.com"))
+	assert.Empty(t, jar.All(""))
+}
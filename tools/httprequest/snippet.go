@@ -0,0 +1,449 @@
+package httprequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// snippetTargets 支持生成的代码类型，按输出顺序排列
+var snippetTargets = []string{"curl", "go", "python", "fetch", "http"}
+
+var httpSnippetTool = func() mcp.Tool {
+	tool := withRequestParams(mcp.NewTool("http_snippet",
+		mcp.WithDescription(`把 http_request 的参数或一条请求历史转换为可直接粘贴使用的代码：curl 命令、Go net/http、Python requests、JavaScript fetch 和 .http 文件区块。
+- 指定 id 时使用历史记录中的请求，否则使用 method、url、headers、body 等参数（与 http_request 相同）；
+- 只生成代码，不会发送请求；
+- 使用 profile 时认证信息以 {{<profile>_token}} 这样的占位符代替，不会输出配置中的密钥。`),
+		mcp.WithNumber("id",
+			mcp.Description("历史记录编号（见 http_history_list），指定时忽略请求参数"),
+		),
+		mcp.WithArray("targets",
+			mcp.Description("生成的代码类型，默认全部"),
+			mcp.Items(map[string]any{"type": "string", "enum": snippetTargets}),
+		),
+	))
+	// 指定 id 时不需要 method 和 url
+	tool.InputSchema.Required = nil
+	return tool
+}()
+
+func httpSnippetHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	var req *Request
+	if id := cast.ToInt(args["id"]); id > 0 {
+		e, ok := getHistory().Get(id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("历史记录 #%d 不存在", id)), nil
+		}
+		if e.RequestTruncated {
+			return mcp.NewToolResultError(fmt.Sprintf("历史记录 #%d 的请求体超过保存上限，无法生成代码", id)), nil
+		}
+		req = e.Request.Clone()
+	} else {
+		if cast.ToString(args["url"]) == "" {
+			return mcp.NewToolResultError("需要提供 id 或 url"), nil
+		}
+		var err error
+		if req, err = buildRequest(args); err != nil {
+			return mcp.NewToolResultErrorFromErr("解析请求参数失败", err), nil
+		}
+		if req.Method == "" {
+			req.Method = http.MethodGet
+		}
+	}
+
+	targets := cast.ToStringSlice(args["targets"])
+	if len(targets) == 0 {
+		targets = snippetTargets
+	}
+	for _, target := range targets {
+		if _, ok := snippetGenerators[target]; !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("不支持的代码类型 %s，可选: %s", target, strings.Join(snippetTargets, ", "))), nil
+		}
+	}
+
+	req, notes, err := applyProfilePlaceholders(req)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("生成认证占位符失败", err), nil
+	}
+	if req.Session != "" {
+		notes = append(notes, fmt.Sprintf("会话 %s 中的 Cookie 没有包含在代码中", req.Session))
+	}
+
+	var sb strings.Builder
+	if len(notes) > 0 {
+		sb.WriteString("Notes:\n")
+		for _, note := range notes {
+			fmt.Fprintf(&sb, "  %s\n", note)
+		}
+		sb.WriteString("\n")
+	}
+	for i, target := range targets {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		gen := snippetGenerators[target]
+		fmt.Fprintf(&sb, "## %s\n```%s\n%s\n```\n", target, gen.lang, strings.TrimRight(gen.fn(req), "\n"))
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+// snippetGenerators 各代码类型的代码块语言和生成函数
+var snippetGenerators = map[string]struct {
+	lang string
+	fn   func(req *Request) string
+}{
+	"curl":   {"bash", curlSnippet},
+	"go":     {"go", goSnippet},
+	"python": {"python", pythonSnippet},
+	"fetch":  {"javascript", fetchSnippet},
+	"http":   {"http", httpFileSnippet},
+}
+
+// placeholderPattern 占位符名称中不允许的字符
+var placeholderPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// applyProfilePlaceholders 按认证配置的类型把认证信息写成 {{<profile>_<field>}} 占位符，返回新请求和占位符说明
+func applyProfilePlaceholders(req *Request) (*Request, []string, error) {
+	if req.Profile == "" {
+		return req, nil, nil
+	}
+
+	profile, err := lookupAuthProfile(req.Profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := req.Clone()
+	out.Profile = ""
+	prefix := strings.Trim(placeholderPattern.ReplaceAllString(strings.ToLower(req.Profile), "_"), "_")
+	var notes []string
+	placeholder := func(field, desc string) string {
+		name := "{{" + prefix + "_" + field + "}}"
+		notes = append(notes, fmt.Sprintf("%s: 认证配置 %s 的%s", name, req.Profile, desc))
+		return name
+	}
+
+	switch strings.ToLower(profile.Type) {
+	case "bearer":
+		out.Header.Set("Authorization", "Bearer "+placeholder("token", "令牌"))
+	case "basic":
+		out.Header.Set("Authorization", "Basic "+placeholder("basic", " base64(用户名:密码)"))
+	case "apikey":
+		if profile.Name == "" {
+			return nil, nil, fmt.Errorf("认证配置 %s 缺少 name", req.Profile)
+		}
+		value := placeholder("key", " API Key")
+		if !strings.EqualFold(profile.In, "query") {
+			out.Header.Set(profile.Name, value)
+			break
+		}
+		// 占位符不做 URL 编码，便于直接替换
+		u, err := url.Parse(out.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		u.RawQuery = setRawQuery(u.RawQuery, profile.Name, value)
+		out.URL = u.String()
+	case "hmac":
+		if profile.KeyID != "" {
+			out.Header.Set(headerOr(profile.KeyIDHeader, "X-Key-Id"), placeholder("key_id", "密钥标识"))
+		}
+		out.Header.Set(headerOr(profile.TimestampHeader, "X-Timestamp"), placeholder("timestamp", "签名时间（Unix 秒）"))
+		algorithm := strings.ToUpper(profile.Algorithm)
+		if algorithm == "" {
+			algorithm = "SHA256"
+		}
+		out.Header.Set(headerOr(profile.SignatureHeader, "X-Signature"), placeholder("signature",
+			fmt.Sprintf(` HMAC-%s 签名，十六进制编码的 HMAC(secret, "METHOD\nPATH?QUERY\nTIMESTAMP\nSHA256(BODY)")`, algorithm)))
+	case "oauth2":
+		out.Header.Set("Authorization", "Bearer "+placeholder("access_token", fmt.Sprintf("访问令牌，通过 client_credentials 从 %s 获取", profile.TokenURL)))
+	default:
+		return nil, nil, fmt.Errorf("认证配置 %s 的类型 %q 不支持", req.Profile, profile.Type)
+	}
+	return out, notes, nil
+}
+
+// snippetHeaders 返回按名称排序的请求头，multipart 请求去掉 Content-Type，由各语言的库生成带 boundary 的值
+func snippetHeaders(req *Request) [][2]string {
+	var headers [][2]string
+	for _, k := range sortedKeys(req.Header) {
+		if len(req.Parts) > 0 && strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		for _, v := range req.Header[k] {
+			headers = append(headers, [2]string{k, v})
+		}
+	}
+	return headers
+}
+
+// joinedHeaders 返回合并同名值后的请求头，用于只能为每个名称设置一个值的库
+func joinedHeaders(req *Request) [][2]string {
+	var headers [][2]string
+	for _, h := range snippetHeaders(req) {
+		if n := len(headers); n > 0 && headers[n-1][0] == h[0] {
+			headers[n-1][1] += ", " + h[1]
+			continue
+		}
+		headers = append(headers, h)
+	}
+	return headers
+}
+
+// shellQuote 使用单引号引用 shell 参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jsString 返回 JSON 格式的字符串字面量，同时是合法的 JavaScript 和 Python 字符串
+func jsString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// goString 返回 Go 字符串字面量，多行或含引号的内容优先使用反引号
+func goString(s string) string {
+	if strings.ContainsAny(s, "\n\"") && !strings.ContainsAny(s, "`\r") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+func curlSnippet(req *Request) string {
+	var sb strings.Builder
+	sb.WriteString("curl")
+	switch {
+	case req.Method == http.MethodHead:
+		sb.WriteString(" -I")
+	case req.Method == http.MethodGet && req.Body == "" && len(req.Parts) == 0:
+	case req.Method == http.MethodPost && (req.Body != "" || len(req.Parts) > 0):
+	default:
+		sb.WriteString(" -X " + req.Method)
+	}
+	sb.WriteString(" " + shellQuote(req.URL))
+
+	for _, h := range snippetHeaders(req) {
+		sb.WriteString(" \\\n  -H " + shellQuote(h[0]+": "+h[1]))
+	}
+	for _, p := range req.Parts {
+		if p.FilePath == "" {
+			// --form-string 不会把以 @ 或 < 开头的值当作文件
+			sb.WriteString(" \\\n  --form-string " + shellQuote(p.Name+"="+p.Value))
+			continue
+		}
+		fileName, contentType := p.fileInfo()
+		sb.WriteString(" \\\n  -F " + shellQuote(fmt.Sprintf("%s=@%s;filename=%s;type=%s", p.Name, p.FilePath, fileName, contentType)))
+	}
+	if req.Body != "" && len(req.Parts) == 0 {
+		sb.WriteString(" \\\n  --data-raw " + shellQuote(req.Body))
+	}
+	return sb.String()
+}
+
+func goSnippet(req *Request) string {
+	imports := []string{"fmt", "io", "net/http"}
+	var body strings.Builder
+	bodyArg := "nil"
+	switch {
+	case len(req.Parts) > 0:
+		imports = []string{"bytes", "fmt", "io", "mime/multipart", "net/http", "net/textproto", "os"}
+		bodyArg = "&buf"
+		body.WriteString("\tvar buf bytes.Buffer\n\tmw := multipart.NewWriter(&buf)\n")
+		for _, p := range req.Parts {
+			if p.FilePath == "" {
+				fmt.Fprintf(&body, "\tif err := mw.WriteField(%s, %s); err != nil {\n\t\tpanic(err)\n\t}\n", strconv.Quote(p.Name), goString(p.Value))
+				continue
+			}
+			fileName, contentType := p.fileInfo()
+			disposition := fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(p.Name), quoteEscaper.Replace(fileName))
+			fmt.Fprintf(&body, `	{
+		f, err := os.Open(%s)
+		if err != nil {
+			panic(err)
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", %s)
+		h.Set("Content-Type", %s)
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := io.Copy(part, f); err != nil {
+			panic(err)
+		}
+		f.Close()
+	}
+`, strconv.Quote(p.FilePath), goString(disposition), strconv.Quote(contentType))
+		}
+		body.WriteString("\tif err := mw.Close(); err != nil {\n\t\tpanic(err)\n\t}\n\n")
+	case req.Body != "":
+		imports = []string{"fmt", "io", "net/http", "strings"}
+		bodyArg = "body"
+		fmt.Fprintf(&body, "\tbody := strings.NewReader(%s)\n", goString(req.Body))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("package main\n\nimport (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&sb, "\t%q\n", imp)
+	}
+	sb.WriteString(")\n\nfunc main() {\n")
+	sb.WriteString(body.String())
+	fmt.Fprintf(&sb, "\treq, err := http.NewRequest(%s, %s, %s)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n",
+		strconv.Quote(req.Method), strconv.Quote(req.URL), bodyArg)
+	for _, h := range snippetHeaders(req) {
+		fmt.Fprintf(&sb, "\treq.Header.Add(%s, %s)\n", strconv.Quote(h[0]), strconv.Quote(h[1]))
+	}
+	if len(req.Parts) > 0 {
+		sb.WriteString("\treq.Header.Set(\"Content-Type\", mw.FormDataContentType())\n")
+	}
+	sb.WriteString(`
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(resp.Status)
+	fmt.Println(string(data))
+}
+`)
+	return sb.String()
+}
+
+func pythonSnippet(req *Request) string {
+	var sb strings.Builder
+	sb.WriteString("import requests\n\n")
+	fmt.Fprintf(&sb, "url = %s\n", jsString(req.URL))
+
+	args := []string{jsString(req.Method), "url"}
+	if headers := joinedHeaders(req); len(headers) > 0 {
+		sb.WriteString("headers = {\n")
+		for _, h := range headers {
+			fmt.Fprintf(&sb, "    %s: %s,\n", jsString(h[0]), jsString(h[1]))
+		}
+		sb.WriteString("}\n")
+		args = append(args, "headers=headers")
+	}
+	switch {
+	case len(req.Parts) > 0:
+		// 文本字段写成 (None, value)，requests 会把它作为普通表单字段发送
+		sb.WriteString("files = [\n")
+		for _, p := range req.Parts {
+			if p.FilePath == "" {
+				fmt.Fprintf(&sb, "    (%s, (None, %s)),\n", jsString(p.Name), jsString(p.Value))
+				continue
+			}
+			fileName, contentType := p.fileInfo()
+			fmt.Fprintf(&sb, "    (%s, (%s, open(%s, \"rb\"), %s)),\n", jsString(p.Name), jsString(fileName), jsString(p.FilePath), jsString(contentType))
+		}
+		sb.WriteString("]\n")
+		args = append(args, "files=files")
+	case req.Body != "":
+		fmt.Fprintf(&sb, "data = %s\n", jsString(req.Body))
+		args = append(args, "data=data.encode(\"utf-8\")")
+	}
+
+	fmt.Fprintf(&sb, "\nresponse = requests.request(%s)\n", strings.Join(args, ", "))
+	sb.WriteString("print(response.status_code)\nprint(response.text)\n")
+	return sb.String()
+}
+
+func fetchSnippet(req *Request) string {
+	var sb strings.Builder
+	if len(req.Parts) > 0 {
+		for _, p := range req.Parts {
+			if p.FilePath != "" {
+				sb.WriteString("import { openAsBlob } from \"node:fs\";\n\n")
+				break
+			}
+		}
+		sb.WriteString("const form = new FormData();\n")
+		for _, p := range req.Parts {
+			if p.FilePath == "" {
+				fmt.Fprintf(&sb, "form.append(%s, %s);\n", jsString(p.Name), jsString(p.Value))
+				continue
+			}
+			fileName, contentType := p.fileInfo()
+			fmt.Fprintf(&sb, "form.append(%s, await openAsBlob(%s, { type: %s }), %s);\n",
+				jsString(p.Name), jsString(p.FilePath), jsString(contentType), jsString(fileName))
+		}
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "const response = await fetch(%s, {\n", jsString(req.URL))
+	fmt.Fprintf(&sb, "  method: %s,\n", jsString(req.Method))
+	if headers := joinedHeaders(req); len(headers) > 0 {
+		sb.WriteString("  headers: {\n")
+		for _, h := range headers {
+			fmt.Fprintf(&sb, "    %s: %s,\n", jsString(h[0]), jsString(h[1]))
+		}
+		sb.WriteString("  },\n")
+	}
+	switch {
+	case len(req.Parts) > 0:
+		sb.WriteString("  body: form,\n")
+	case req.Body != "":
+		fmt.Fprintf(&sb, "  body: %s,\n", jsString(req.Body))
+	}
+	sb.WriteString("});\n")
+	sb.WriteString("console.log(response.status);\nconsole.log(await response.text());\n")
+	return sb.String()
+}
+
+// httpFileSnippet 生成 .http 文件区块，multipart 文件字段使用 "< 路径" 引用本地文件
+func httpFileSnippet(req *Request) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s %s\n%s %s\n", req.Method, snippetPath(req.URL), req.Method, req.URL)
+	for _, h := range snippetHeaders(req) {
+		fmt.Fprintf(&sb, "%s: %s\n", h[0], h[1])
+	}
+
+	switch {
+	case len(req.Parts) > 0:
+		const boundary = "boundary"
+		fmt.Fprintf(&sb, "Content-Type: multipart/form-data; boundary=%s\n\n", boundary)
+		for _, p := range req.Parts {
+			fmt.Fprintf(&sb, "--%s\n", boundary)
+			if p.FilePath == "" {
+				fmt.Fprintf(&sb, "Content-Disposition: form-data; name=\"%s\"\n\n%s\n", quoteEscaper.Replace(p.Name), p.Value)
+				continue
+			}
+			fileName, contentType := p.fileInfo()
+			fmt.Fprintf(&sb, "Content-Disposition: form-data; name=\"%s\"; filename=\"%s\"\nContent-Type: %s\n\n< %s\n",
+				quoteEscaper.Replace(p.Name), quoteEscaper.Replace(fileName), contentType, p.FilePath)
+		}
+		fmt.Fprintf(&sb, "--%s--\n", boundary)
+	case req.Body != "":
+		fmt.Fprintf(&sb, "\n%s\n", req.Body)
+	}
+	return sb.String()
+}
+
+// snippetPath 返回 .http 区块标题中显示的路径
+func snippetPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
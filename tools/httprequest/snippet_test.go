@@ -0,0 +1,105 @@
+package httprequest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpSnippetHandler(t *testing.T) {
+	setAuthProfiles(t, map[string]*AuthProfile{
+		"api":   {Type: "bearer", Token: "real-secret", Hosts: []string{"api.example.com"}},
+		"query": {Type: "apikey", Name: "api_key", Value: "real-key", In: "query", Hosts: []string{"api.example.com"}},
+	})
+
+	call := func(t *testing.T, args map[string]any) (string, bool) {
+		t.Helper()
+		result, err := httpSnippetHandler(context.Background(), newCallToolRequest("http_snippet", args))
+		require.NoError(t, err)
+		return resultText(t, result), result.IsError
+	}
+
+	t.Run("JSON 请求生成全部代码", func(t *testing.T) {
+		text, isErr := call(t, map[string]any{
+			"method":  "POST",
+			"url":     "https://api.example.com/items",
+			"headers": map[string]any{"X-Tag": []any{"a", "b"}},
+			"json":    map[string]any{"name": "it's"},
+			"profile": "api",
+		})
+		require.False(t, isErr, text)
+		assert.NotContains(t, text, "real-secret")
+		assert.Contains(t, text, "Notes:\n  {{api_token}}: 认证配置 api 的令牌\n")
+
+		assert.Contains(t, text, "## curl\n```bash\ncurl 'https://api.example.com/items' \\\n"+
+			"  -H 'Authorization: Bearer {{api_token}}' \\\n"+
+			"  -H 'Content-Type: application/json' \\\n"+
+			"  -H 'X-Tag: a' \\\n"+
+			"  -H 'X-Tag: b' \\\n"+
+			"  --data-raw '{\"name\":\"it'\\''s\"}'\n```")
+		assert.Contains(t, text, "\tbody := strings.NewReader(`{\"name\":\"it's\"}`)\n")
+		assert.Contains(t, text, "\treq.Header.Add(\"X-Tag\", \"a\")\n\treq.Header.Add(\"X-Tag\", \"b\")\n")
+		assert.Contains(t, text, "    \"X-Tag\": \"a, b\",\n")
+		assert.Contains(t, text, "data = \"{\\\"name\\\":\\\"it's\\\"}\"\n")
+		assert.Contains(t, text, "response = requests.request(\"POST\", url, headers=headers, data=data.encode(\"utf-8\"))")
+		assert.Contains(t, text, "const response = await fetch(\"https://api.example.com/items\", {\n  method: \"POST\",\n")
+		assert.Contains(t, text, "## http\n```http\n### POST /items\nPOST https://api.example.com/items\n"+
+			"Authorization: Bearer {{api_token}}\nContent-Type: application/json\nX-Tag: a\nX-Tag: b\n\n{\"name\":\"it's\"}\n```")
+	})
+
+	t.Run("查询参数认证和 multipart", func(t *testing.T) {
+		text, isErr := call(t, map[string]any{
+			"method":  "PUT",
+			"url":     "https://api.example.com/upload?x=1&sig=a%2Fb&a=1",
+			"profile": "query",
+			"targets": []any{"curl", "python"},
+			"multipart": []any{
+				map[string]any{"name": "title", "value": "@demo"},
+				map[string]any{"name": "file", "path": "/tmp/a.png"},
+			},
+		})
+		require.False(t, isErr, text)
+		assert.NotContains(t, text, "real-key")
+		assert.Contains(t, text, "curl -X PUT 'https://api.example.com/upload?x=1&sig=a%2Fb&a=1&api_key={{query_key}}' \\\n"+
+			"  --form-string 'title=@demo' \\\n"+
+			"  -F 'file=@/tmp/a.png;filename=a.png;type=image/png'")
+		assert.Contains(t, text, "    (\"title\", (None, \"@demo\")),\n    (\"file\", (\"a.png\", open(\"/tmp/a.png\", \"rb\"), \"image/png\")),\n")
+		assert.NotContains(t, text, "## go")
+	})
+
+	t.Run("从历史记录生成", func(t *testing.T) {
+		store := resetHistory(t, HistoryConfig{})
+		store.Record(&Request{Method: "GET", URL: "https://api.example.com/items", Header: http.Header{"Accept": {"application/json"}}, Session: "dev"}, nil, errors.New("refused"), time.Now(), 0)
+
+		text, isErr := call(t, map[string]any{"id": 1, "targets": []any{"curl", "fetch"}})
+		require.False(t, isErr, text)
+		assert.Equal(t, "Notes:\n  会话 dev 中的 Cookie 没有包含在代码中\n\n"+
+			"## curl\n```bash\ncurl 'https://api.example.com/items' \\\n  -H 'Accept: application/json'\n```\n\n"+
+			"## fetch\n```javascript\nconst response = await fetch(\"https://api.example.com/items\", {\n"+
+			"  method: \"GET\",\n  headers: {\n    \"Accept\": \"application/json\",\n  },\n});\n"+
+			"console.log(response.status);\nconsole.log(await response.text());\n```", text)
+
+		text, isErr = call(t, map[string]any{"id": 9})
+		assert.True(t, isErr)
+		assert.Equal(t, "历史记录 #9 不存在", text)
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		text, isErr := call(t, nil)
+		assert.True(t, isErr)
+		assert.Equal(t, "需要提供 id 或 url", text)
+
+		text, isErr = call(t, map[string]any{"url": "https://api.example.com", "targets": []any{"ruby"}})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "不支持的代码类型 ruby")
+
+		text, isErr = call(t, map[string]any{"url": "https://api.example.com", "profile": "missing"})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "认证配置 missing 不存在")
+	})
+}
@@ -0,0 +1,332 @@
+package httprequest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// 流式读取的默认限制
+const (
+	defaultStreamMaxEvents = 20
+	defaultStreamMaxBytes  = 64 * 1024
+	defaultStreamDuration  = 10 * time.Second
+	maxStreamDuration      = 5 * time.Minute
+)
+
+// StreamOptions 控制流式响应的读取方式，任一限制达到即停止读取
+type StreamOptions struct {
+	Mode      string        // auto | sse | chunks，auto 时按 Content-Type 判断是否为 SSE
+	MaxEvents int           // 最多读取的事件或分块数
+	MaxBytes  int           // 最多读取的字节数
+	Duration  time.Duration // 最长读取时间
+}
+
+// StreamEvent 流式响应中的一个 SSE 事件或数据分块
+type StreamEvent struct {
+	Elapsed time.Duration // 距离请求开始的时间
+	Event   string        // SSE 事件类型
+	ID      string        // SSE 事件 ID
+	Retry   string        // SSE 重连间隔
+	Data    string        // SSE 的 data（多行以换行连接）或分块内容
+}
+
+// StreamResult 流式读取结果
+type StreamResult struct {
+	StatusCode int
+	Header     http.Header
+	Mode       string
+	Events     []StreamEvent
+	Bytes      int
+	Elapsed    time.Duration
+	StopReason string
+	raw        []byte
+}
+
+// sseParser 按 https://html.spec.whatwg.org/multipage/server-sent-events.html 解析事件流
+type sseParser struct {
+	event, id, retry string
+	data             []string
+	hasData          bool
+}
+
+// feed 处理一行（不含行尾），遇到空行时返回完整事件
+func (p *sseParser) feed(line string) (StreamEvent, bool) {
+	if line == "" {
+		if !p.hasData {
+			p.event = ""
+			return StreamEvent{}, false
+		}
+		ev := StreamEvent{Event: p.event, ID: p.id, Retry: p.retry, Data: strings.Join(p.data, "\n")}
+		if ev.Event == "" {
+			ev.Event = "message"
+		}
+		// id 在事件之间保留，其余字段每个事件重新开始
+		p.event, p.retry, p.data, p.hasData = "", "", nil, false
+		return ev, true
+	}
+	if strings.HasPrefix(line, ":") {
+		return StreamEvent{}, false
+	}
+
+	field, value, _ := strings.Cut(line, ":")
+	value = strings.TrimPrefix(value, " ")
+	switch field {
+	case "event":
+		p.event = value
+	case "data":
+		p.data = append(p.data, value)
+		p.hasData = true
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			p.id = value
+		}
+	case "retry":
+		p.retry = value
+	}
+	return StreamEvent{}, false
+}
+
+// readSSELine 读取一行，行尾可以是 \n、\r\n 或 \r
+func readSSELine(r *bufio.Reader) (string, int, error) {
+	var sb strings.Builder
+	n := 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return sb.String(), n, err
+		}
+		n++
+		switch b {
+		case '\n':
+			return sb.String(), n, nil
+		case '\r':
+			if next, err := r.Peek(1); err == nil && next[0] == '\n' {
+				r.ReadByte()
+				n++
+			}
+			return sb.String(), n, nil
+		}
+		sb.WriteByte(b)
+	}
+}
+
+// readStream 按选项读取响应体，onEvent 在每个事件后调用
+//
+// 时长限制由请求的上下文控制，超时后阻塞的读取会返回错误。
+func readStream(resp *http.Response, opts StreamOptions, start time.Time, onEvent func(*StreamResult)) *StreamResult {
+	result := &StreamResult{StatusCode: resp.StatusCode, Header: resp.Header, Mode: opts.Mode}
+	if result.Mode == "" || result.Mode == "auto" {
+		result.Mode = "chunks"
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+			result.Mode = "sse"
+		}
+	}
+
+	add := func(ev StreamEvent) bool {
+		ev.Elapsed = time.Since(start)
+		result.Events = append(result.Events, ev)
+		onEvent(result)
+		return len(result.Events) < opts.MaxEvents
+	}
+	stop := func(err error) {
+		switch {
+		case time.Since(start) >= opts.Duration:
+			result.StopReason = fmt.Sprintf("duration limit %s reached", opts.Duration)
+		case err == io.EOF:
+			result.StopReason = "stream closed by server"
+		case err != nil:
+			result.StopReason = "read error: " + helper.RedactError(err).Error()
+		}
+	}
+
+	limited := io.LimitReader(resp.Body, int64(opts.MaxBytes)+1)
+	if result.Mode == "sse" {
+		r := bufio.NewReader(limited)
+		var parser sseParser
+		for {
+			line, n, err := readSSELine(r)
+			result.Bytes += n
+			if result.Bytes > opts.MaxBytes {
+				result.Bytes = opts.MaxBytes
+				result.StopReason = fmt.Sprintf("byte limit %d reached", opts.MaxBytes)
+				break
+			}
+			if err != nil {
+				// 流结束时分发最后一个未以空行结束的事件
+				if err == io.EOF && line != "" {
+					parser.feed(line)
+				}
+				if ev, ok := parser.feed(""); ok && err == io.EOF {
+					add(ev)
+				}
+				stop(err)
+				break
+			}
+			if ev, ok := parser.feed(line); ok && !add(ev) {
+				result.StopReason = fmt.Sprintf("event limit %d reached", opts.MaxEvents)
+				break
+			}
+		}
+	} else {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := limited.Read(buf)
+			if n > 0 {
+				chunk := buf[:n]
+				if result.Bytes+n > opts.MaxBytes {
+					chunk = chunk[:opts.MaxBytes-result.Bytes]
+				}
+				result.Bytes += len(chunk)
+				result.raw = append(result.raw, chunk...)
+				if len(chunk) > 0 && !add(StreamEvent{Data: string(chunk)}) {
+					result.StopReason = fmt.Sprintf("chunk limit %d reached", opts.MaxEvents)
+					break
+				}
+				if len(chunk) < n {
+					result.StopReason = fmt.Sprintf("byte limit %d reached", opts.MaxBytes)
+					break
+				}
+			}
+			if err != nil {
+				stop(err)
+				break
+			}
+		}
+	}
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+// streamOptionsFromArgs 从工具参数中读取流式读取选项
+func streamOptionsFromArgs(args map[string]any) StreamOptions {
+	opts := StreamOptions{
+		Mode:      cast.ToString(args["mode"]),
+		MaxEvents: cast.ToInt(args["maxEvents"]),
+		MaxBytes:  cast.ToInt(args["maxBytes"]),
+		Duration:  time.Duration(cast.ToFloat64(args["durationSeconds"]) * float64(time.Second)),
+	}
+	if opts.MaxEvents <= 0 {
+		opts.MaxEvents = defaultStreamMaxEvents
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultStreamMaxBytes
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = defaultStreamDuration
+	}
+	opts.Duration = min(opts.Duration, maxStreamDuration)
+	return opts
+}
+
+// sendStream 发送请求并流式读取响应，读取的内容记录到请求历史
+func sendStream(ctx context.Context, req *Request, opts StreamOptions, onEvent func(*StreamResult)) (*StreamResult, error) {
+	start := time.Now()
+	// 时长限制从发送请求开始计算，包括等待响应头的时间
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var result *StreamResult
+	err := do(ctx, req, func(client *http.Client, signed *Request, body io.Reader) error {
+		// 流式读取由 Duration 控制时长，不受客户端整体超时限制
+		c := *client
+		c.Timeout = 0
+		resp, err := helper.OpenHttpStream(ctx, &c, signed.Method, signed.URL, signed.Header, body)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("在 %s 内没有收到响应头: %w", opts.Duration, err)
+		}
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		result = readStream(resp, opts, start, onEvent)
+		return nil
+	})
+
+	var resp *helper.HttpResponse
+	if result != nil {
+		raw := result.raw
+		if result.Mode == "sse" {
+			raw = []byte(formatStreamEvents(result))
+		}
+		resp = &helper.HttpResponse{StatusCode: result.StatusCode, Header: result.Header, Body: raw}
+	}
+	getHistory().Record(req, resp, err, start, time.Since(start))
+	return result, err
+}
+
+// formatStreamEvents 每个事件一段，SSE 事件带上事件类型和 ID
+func formatStreamEvents(result *StreamResult) string {
+	var sb strings.Builder
+	for i, ev := range result.Events {
+		fmt.Fprintf(&sb, "[%d] +%.3fs", i+1, ev.Elapsed.Seconds())
+		if result.Mode == "sse" {
+			fmt.Fprintf(&sb, " event=%s", ev.Event)
+			if ev.ID != "" {
+				fmt.Fprintf(&sb, " id=%s", ev.ID)
+			}
+			if ev.Retry != "" {
+				fmt.Fprintf(&sb, " retry=%s", ev.Retry)
+			}
+		} else {
+			fmt.Fprintf(&sb, " %d bytes", len(ev.Data))
+		}
+		fmt.Fprintf(&sb, "\n%s\n", ev.Data)
+	}
+	return sb.String()
+}
+
+var httpStreamTool = withRequestParams(mcp.NewTool("http_stream",
+	mcp.WithDescription(`发送 HTTP 请求并流式读取响应，适用于 Server-Sent Events（text/event-stream）、分块传输和长轮询接口，这类接口使用 http_request 会一直等待。
+达到事件数、字节数或时长任一限制即停止读取并返回已收到的内容；SSE 响应按 event/data/id 解析为事件列表，其他响应按到达的数据块返回。
+读取过程中会发送 MCP 进度通知。请求参数与 http_request 相同。`),
+	mcp.WithString("mode",
+		mcp.Enum("auto", "sse", "chunks"),
+		mcp.Description("解析方式: auto 按 Content-Type 判断（默认），sse 按 SSE 事件解析，chunks 按数据块返回"),
+	),
+	mcp.WithNumber("maxEvents",
+		mcp.Description(fmt.Sprintf("最多读取的事件或数据块数量，默认 %d", defaultStreamMaxEvents)),
+	),
+	mcp.WithNumber("maxBytes",
+		mcp.Description(fmt.Sprintf("最多读取的字节数，默认 %d", defaultStreamMaxBytes)),
+	),
+	mcp.WithNumber("durationSeconds",
+		mcp.Description(fmt.Sprintf("最长读取时间（秒），默认 %d，最大 %d", int(defaultStreamDuration.Seconds()), int(maxStreamDuration.Seconds()))),
+	),
+))
+
+func httpStreamHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	req, err := buildRequest(args)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("解析请求参数失败", err), nil
+	}
+	opts := streamOptionsFromArgs(args)
+
+	result, err := sendStream(ctx, req, opts, func(r *StreamResult) {
+		helper.NotifyProgress(ctx, request, float64(len(r.Events)), float64(opts.MaxEvents),
+			fmt.Sprintf("received %d events, %d bytes", len(r.Events), r.Bytes))
+	})
+	if err != nil {
+		return requestErrorResult(err), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Status: %d\n", result.StatusCode)
+	fmt.Fprintf(&sb, "Content-Type: %s\n", result.Header.Get("Content-Type"))
+	fmt.Fprintf(&sb, "Mode: %s\n", result.Mode)
+	fmt.Fprintf(&sb, "Stopped: %s\n", result.StopReason)
+	fmt.Fprintf(&sb, "Events: %d, %d bytes in %.3fs\n", len(result.Events), result.Bytes, result.Elapsed.Seconds())
+	sb.WriteString(formatStreamEvents(result))
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
@@ -0,0 +1,142 @@
+package httprequest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamServer 返回一个推送 SSE 事件和分块数据的测试服务，推送完成后保持连接直到客户端断开
+func newStreamServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		switch r.URL.Path {
+		case "/events":
+			w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+			fmt.Fprint(w, ": keep-alive\n\n")
+			fmt.Fprint(w, "event: token\nid: 1\ndata: hello\n\n")
+			fmt.Fprint(w, "data: line1\r\ndata: line2\r\n\r\n")
+			fmt.Fprint(w, "id: 3\nretry: 1000\ndata: {\"done\":true}\n\n")
+		case "/chunks":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for i := 1; i <= 3; i++ {
+				fmt.Fprintf(w, "{\"n\":%d}\n", i)
+				flusher.Flush()
+				time.Sleep(20 * time.Millisecond)
+			}
+		case "/slow":
+			// 迟迟不返回响应头
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		case "/close":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: a\n\ndata: b")
+			return
+		}
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+}
+
+func TestSSEParser(t *testing.T) {
+	input := "event: a\ndata: 1\ndata:2\n\n: comment\nid: x\ndata\n\nevent: empty\n\n"
+	var parser sseParser
+	var events []StreamEvent
+	for _, line := range strings.Split(strings.TrimSuffix(input, "\n"), "\n") {
+		if ev, ok := parser.feed(line); ok {
+			events = append(events, ev)
+		}
+	}
+	assert.Equal(t, []StreamEvent{
+		{Event: "a", Data: "1\n2"},
+		{Event: "message", ID: "x", Data: ""},
+	}, events, "没有 data 的事件不分发")
+
+	r := bufio.NewReader(strings.NewReader("a\r\nb\rc\n"))
+	for _, want := range []string{"a", "b", "c"} {
+		line, _, err := readSSELine(r)
+		require.NoError(t, err)
+		assert.Equal(t, want, line)
+	}
+}
+
+func TestHttpStreamHandler(t *testing.T) {
+	srv := newStreamServer()
+	defer srv.Close()
+	resetHistory(t, HistoryConfig{})
+
+	t.Run("SSE 达到事件数限制", func(t *testing.T) {
+		result, err := httpStreamHandler(context.Background(), newCallToolRequest("http_stream", map[string]any{
+			"url":       srv.URL + "/events",
+			"maxEvents": 2,
+		}))
+		require.NoError(t, err)
+		text := resultText(t, result)
+		assert.Contains(t, text, "Mode: sse\nStopped: event limit 2 reached\nEvents: 2,")
+		assert.Regexp(t, `\[1\] \+\d+\.\d{3}s event=token id=1\nhello\n`, text)
+		assert.Regexp(t, `\[2\] \+\d+\.\d{3}s event=message id=1\nline1\nline2$`, text)
+	})
+
+	t.Run("SSE 达到时长限制", func(t *testing.T) {
+		start := time.Now()
+		result, err := httpStreamHandler(context.Background(), newCallToolRequest("http_stream", map[string]any{
+			"url":             srv.URL + "/events",
+			"durationSeconds": 0.2,
+		}))
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		text := resultText(t, result)
+		assert.Contains(t, text, "Stopped: duration limit 200ms reached\nEvents: 3,")
+		assert.Contains(t, text, "event=message id=3 retry=1000\n{\"done\":true}")
+	})
+
+	t.Run("服务端关闭连接", func(t *testing.T) {
+		result, err := httpStreamHandler(context.Background(), newCallToolRequest("http_stream", map[string]any{
+			"url": srv.URL + "/close",
+		}))
+		require.NoError(t, err)
+		text := resultText(t, result)
+		assert.Contains(t, text, "Stopped: stream closed by server\nEvents: 2,")
+		assert.True(t, strings.HasSuffix(text, "\nb"), text)
+	})
+
+	t.Run("分块数据达到字节数限制", func(t *testing.T) {
+		result, err := httpStreamHandler(context.Background(), newCallToolRequest("http_stream", map[string]any{
+			"url":      srv.URL + "/chunks",
+			"maxBytes": 12,
+		}))
+		require.NoError(t, err)
+		text := resultText(t, result)
+		assert.Contains(t, text, "Mode: chunks\nStopped: byte limit 12 reached\nEvents: 2, 12 bytes")
+		assert.Regexp(t, `\[1\] \+\d+\.\d{3}s 8 bytes\n\{"n":1\}\n`, text)
+		assert.Regexp(t, `\[2\] \+\d+\.\d{3}s 4 bytes\n\{"n"$`, text)
+	})
+
+	entries := getHistory().List()
+	require.Len(t, entries, 4)
+	assert.Equal(t, srv.URL+"/chunks", entries[0].Request.URL)
+	assert.Equal(t, "{\"n\":1}\n{\"n\"", entries[0].Body)
+
+	t.Run("等待响应头的时间计入时长限制", func(t *testing.T) {
+		start := time.Now()
+		result, err := httpStreamHandler(context.Background(), newCallToolRequest("http_stream", map[string]any{
+			"url":             srv.URL + "/slow",
+			"durationSeconds": 1,
+		}))
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, result.IsError)
+		assert.Contains(t, resultText(t, result), "在 1s 内没有收到响应头")
+	})
+}
@@ -2,8 +2,14 @@ package httprequest
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/kugouming/mcpservers/helper"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -13,7 +19,28 @@ import (
 
 // RegisterTool 注册HTTP请求工具
 func RegisterTool(s *server.MCPServer) {
-	s.AddTool(httpTool, httpHandler)
+	s.AddTool(withResponseOptions(httpTool), httpHandler)
+	s.AddTool(withResponseOptions(httpRawTool), httpRawHandler)
+	s.AddTool(httpAssertTool, httpAssertHandler)
+	s.AddTool(httpStreamTool, httpStreamHandler)
+	s.AddTool(httpLoadTool, httpLoadHandler)
+	s.AddTool(httpFileListTool, httpFileListHandler)
+	s.AddTool(httpFileRunTool, httpFileRunHandler)
+	s.AddTool(httpAuthProfilesTool, httpAuthProfilesHandler)
+	s.AddTool(httpSessionListTool, httpSessionListHandler)
+	s.AddTool(httpSessionCookiesTool, httpSessionCookiesHandler)
+	s.AddTool(httpSessionClearTool, httpSessionClearHandler)
+	s.AddTool(httpHistoryListTool, httpHistoryListHandler)
+	s.AddTool(withResponseOptions(httpHistoryReplayTool), httpHistoryReplayHandler)
+	s.AddTool(httpHistoryDiffTool, httpHistoryDiffHandler)
+	s.AddTool(httpSnippetTool, httpSnippetHandler)
+	s.AddTool(httpHarExportTool, httpHarExportHandler)
+	s.AddTool(httpHarImportTool, httpHarImportHandler)
+	s.AddTool(graphqlRequestTool, graphqlRequestHandler)
+	s.AddTool(graphqlSchemaTool, graphqlSchemaHandler)
+	s.AddTool(websocketSendTool, websocketSendHandler)
+	s.AddTool(websocketListTool, websocketListHandler)
+	s.AddTool(websocketCloseTool, websocketCloseHandler)
 }
 
 // httpTool 定义了HTTP请求工具的配置
@@ -23,7 +50,8 @@ var httpTool = mcp.NewTool("http_request",
   * 根据用户的输入先识别出输入的数据格式, 如: CURL 命令、REST 风格请求等数据;
   * 再根据数据格式找到与下面提供的格式说明中对应的格式, 提取出请求所需参数;
 - 对解析出的HTTP请求参数发起HTTP请求;
-- 禁止对请求结果做解析, 直接按照原样(json格式)输出。
+- 禁止对请求结果做解析, 直接按照原样(json格式)输出;
+- 如果用户输入的是完整的 CURL 命令或原始 HTTP 报文, 优先使用 http_request_raw 工具原样传入。
 
 ## CURL 命令示例
 
@@ -34,7 +62,7 @@ curl -X POST https://api.example.com/data -H "Content-Type: application/json" -d
 {
 	"method": "POST",
 	"url": "https://api.example.com/data",
-	"headers": "Content-Type: application/json",
+	"headers": {"Content-Type": "application/json"},
 	"body": "{\"key\": \"value\"}"
 }
 
@@ -50,7 +78,7 @@ Content-Type: application/json
 {
 	"method": "POST",
 	"url": "https://api.example.com/data",
-	"headers": "Content-Type: application/json",
+	"headers": {"Content-Type": "application/json"},
 	"body": "{\"key\": \"value\"}"
 }
 
@@ -68,7 +96,7 @@ Content-Type: application/json
 {
 	"method": "POST",
 	"url": "https://api.example.com/data",
-	"headers": "Content-Type: application/json",
+	"headers": {"Content-Type": "application/json"},
 	"body": "{\"key\": \"value\"}"
 }
 
@@ -76,61 +104,260 @@ Content-Type: application/json
 {
 	"method": "POST",
 	"url": "https://api.example.com/data",
-	"headers": "",
+	"headers": {},
 	"body": "{\"key\": \"value\""
 }
 `),
 	mcp.WithString("method",
 		mcp.Required(),
 		mcp.Description("HTTP method to use"),
-		mcp.Enum("GET", "POST", "PUT", "DELETE"),
+		mcp.Enum("GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"),
 	),
 	mcp.WithString("url",
 		mcp.Required(),
 		mcp.Description("URL to send the request to"),
 		mcp.Pattern("^https?://.*"),
 	),
-	mcp.WithString("headers",
-		mcp.Description("Request headers"),
+	mcp.WithObject("headers",
+		mcp.Description("Request headers, e.g. {\"Content-Type\": \"application/json\", \"X-Tag\": [\"a\", \"b\"]}. Use an array value to send a header multiple times"),
+		mcp.AdditionalProperties(map[string]any{
+			"anyOf": []any{
+				map[string]any{"type": "string"},
+				map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+		}),
+	),
+	mcp.WithObject("query",
+		mcp.Description("Query parameters merged into the URL with proper encoding, e.g. {\"q\": \"hello world\", \"id\": [1, 2]}"),
 	),
 	mcp.WithString("body",
-		mcp.Description("Request body"),
+		mcp.Description("Raw request body"),
+	),
+	mcp.WithObject("json",
+		mcp.Description("JSON request body; Content-Type is set to application/json automatically. Do not combine with body"),
+	),
+	mcp.WithObject("form",
+		mcp.Description("application/x-www-form-urlencoded body, e.g. {\"username\": \"alice\", \"tag\": [\"a\", \"b\"]}. Do not combine with body/json/multipart"),
 	),
+	mcp.WithArray("multipart",
+		mcp.Description("multipart/form-data parts. Text field: {\"name\": \"title\", \"value\": \"demo\"}; file field: {\"name\": \"file\", \"path\": \"/abs/path/a.png\", \"filename\": \"a.png\", \"contentType\": \"image/png\"}. Files are streamed from local disk"),
+		mcp.Items(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name":        map[string]any{"type": "string", "description": "Field name"},
+				"value":       map[string]any{"type": "string", "description": "Text field value"},
+				"path":        map[string]any{"type": "string", "description": "Absolute path of a local file to upload"},
+				"filename":    map[string]any{"type": "string", "description": "File name sent to the server, defaults to the base name of path"},
+				"contentType": map[string]any{"type": "string", "description": "File content type, guessed from the extension by default"},
+			},
+			"required": []string{"name"},
+		}),
+	),
+	profileToolOption,
+	sessionToolOption,
 )
 
+// withRequestParams 为工具追加 http_request 的全部请求参数（method、url、headers、body、profile 等）
+func withRequestParams(tool mcp.Tool) mcp.Tool {
+	for name, prop := range httpTool.InputSchema.Properties {
+		tool.InputSchema.Properties[name] = prop
+	}
+	tool.InputSchema.Required = append(tool.InputSchema.Required, httpTool.InputSchema.Required...)
+	return tool
+}
+
 func httpHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	method := cast.ToString(request.GetArguments()["method"])
-	url := cast.ToString(request.GetArguments()["url"])
-
-	// 尝试解析headers参数
-	headers := make(map[string]string)
-	headersStr := cast.ToString(request.GetArguments()["headers"])
-	if headersStr != "" {
-		// 这里可以添加更复杂的header解析逻辑
-		// 简单处理：假设格式为 "Key1: Value1\nKey2: Value2"
-		headerLines := strings.Split(headersStr, "\n")
-		for _, line := range headerLines {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				if key != "" && value != "" {
-					headers[key] = value
+	req, err := buildRequest(request.GetArguments())
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("解析请求参数失败", err), nil
+	}
+
+	return doRequest(ctx, req, responseOptionsFromArgs(request.GetArguments()))
+}
+
+// buildRequest 根据 http_request 工具参数构造请求
+func buildRequest(args map[string]any) (*Request, error) {
+	req := &Request{
+		Method:  strings.ToUpper(cast.ToString(args["method"])),
+		Header:  make(http.Header),
+		Profile: cast.ToString(args["profile"]),
+		Session: cast.ToString(args["session"]),
+	}
+
+	// 解析headers参数，兼容 "Key1: Value1\nKey2: Value2" 形式的字符串
+	switch headers := args["headers"].(type) {
+	case map[string]any:
+		for k, v := range headers {
+			if values, ok := v.([]any); ok {
+				for _, vv := range values {
+					req.Header.Add(k, cast.ToString(vv))
 				}
+				continue
+			}
+			req.Header.Add(k, cast.ToString(v))
+		}
+	case string:
+		for _, line := range strings.Split(headers, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if ok && strings.TrimSpace(key) != "" {
+				req.Header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+			}
+		}
+	}
+
+	rawURL, err := mergeQuery(cast.ToString(args["url"]), args["query"])
+	if err != nil {
+		return nil, err
+	}
+	req.URL = rawURL
+
+	// 解析body参数
+	if b, ok := args["body"].(string); ok {
+		req.Body = strings.ReplaceAll(b, "\"\"", "\"")
+	}
+	bodies := 0
+	for _, name := range []string{"body", "json", "form", "multipart"} {
+		if v, ok := args[name]; ok && v != nil && v != "" {
+			bodies++
+		}
+	}
+	if bodies > 1 {
+		return nil, errors.New("body、json、form 和 multipart 参数只能使用其中一个")
+	}
+
+	if j, ok := args["json"]; ok && j != nil {
+		data, err := json.Marshal(j)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = string(data)
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if form, ok := args["form"].(map[string]any); ok {
+		req.Body = parseFormArg(form)
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if m, ok := args["multipart"]; ok && m != nil {
+		parts, err := parseMultipartArg(m)
+		if err != nil {
+			return nil, err
+		}
+		req.Parts = parts
+	}
+
+	return req, nil
+}
+
+// mergeQuery 将 query 参数追加到 URL 中，URL 中已有的查询参数保持原样，不重新编码或排序
+func mergeQuery(rawURL string, query any) (string, error) {
+	params, ok := query.(map[string]any)
+	if !ok || len(params) == 0 {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	values := url.Values{}
+	for k, v := range params {
+		if list, ok := v.([]any); ok {
+			for _, item := range list {
+				values.Add(k, cast.ToString(item))
 			}
+			continue
 		}
+		values.Add(k, cast.ToString(v))
 	}
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += values.Encode()
+	return u.String(), nil
+}
+
+// doRequest 执行请求并按 "Status/Body" 格式返回结果
+func doRequest(ctx context.Context, req *Request, opts ResponseOptions) (*mcp.CallToolResult, error) {
+	resp, err := sendRequest(ctx, req)
+	if err != nil {
+		return requestErrorResult(err), nil
+	}
+
+	return formatResponse(resp, opts)
+}
 
-	// 尝试解析body参数
-	body := ""
-	if b, ok := request.GetArguments()["body"].(string); ok {
-		body = strings.ReplaceAll(b, "\"\"", "\"")
+// requestErrorResult 将请求错误转换为工具结果，策略拒绝时直接给出命中的规则
+func requestErrorResult(err error) *mcp.CallToolResult {
+	var policyErr *helper.PolicyError
+	if errors.As(err, &policyErr) {
+		return mcp.NewToolResultError(policyErr.Error())
 	}
+	return mcp.NewToolResultErrorFromErr("执行请求失败", err)
+}
 
-	statusCode, responseBody, err := helper.HttpRequest(ctx, method, url, headers, body)
+// sendRequest 发送请求并记录到请求历史
+func sendRequest(ctx context.Context, req *Request) (*helper.HttpResponse, error) {
+	start := time.Now()
+	resp, err := send(ctx, req)
+	getHistory().Record(req, resp, err, start, time.Since(start))
+	return resp, err
+}
+
+// send 使用 do 发送请求并读取完整响应
+func send(ctx context.Context, req *Request) (*helper.HttpResponse, error) {
+	var resp *helper.HttpResponse
+	err := do(ctx, req, func(client *http.Client, signed *Request, body io.Reader) (err error) {
+		resp, err = helper.DoHttpRequestWithClient(ctx, client, signed.Method, signed.URL, signed.Header, body)
+		return err
+	})
+	return resp, err
+}
+
+// do 注入认证信息后使用带出站策略检查的客户端执行 fn，指定会话时携带并保存会话 Cookie
+func do(ctx context.Context, req *Request, fn func(client *http.Client, signed *Request, body io.Reader) error) error {
+	signed, err := applyAuthProfile(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	client := getHttpClient()
+	var sess *Session
+	if req.Session != "" {
+		if sess, err = sessions.Get(req.Session); err != nil {
+			return err
+		}
+		client = sess.Client(client)
+	}
+	if req.Profile != "" {
+		profile, err := lookupAuthProfile(req.Profile)
+		if err != nil {
+			return err
+		}
+		client = profileRedirectClient(client, profile)
+	}
+
+	// 所有检查通过后才创建请求体，multipart 请求体会打开文件
+	body, contentType, err := signed.bodyReader()
 	if err != nil {
-		return mcp.NewToolResultErrorFromErr("执行请求失败", err), nil
+		return err
+	}
+	// 请求在读取请求体之前失败时关闭管道，结束写入文件的 goroutine
+	if closer, ok := body.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if contentType != "" {
+		signed.Header.Set("Content-Type", contentType)
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Status: %d\nBody: %s", statusCode, string(responseBody))), nil
+	err = fn(client, signed, body)
+	if sess != nil {
+		if saveErr := sessions.Save(sess); saveErr != nil {
+			log.Printf("保存会话 %s 失败: %v", sess.Name, saveErr)
+		}
+	}
+	return err
 }
@@ -0,0 +1,129 @@
+package httprequest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCallToolRequest 构造工具调用请求
+func newCallToolRequest(name string, arguments map[string]any) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	return request
+}
+
+// resultText 返回工具结果中的文本内容
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	require.NotNil(t, result)
+	require.NotEmpty(t, result.Content)
+	text, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	return text.Text
+}
+
+// newEchoServer 返回一个回显请求方法、请求头和请求体的测试服务
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Echo-Method", r.Method)
+		for k, v := range r.Header {
+			for _, vv := range v {
+				io.WriteString(w, k+": "+vv+"\n")
+			}
+		}
+		io.WriteString(w, "\n"+r.Method+" "+r.URL.RequestURI()+"\n"+string(body))
+	}))
+}
+
+func TestHttpRawHandler(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+
+	result, err := httpRawHandler(context.Background(), newCallToolRequest("http_request_raw", map[string]any{
+		"raw": "curl -X POST " + srv.URL + "/data \\\n  -H 'X-Token: abc' \\\n  -d '{\"key\": \"value\"}'",
+	}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	text := resultText(t, result)
+	assert.Contains(t, text, "Status: 200")
+	assert.Contains(t, text, "X-Token: abc")
+	assert.Contains(t, text, "POST /data\n{\"key\": \"value\"}")
+
+	result, err = httpRawHandler(context.Background(), newCallToolRequest("http_request_raw", map[string]any{
+		"raw": "curl -X POST",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestBuildRequest(t *testing.T) {
+	t.Run("对象请求头支持多值和空值", func(t *testing.T) {
+		req, err := buildRequest(map[string]any{
+			"method":  "patch",
+			"url":     "https://api.example.com/items?x=1&sig=a%2Fb&flag",
+			"headers": map[string]any{"X-Tag": []any{"a", "b"}, "X-Empty": ""},
+			"query":   map[string]any{"q": "hello world", "id": []any{float64(1), float64(2)}},
+			"json":    map[string]any{"key": "value"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "PATCH", req.Method)
+		assert.Equal(t, "https://api.example.com/items?x=1&sig=a%2Fb&flag&id=1&id=2&q=hello+world", req.URL)
+		assert.Equal(t, []string{"a", "b"}, req.Header.Values("X-Tag"))
+		assert.Equal(t, []string{""}, req.Header.Values("X-Empty"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, `{"key":"value"}`, req.Body)
+	})
+
+	t.Run("兼容字符串请求头", func(t *testing.T) {
+		req, err := buildRequest(map[string]any{
+			"method":  "GET",
+			"url":     "https://api.example.com",
+			"headers": "Accept: text/plain\nX-Tag: a\nX-Tag: b\nX-Empty:",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", req.Header.Get("Accept"))
+		assert.Equal(t, []string{"a", "b"}, req.Header.Values("X-Tag"))
+		assert.Equal(t, []string{""}, req.Header.Values("X-Empty"))
+	})
+
+	t.Run("body 和 json 不能同时使用", func(t *testing.T) {
+		_, err := buildRequest(map[string]any{
+			"method": "POST",
+			"url":    "https://api.example.com",
+			"body":   "raw",
+			"json":   map[string]any{},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestHttpHandler(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+
+	result, err := httpHandler(context.Background(), newCallToolRequest("http_request", map[string]any{
+		"method":  "POST",
+		"url":     srv.URL + "/items",
+		"headers": map[string]any{"X-Tag": []any{"a", "b"}},
+		"query":   map[string]any{"q": "a&b"},
+		"json":    map[string]any{"key": "value"},
+	}))
+	require.NoError(t, err)
+
+	text := resultText(t, result)
+	assert.Contains(t, text, "Status: 200")
+	assert.Contains(t, text, "X-Tag: a\nX-Tag: b\n")
+	assert.Contains(t, text, "Content-Type: application/json")
+	assert.Contains(t, text, "POST /items?q=a%26b\n{\"key\":\"value\"}")
+}
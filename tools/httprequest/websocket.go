@@ -0,0 +1,543 @@
+package httprequest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket 工具的默认值和上限
+const (
+	defaultWSMaxMessages = 10
+	defaultWSTimeout     = 5 * time.Second
+	maxWSTimeout         = 60 * time.Second
+	maxWSConnections     = 10
+	maxWSPending         = 1000     // 命名连接在两次调用之间最多缓存的消息数
+	maxWSPayloadBytes    = 1 << 20  // 单条消息的最大字节数
+	maxWSDisplayBytes    = 4 * 1024 // 输出中每条消息显示的最大字节数
+)
+
+// wsMessage 连接上收发的一条消息
+type wsMessage struct {
+	Time   time.Time
+	Sent   bool
+	Binary bool
+	Data   []byte
+}
+
+// wsCodec 接收时保留帧类型，用于区分文本和二进制消息
+var wsCodec = websocket.Codec{
+	Marshal: func(v any) ([]byte, byte, error) {
+		return []byte(v.(string)), websocket.TextFrame, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v any) error {
+		msg := v.(*wsMessage)
+		msg.Data = data
+		msg.Binary = payloadType == websocket.BinaryFrame
+		return nil
+	},
+}
+
+// wsConn 一个 WebSocket 连接，后台持续读取消息，收到的消息在被取走前缓存在 pending 中
+type wsConn struct {
+	Name     string
+	URL      string
+	Protocol string
+	Created  time.Time
+
+	ws       *websocket.Conn
+	mu       sync.Mutex
+	pending  []wsMessage
+	dropped  int
+	closed   bool
+	closeErr error
+	notify   chan struct{}
+	lastUsed time.Time
+}
+
+// readLoop 读取消息直到连接关闭
+func (c *wsConn) readLoop() {
+	for {
+		var msg wsMessage
+		err := wsCodec.Receive(c.ws, &msg)
+		c.mu.Lock()
+		if err != nil {
+			if !errors.Is(err, websocket.ErrFrameTooLarge) {
+				c.closed = true
+				if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+					c.closeErr = err
+				}
+				c.mu.Unlock()
+				c.signal()
+				return
+			}
+			msg.Data = []byte(fmt.Sprintf("消息超过 %d 字节，已丢弃", maxWSPayloadBytes))
+		}
+		msg.Time = time.Now()
+		if len(c.pending) >= maxWSPending {
+			c.pending = c.pending[1:]
+			c.dropped++
+		}
+		c.pending = append(c.pending, msg)
+		c.mu.Unlock()
+		c.signal()
+	}
+}
+
+func (c *wsConn) signal() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// collect 取走缓存的消息，不足 max 条时等待新消息，直到超时、连接关闭或 ctx 取消
+func (c *wsConn) collect(ctx context.Context, max int, timeout time.Duration) []wsMessage {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var out []wsMessage
+	for {
+		c.mu.Lock()
+		n := min(max-len(out), len(c.pending))
+		out = append(out, c.pending[:n]...)
+		c.pending = c.pending[n:]
+		closed := c.closed
+		c.mu.Unlock()
+		if len(out) >= max || closed {
+			return out
+		}
+		select {
+		case <-c.notify:
+		case <-timer.C:
+			return out
+		case <-ctx.Done():
+			return out
+		}
+	}
+}
+
+// send 依次发送文本消息
+func (c *wsConn) send(messages []string) ([]wsMessage, error) {
+	sent := make([]wsMessage, 0, len(messages))
+	for _, m := range messages {
+		c.ws.SetWriteDeadline(time.Now().Add(maxWSTimeout))
+		if err := wsCodec.Send(c.ws, m); err != nil {
+			return sent, err
+		}
+		sent = append(sent, wsMessage{Time: time.Now(), Sent: true, Data: []byte(m)})
+	}
+	return sent, nil
+}
+
+// wsConnections 保持打开的命名 WebSocket 连接
+type wsConnections struct {
+	mu    sync.Mutex
+	conns map[string]*wsConn
+}
+
+var wsConns = &wsConnections{conns: make(map[string]*wsConn)}
+
+// Get 返回命名连接，不存在时返回 nil
+func (s *wsConnections) Get(name string) *wsConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[name]
+}
+
+// Add 保存命名连接，超过上限时返回错误
+func (s *wsConnections) Add(c *wsConn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conns[c.Name]; ok {
+		return fmt.Errorf("连接 %s 已存在", c.Name)
+	}
+	if len(s.conns) >= maxWSConnections {
+		return fmt.Errorf("最多保持 %d 个连接，请先使用 websocket_close 关闭不用的连接", maxWSConnections)
+	}
+	s.conns[c.Name] = c
+	return nil
+}
+
+// Close 关闭并移除命名连接
+func (s *wsConnections) Close(name string) bool {
+	s.mu.Lock()
+	c, ok := s.conns[name]
+	delete(s.conns, name)
+	s.mu.Unlock()
+	if ok {
+		c.ws.Close()
+	}
+	return ok
+}
+
+// List 返回按名称排序的连接
+func (s *wsConnections) List() []*wsConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*wsConn, 0, len(s.conns))
+	for _, c := range s.conns {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// wsHTTPURL 把 ws/wss 地址转换为 http/https，用于出站策略和认证配置的主机检查
+func wsHTTPURL(u *url.URL) (*url.URL, error) {
+	out := *u
+	switch strings.ToLower(u.Scheme) {
+	case "ws", "http":
+		out.Scheme = "http"
+	case "wss", "https":
+		out.Scheme = "https"
+	default:
+		return nil, fmt.Errorf("不支持的 WebSocket 协议 %s，请使用 ws:// 或 wss://", u.Scheme)
+	}
+	return &out, nil
+}
+
+// loadHttpClientConfig 读取代理和 TLS 等客户端配置，测试中可替换
+var loadHttpClientConfig = helper.LoadHttpClientConfig
+
+// dialWebSocket 检查出站策略、注入认证信息后建立 WebSocket 连接
+func dialWebSocket(ctx context.Context, req *Request, protocols []string, timeout time.Duration) (*websocket.Conn, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	httpURL, err := wsHTTPURL(u)
+	if err != nil {
+		return nil, err
+	}
+	policy := getURLPolicy()
+	if err := policy.CheckURL(ctx, httpURL); err != nil {
+		return nil, err
+	}
+
+	// 认证配置按 http(s) 地址匹配主机，签名类认证以 GET 空请求体计算
+	authReq := req.Clone()
+	authReq.Method = http.MethodGet
+	authReq.URL = httpURL.String()
+	signed, err := applyAuthProfile(ctx, authReq)
+	if err != nil {
+		return nil, err
+	}
+
+	wsURL := *httpURL
+	wsURL.Scheme = map[string]string{"http": "ws", "https": "wss"}[httpURL.Scheme]
+	origin := signed.Header.Get("Origin")
+	if origin == "" {
+		origin = httpURL.Scheme + "://" + httpURL.Host
+	}
+	config, err := websocket.NewConfig(wsURL.String(), origin)
+	if err != nil {
+		return nil, err
+	}
+	config.Header = signed.Header.Clone()
+	config.Header.Del("Origin")
+	config.Protocol = protocols
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cfg, err := loadHttpClientConfig()
+	if err != nil {
+		log.Printf("读取 HTTP 客户端配置失败，使用默认配置: %v", err)
+		cfg = helper.DefaultHttpClientConfig()
+	}
+	dialer := &net.Dialer{}
+	dial := helper.PolicyDialer(func() *helper.URLPolicy { return policy }, proxyAddrs(cfg))(dialer.DialContext)
+	conn, err := helper.DialURL(ctx, cfg, httpURL, dial)
+	if err != nil {
+		return nil, helper.RedactError(err)
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		if errors.Is(err, websocket.ErrBadStatus) {
+			return nil, errors.New("服务端没有接受 WebSocket 握手（响应状态码不是 101）")
+		}
+		return nil, fmt.Errorf("WebSocket 握手失败: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = maxWSPayloadBytes
+	log.Printf("WebSocket 已连接 %s", helper.RedactURL(wsURL.String()))
+	return ws, nil
+}
+
+// parseWSMessages 解析脚本消息，字符串原样发送，其他 JSON 值编码后以文本消息发送
+func parseWSMessages(v any) ([]string, error) {
+	items, ok := v.([]any)
+	if !ok {
+		if v == nil {
+			return nil, nil
+		}
+		items = []any{v}
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+			continue
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, string(data))
+	}
+	return out, nil
+}
+
+// formatWSMessages 每条消息一段，→ 为发送，← 为接收
+func formatWSMessages(sb *strings.Builder, messages []wsMessage, start time.Time) {
+	for i, m := range messages {
+		arrow := "←"
+		if m.Sent {
+			arrow = "→"
+		}
+		fmt.Fprintf(sb, "[%d] +%.3fs %s", i+1, m.Time.Sub(start).Seconds(), arrow)
+		if m.Binary {
+			fmt.Fprintf(sb, " binary %d bytes (base64)\n", len(m.Data))
+			data := m.Data[:min(len(m.Data), maxWSDisplayBytes)]
+			sb.WriteString(base64.StdEncoding.EncodeToString(data))
+		} else {
+			text, truncated := truncateUTF8(m.Data, maxWSDisplayBytes)
+			sb.WriteString("\n" + text)
+			if truncated {
+				fmt.Fprintf(sb, "\n...（共 %d 字节）", len(m.Data))
+			}
+		}
+		sb.WriteString("\n")
+	}
+}
+
+var websocketSendTool = mcp.NewTool("websocket_send",
+	mcp.WithDescription(`建立 WebSocket 连接，依次发送脚本中的消息，然后收集服务端的消息，直到达到消息数或超时。
+指定 connection 时连接在调用结束后保持打开，后续调用使用同一 connection 名称可继续收发（不需要再传 url），两次调用之间收到的消息会在下次调用时返回；
+不指定时调用结束即关闭连接。连接同样经过出站策略检查，可使用 profile 注入认证信息。`),
+	mcp.WithString("url",
+		mcp.Description("WebSocket 地址，如 wss://example.com/ws；使用已打开的命名连接时可省略"),
+	),
+	mcp.WithString("connection",
+		mcp.Description("连接名称，指定时保持连接打开供后续调用使用"),
+		mcp.Pattern(`^[A-Za-z0-9_.-]{1,64}$`),
+	),
+	mcp.WithObject("headers",
+		mcp.Description("握手请求头，如 {\"Authorization\": \"Bearer xxx\", \"Origin\": \"https://example.com\"}"),
+	),
+	mcp.WithObject("query",
+		mcp.Description("追加到 URL 的查询参数"),
+	),
+	mcp.WithArray("subprotocols",
+		mcp.Description("Sec-WebSocket-Protocol 子协议列表，如 [\"graphql-transport-ws\"]"),
+		mcp.Items(map[string]any{"type": "string"}),
+	),
+	mcp.WithString("profile",
+		mcp.Description("认证配置名称，可通过 http_auth_profiles 查看"),
+	),
+	mcp.WithArray("messages",
+		mcp.Description("依次发送的文本消息；字符串原样发送，对象或数组编码为 JSON 后发送"),
+	),
+	mcp.WithNumber("maxMessages",
+		mcp.Description(fmt.Sprintf("最多收集的消息数，默认 %d", defaultWSMaxMessages)),
+	),
+	mcp.WithNumber("timeoutSeconds",
+		mcp.Description(fmt.Sprintf("发送完成后等待消息的最长时间（秒），默认 %d，最大 %d", int(defaultWSTimeout.Seconds()), int(maxWSTimeout.Seconds()))),
+	),
+)
+
+func websocketSendHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	name := cast.ToString(args["connection"])
+	messages, err := parseWSMessages(args["messages"])
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("解析 messages 参数失败", err), nil
+	}
+	maxMessages := cast.ToInt(args["maxMessages"])
+	if maxMessages <= 0 {
+		maxMessages = defaultWSMaxMessages
+	}
+	timeout := time.Duration(cast.ToFloat64(args["timeoutSeconds"]) * float64(time.Second))
+	if timeout <= 0 {
+		timeout = defaultWSTimeout
+	}
+	timeout = min(timeout, maxWSTimeout)
+
+	var conn *wsConn
+	reused := false
+	if name != "" {
+		conn = wsConns.Get(name)
+		reused = conn != nil
+	}
+	if conn == nil {
+		if cast.ToString(args["url"]) == "" {
+			if name != "" {
+				return mcp.NewToolResultError(fmt.Sprintf("连接 %s 不存在，请提供 url 建立连接", name)), nil
+			}
+			return mcp.NewToolResultError("url 参数不能为空"), nil
+		}
+		req, err := buildRequest(map[string]any{
+			"method":  http.MethodGet,
+			"url":     args["url"],
+			"query":   args["query"],
+			"headers": args["headers"],
+			"profile": args["profile"],
+		})
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("解析请求参数失败", err), nil
+		}
+		ws, err := dialWebSocket(ctx, req, cast.ToStringSlice(args["subprotocols"]), timeout)
+		if err != nil {
+			return requestErrorResult(err), nil
+		}
+		conn = &wsConn{
+			Name:     name,
+			URL:      helper.RedactURL(ws.Config().Location.String()),
+			Created:  time.Now(),
+			ws:       ws,
+			notify:   make(chan struct{}, 1),
+			lastUsed: time.Now(),
+		}
+		if len(ws.Config().Protocol) == 1 {
+			conn.Protocol = ws.Config().Protocol[0]
+		}
+		if name != "" {
+			if err := wsConns.Add(conn); err != nil {
+				ws.Close()
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		} else {
+			defer ws.Close()
+		}
+		go conn.readLoop()
+	} else if args["url"] != nil || args["subprotocols"] != nil || args["headers"] != nil {
+		log.Printf("WebSocket 连接 %s 已打开，忽略 url、headers 和 subprotocols 参数", name)
+	}
+
+	// 先取走两次调用之间收到的消息，再发送脚本消息
+	conn.mu.Lock()
+	start := conn.lastUsed
+	transcript := append([]wsMessage(nil), conn.pending...)
+	conn.pending = nil
+	dropped := conn.dropped
+	conn.dropped = 0
+	conn.mu.Unlock()
+
+	sent, sendErr := conn.send(messages)
+	transcript = append(transcript, sent...)
+	received := 0
+	for _, m := range transcript {
+		if !m.Sent {
+			received++
+		}
+	}
+	if sendErr == nil && received < maxMessages {
+		transcript = append(transcript, conn.collect(ctx, maxMessages-received, timeout)...)
+	}
+	sort.SliceStable(transcript, func(i, j int) bool { return transcript[i].Time.Before(transcript[j].Time) })
+
+	conn.mu.Lock()
+	conn.lastUsed = time.Now()
+	closed, closeErr := conn.closed, conn.closeErr
+	conn.mu.Unlock()
+	if closed && name != "" {
+		wsConns.Close(name)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Connection: %s", conn.URL)
+	if name != "" {
+		fmt.Fprintf(&sb, " (%s", name)
+		if reused {
+			sb.WriteString("，复用")
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString("\n")
+	if conn.Protocol != "" {
+		fmt.Fprintf(&sb, "Subprotocol: %s\n", conn.Protocol)
+	}
+	fmt.Fprintf(&sb, "Messages: 发送 %d，接收 %d\n", len(sent), len(transcript)-len(sent))
+	if dropped > 0 {
+		fmt.Fprintf(&sb, "Dropped: 缓存已满，丢弃了 %d 条较早的消息\n", dropped)
+	}
+	switch {
+	case sendErr != nil:
+		fmt.Fprintf(&sb, "Error: 发送失败: %v\n", helper.RedactError(sendErr))
+	case closeErr != nil:
+		fmt.Fprintf(&sb, "Closed: %v\n", helper.RedactError(closeErr))
+	case closed:
+		sb.WriteString("Closed: 服务端已关闭连接\n")
+	case name != "":
+		sb.WriteString("连接保持打开，使用 websocket_close 关闭\n")
+	}
+	formatWSMessages(&sb, transcript, start)
+
+	text := strings.TrimRight(sb.String(), "\n")
+	if sendErr != nil {
+		return mcp.NewToolResultError(text), nil
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+var websocketListTool = mcp.NewTool("websocket_list",
+	mcp.WithDescription("列出 websocket_send 保持打开的命名连接，以及各连接尚未取走的消息数"),
+)
+
+func websocketListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	conns := wsConns.List()
+	if len(conns) == 0 {
+		return mcp.NewToolResultText("没有打开的 WebSocket 连接"), nil
+	}
+	var sb strings.Builder
+	for _, c := range conns {
+		c.mu.Lock()
+		pending, closed := len(c.pending), c.closed
+		c.mu.Unlock()
+		fmt.Fprintf(&sb, "%s: %s", c.Name, c.URL)
+		if c.Protocol != "" {
+			fmt.Fprintf(&sb, " [%s]", c.Protocol)
+		}
+		fmt.Fprintf(&sb, " 建立于 %s，待读取 %d 条", c.Created.Format(time.DateTime), pending)
+		if closed {
+			sb.WriteString("（服务端已关闭）")
+		}
+		sb.WriteString("\n")
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+var websocketCloseTool = mcp.NewTool("websocket_close",
+	mcp.WithDescription("关闭 websocket_send 保持打开的命名连接"),
+	mcp.WithString("connection",
+		mcp.Required(),
+		mcp.Description("连接名称"),
+	),
+)
+
+func websocketCloseHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := cast.ToString(request.GetArguments()["connection"])
+	if !wsConns.Close(name) {
+		return mcp.NewToolResultError(fmt.Sprintf("连接 %s 不存在", name)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已关闭连接 %s", name)), nil
+}
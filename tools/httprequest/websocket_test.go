@@ -0,0 +1,169 @@
+package httprequest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kugouming/mcpservers/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// newWebSocketServer 返回一个 WebSocket 测试服务：连接后先发送欢迎消息（包含 X-Token 请求头），
+// 之后回显每条文本消息，收到 "binary" 时回复二进制消息，收到 "push" 时延迟推送一条消息，收到 "bye" 时关闭连接
+func newWebSocketServer() *httptest.Server {
+	return httptest.NewServer(newWebSocketHandler())
+}
+
+func newWebSocketHandler() websocket.Server {
+	return websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			for _, p := range config.Protocol {
+				if p == "chat.v2" {
+					config.Protocol = []string{p}
+					return nil
+				}
+			}
+			config.Protocol = nil
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			websocket.Message.Send(ws, "welcome "+ws.Request().Header.Get("X-Token"))
+			for {
+				var msg string
+				if err := websocket.Message.Receive(ws, &msg); err != nil {
+					return
+				}
+				switch msg {
+				case "binary":
+					websocket.Message.Send(ws, []byte{0, 1, 2})
+				case "push":
+					time.AfterFunc(50*time.Millisecond, func() { websocket.Message.Send(ws, "pushed") })
+				case "bye":
+					ws.Close()
+					return
+				default:
+					websocket.Message.Send(ws, "echo: "+msg)
+				}
+			}
+		},
+	}
+}
+
+func TestWebSocketSendHandler(t *testing.T) {
+	srv := newWebSocketServer()
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	call := func(t *testing.T, args map[string]any) (string, bool) {
+		t.Helper()
+		result, err := websocketSendHandler(context.Background(), newCallToolRequest("websocket_send", args))
+		require.NoError(t, err)
+		return resultText(t, result), result.IsError
+	}
+
+	t.Run("发送脚本消息并收集回复", func(t *testing.T) {
+		text, isErr := call(t, map[string]any{
+			"url":          wsURL,
+			"headers":      map[string]any{"X-Token": "abc"},
+			"subprotocols": []any{"chat.v1", "chat.v2"},
+			"messages":     []any{"hello", map[string]any{"op": "ping"}, "binary"},
+			"maxMessages":  4,
+		})
+		require.False(t, isErr, text)
+		assert.Contains(t, text, "Subprotocol: chat.v2\nMessages: 发送 3，接收 4\n")
+		assert.Regexp(t, `← *\nwelcome abc\n`, text)
+		assert.Regexp(t, `→\n\{"op":"ping"\}\n`, text)
+		assert.Contains(t, text, "\necho: {\"op\":\"ping\"}\n")
+		assert.Contains(t, text, "← binary 3 bytes (base64)\nAAEC")
+		assert.Empty(t, wsConns.List(), "未指定 connection 时不保留连接")
+	})
+
+	t.Run("超时前未收到足够消息", func(t *testing.T) {
+		start := time.Now()
+		text, _ := call(t, map[string]any{"url": wsURL, "timeoutSeconds": 0.2})
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Contains(t, text, "Messages: 发送 0，接收 1\n")
+	})
+
+	t.Run("命名连接在多次调用之间保持打开", func(t *testing.T) {
+		t.Cleanup(func() { wsConns.Close("chat") })
+		text, _ := call(t, map[string]any{"url": wsURL, "connection": "chat", "messages": []any{"push"}, "maxMessages": 1})
+		assert.Contains(t, text, "(chat)\n")
+		assert.Contains(t, text, "连接保持打开")
+
+		time.Sleep(150 * time.Millisecond)
+		result, err := websocketListHandler(context.Background(), newCallToolRequest("websocket_list", nil))
+		require.NoError(t, err)
+		assert.Contains(t, resultText(t, result), "chat: "+wsURL)
+		assert.Contains(t, resultText(t, result), "待读取 1 条")
+
+		text, _ = call(t, map[string]any{"connection": "chat", "messages": []any{"again"}, "maxMessages": 2})
+		assert.Contains(t, text, "(chat，复用)\nMessages: 发送 1，接收 2\n")
+		assert.Less(t, strings.Index(text, "pushed"), strings.Index(text, "again"), "按时间顺序排列")
+		assert.Contains(t, text, "echo: again")
+
+		text, _ = call(t, map[string]any{"connection": "chat", "messages": []any{"bye"}})
+		assert.Contains(t, text, "Closed: 服务端已关闭连接")
+		assert.Nil(t, wsConns.Get("chat"), "服务端关闭后移除连接")
+
+		text, isErr := call(t, map[string]any{"connection": "chat"})
+		assert.True(t, isErr)
+		assert.Equal(t, "连接 chat 不存在，请提供 url 建立连接", text)
+	})
+
+	t.Run("关闭命名连接", func(t *testing.T) {
+		call(t, map[string]any{"url": wsURL, "connection": "tmp", "timeoutSeconds": 0.1})
+		result, err := websocketCloseHandler(context.Background(), newCallToolRequest("websocket_close", map[string]any{"connection": "tmp"}))
+		require.NoError(t, err)
+		assert.Equal(t, "已关闭连接 tmp", resultText(t, result))
+		assert.Empty(t, wsConns.List())
+	})
+
+	t.Run("出站策略和协议检查", func(t *testing.T) {
+		text, isErr := call(t, map[string]any{"url": "ws://169.254.169.254/ws"})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "169.254.169.254")
+
+		text, isErr = call(t, map[string]any{"url": "ftp://example.com/"})
+		assert.True(t, isErr)
+		assert.Contains(t, text, "不支持的 WebSocket 协议 ftp")
+	})
+}
+
+func TestWebSocketSendHandler_TLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(newWebSocketHandler())
+	defer srv.Close()
+	wsURL := "wss" + strings.TrimPrefix(srv.URL, "https") + "/ws"
+
+	setConfig := func(t *testing.T, cfg *helper.HttpClientConfig) {
+		orig := loadHttpClientConfig
+		loadHttpClientConfig = func() (*helper.HttpClientConfig, error) { return cfg, nil }
+		t.Cleanup(func() { loadHttpClientConfig = orig })
+	}
+	call := func(t *testing.T) (string, bool) {
+		t.Helper()
+		result, err := websocketSendHandler(context.Background(), newCallToolRequest("websocket_send", map[string]any{"url": wsURL, "maxMessages": 1}))
+		require.NoError(t, err)
+		return resultText(t, result), result.IsError
+	}
+
+	t.Run("默认校验证书", func(t *testing.T) {
+		setConfig(t, &helper.HttpClientConfig{})
+		text, isErr := call(t)
+		assert.True(t, isErr)
+		assert.Contains(t, text, "certificate")
+	})
+
+	t.Run("按 insecure_skip_verify 跳过校验", func(t *testing.T) {
+		setConfig(t, &helper.HttpClientConfig{InsecureSkipVerify: []string{"127.0.0.1"}})
+		text, isErr := call(t)
+		require.False(t, isErr, text)
+		assert.Contains(t, text, "welcome")
+	})
+}
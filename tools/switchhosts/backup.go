@@ -0,0 +1,204 @@
+package switchhosts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+// backupTimeFormat 备份编号的时间格式，按字符串排序即按时间排序
+const backupTimeFormat = "20060102-150405.000"
+
+// backupLimit 保留的备份数量，超出时删除最旧的备份
+var backupLimit = 20
+
+// now 返回当前时间，测试中可替换
+var now = time.Now
+
+// getBackupDir 获取 hosts 备份目录
+var getBackupDir = func() string {
+	return filepath.Join(getConfigDir(), "backups")
+}
+
+var historyhostsTool = mcp.NewTool(
+	"switchhosts_history",
+	mcp.WithDescription("列出系统 hosts 文件的历史备份（每次切换或回滚前自动备份），指定 id 时查看该备份的内容。"),
+	mcp.WithString("id",
+		mcp.Description("备份编号，如 20250101-120000.000"),
+	),
+)
+
+var rollbackhostsTool = mcp.NewTool(
+	"switchhosts_rollback",
+	mcp.WithDescription("把系统 hosts 文件恢复为指定的历史备份，未指定时恢复到最近一次修改之前的内容。回滚前同样会备份当前内容，可再次回滚撤销。"),
+	mcp.WithString("id",
+		mcp.Description("备份编号（见 switchhosts_history），默认为最新的备份"),
+	),
+)
+
+// backup hosts 文件的一个备份
+type backup struct {
+	ID   string
+	Path string
+	Size int64
+}
+
+// listBackups 返回按时间倒序排列的备份
+func listBackups() ([]backup, error) {
+	entries, err := os.ReadDir(getBackupDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".bak")
+		if entry.IsDir() || !ok || !strings.HasPrefix(id, "hosts_") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{
+			ID:   strings.TrimPrefix(id, "hosts_"),
+			Path: filepath.Join(getBackupDir(), entry.Name()),
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ID > backups[j].ID })
+	return backups, nil
+}
+
+// findBackup 按编号查找备份，id 为空时返回最新的备份
+func findBackup(id string) (*backup, error) {
+	backups, err := listBackups()
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("暂无 hosts 备份")
+	}
+	if id == "" {
+		return &backups[0], nil
+	}
+	for i := range backups {
+		if backups[i].ID == id {
+			return &backups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("备份 %s 不存在", id)
+}
+
+// backupHosts 保存 hosts 文件当前内容的快照并清理超出保留数量的旧备份，返回备份编号
+func backupHosts(content []byte) (string, error) {
+	dir := getBackupDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	// 同一毫秒内多次备份时追加序号
+	base := now().Format(backupTimeFormat)
+	id := base
+	for i := 2; ; i++ {
+		path := filepath.Join(dir, "hosts_"+id+".bak")
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			id = fmt.Sprintf("%s-%d", base, i)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		break
+	}
+
+	backups, err := listBackups()
+	if err != nil {
+		return id, nil
+	}
+	for i := backupLimit; i < len(backups); i++ {
+		os.Remove(backups[i].Path)
+	}
+	return id, nil
+}
+
+// writeHostsFile 备份 hosts 文件的当前内容后写入新内容，返回备份编号
+func writeHostsFile(hostsPath string, content []byte) (string, error) {
+	current, err := os.ReadFile(hostsPath)
+	if err != nil {
+		return "", fmt.Errorf("读取系统 hosts 文件失败: %w", err)
+	}
+	id, err := backupHosts(current)
+	if err != nil {
+		return "", fmt.Errorf("备份系统 hosts 文件失败: %w", err)
+	}
+	if err := os.WriteFile(hostsPath, content, 0644); err != nil {
+		return "", fmt.Errorf("写入系统 hosts 文件失败: %w", err)
+	}
+	return id, nil
+}
+
+func historyhostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if id := cast.ToString(request.GetArguments()["id"]); id != "" {
+		b, err := findBackup(id)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		content, err := os.ReadFile(b.Path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("读取备份失败: %v", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("备份 %s 的内容: \n%s", b.ID, string(content))), nil
+	}
+
+	backups, err := listBackups()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("读取备份目录失败: %v", err)), nil
+	}
+	if len(backups) == 0 {
+		return mcp.NewToolResultText("暂无 hosts 备份"), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "hosts 备份（最多保留 %d 个，最新的在前）:\n", backupLimit)
+	for _, b := range backups {
+		fmt.Fprintf(&sb, "%s (%d bytes)\n", b.ID, b.Size)
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
+
+func rollbackhostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	b, err := findBackup(cast.ToString(request.GetArguments()["id"]))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	content, err := os.ReadFile(b.Path)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("读取备份失败: %v", err)), nil
+	}
+
+	backupID, err := writeHostsFile(getSystemHostsPath(), content)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已将 hosts 恢复为备份 %s，回滚前的内容已备份为 %s", b.ID, backupID)), nil
+}
@@ -0,0 +1,110 @@
+package switchhosts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBackup 在测试期间使用临时的 hosts 文件和备份目录，时间从 2025-01-01 12:00:00 开始每次调用递增一秒
+func setupBackup(t *testing.T, hosts string, limit int) string {
+	t.Helper()
+	dir := t.TempDir()
+	hostsPath := filepath.Join(dir, "hosts")
+	require.NoError(t, os.WriteFile(hostsPath, []byte(hosts), 0644))
+
+	oldHostsPath, oldBackupDir, oldLimit, oldNow := getSystemHostsPath, getBackupDir, backupLimit, now
+	t.Cleanup(func() {
+		getSystemHostsPath, getBackupDir, backupLimit, now = oldHostsPath, oldBackupDir, oldLimit, oldNow
+	})
+	getSystemHostsPath = func() string { return hostsPath }
+	getBackupDir = func() string { return filepath.Join(dir, "backups") }
+	backupLimit = limit
+	clock := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local)
+	now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return hostsPath
+}
+
+func callHostsTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestWriteHostsFile(t *testing.T) {
+	hostsPath := setupBackup(t, "v0", 3)
+
+	for _, content := range []string{"v1", "v2", "v3", "v4"} {
+		_, err := writeHostsFile(hostsPath, []byte(content))
+		require.NoError(t, err)
+	}
+
+	data, _ := os.ReadFile(hostsPath)
+	assert.Equal(t, "v4", string(data))
+
+	// 只保留最近 3 个备份
+	backups, err := listBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+	assert.Equal(t, []string{"20250101-120004.000", "20250101-120003.000", "20250101-120002.000"},
+		[]string{backups[0].ID, backups[1].ID, backups[2].ID})
+	data, _ = os.ReadFile(backups[0].Path)
+	assert.Equal(t, "v3", string(data))
+
+	// 同一时间的备份追加序号
+	now = func() time.Time { return time.Date(2025, 1, 1, 13, 0, 0, 0, time.Local) }
+	id1, err := backupHosts([]byte("a"))
+	require.NoError(t, err)
+	id2, err := backupHosts([]byte("b"))
+	require.NoError(t, err)
+	assert.Equal(t, "20250101-130000.000", id1)
+	assert.Equal(t, "20250101-130000.000-2", id2)
+}
+
+func TestHostsHistoryAndRollback(t *testing.T) {
+	hostsPath := setupBackup(t, "original", 20)
+
+	text, isErr := callHostsTool(t, historyhostsHandler, nil)
+	assert.False(t, isErr)
+	assert.Equal(t, "暂无 hosts 备份", text)
+	_, isErr = callHostsTool(t, rollbackhostsHandler, nil)
+	assert.True(t, isErr)
+
+	_, err := writeHostsFile(hostsPath, []byte("first"))
+	require.NoError(t, err)
+	_, err = writeHostsFile(hostsPath, []byte("second"))
+	require.NoError(t, err)
+
+	text, _ = callHostsTool(t, historyhostsHandler, nil)
+	assert.Equal(t, "hosts 备份（最多保留 20 个，最新的在前）:\n20250101-120002.000 (5 bytes)\n20250101-120001.000 (8 bytes)", text)
+	text, _ = callHostsTool(t, historyhostsHandler, map[string]any{"id": "20250101-120001.000"})
+	assert.Equal(t, "备份 20250101-120001.000 的内容: \noriginal", text)
+
+	// 默认恢复到最近一次修改之前
+	text, isErr = callHostsTool(t, rollbackhostsHandler, nil)
+	assert.False(t, isErr)
+	assert.Equal(t, "已将 hosts 恢复为备份 20250101-120002.000，回滚前的内容已备份为 20250101-120003.000", text)
+	data, _ := os.ReadFile(hostsPath)
+	assert.Equal(t, "first", string(data))
+
+	_, isErr = callHostsTool(t, rollbackhostsHandler, map[string]any{"id": "20250101-120001.000"})
+	assert.False(t, isErr)
+	data, _ = os.ReadFile(hostsPath)
+	assert.Equal(t, "original", string(data))
+
+	text, isErr = callHostsTool(t, rollbackhostsHandler, map[string]any{"id": "missing"})
+	assert.True(t, isErr)
+	assert.Equal(t, "备份 missing 不存在", text)
+}
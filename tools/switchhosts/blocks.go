@@ -0,0 +1,330 @@
+package switchhosts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cast"
+)
+
+var enablehostsTool = mcp.NewTool(
+	"switchhosts_enable",
+	mcp.WithDescription("在不影响其他已启用配置的情况下启用一个 hosts 配置，可同时启用多个配置（如 staging-api 和 mock-payments）。配置已启用时用配置文件的最新内容更新。"),
+	mcp.WithString("conf_name",
+		mcp.Required(),
+		mcp.Description("配置名称，用于标识配置目录下不同的Hosts文件。"),
+	),
+	mcp.WithBoolean("first",
+		mcp.Description("放在其他已启用配置之前，同一域名出现在多个配置中时优先生效，默认放在最后"),
+	),
+)
+
+var disablehostsTool = mcp.NewTool(
+	"switchhosts_disable",
+	mcp.WithDescription("停用一个已启用的 hosts 配置，其他配置保持不变。"),
+	mcp.WithString("conf_name",
+		mcp.Required(),
+		mcp.Description("配置名称，用于标识配置目录下不同的Hosts文件。"),
+	),
+)
+
+var statushostsTool = mcp.NewTool(
+	"switchhosts_status",
+	mcp.WithDescription("查看当前已启用的 hosts 配置及其生效顺序，并列出多个来源中定义了不同地址的域名。"),
+)
+
+// hostsBlock hosts 文件中由 switchhosts 写入的一个配置区块
+type hostsBlock struct {
+	Name    string // 配置名称，旧版未命名区块为空
+	Content string
+}
+
+// label 返回区块在结果中显示的名称
+func (b hostsBlock) label() string {
+	if b.Name == "" {
+		return "(未命名)"
+	}
+	return b.Name
+}
+
+// splitHostsBlocks 把 hosts 内容拆分为区块之外的内容和按出现顺序排列的区块
+//
+// 区块以 #switchhosts_start:<name> 开始、#switchhosts_end:<name> 结束，
+// 同时兼容旧版未命名的 #switchhosts_start/#switchhosts_end；缺少结束标记的区块视为普通内容。
+func splitHostsBlocks(content string) (string, []hostsBlock) {
+	lines := strings.Split(content, "\n")
+	var (
+		base   []string
+		blocks []hostsBlock
+	)
+	for i := 0; i < len(lines); i++ {
+		name, ok := blockMarker(lines[i], switchhostsStart)
+		if !ok {
+			base = append(base, lines[i])
+			continue
+		}
+		end := -1
+		for j := i + 1; j < len(lines); j++ {
+			if _, ok := blockMarker(lines[j], switchhostsEnd); ok {
+				end = j
+				break
+			}
+		}
+		if end == -1 {
+			base = append(base, lines[i])
+			continue
+		}
+		blocks = append(blocks, hostsBlock{Name: name, Content: strings.Join(lines[i+1:end], "\n")})
+		i = end
+	}
+	return strings.Join(base, "\n"), blocks
+}
+
+// blockMarker 判断是否为指定的区块标记行，返回标记中的配置名称
+func blockMarker(line, marker string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), marker)
+	if !ok {
+		return "", false
+	}
+	if rest == "" {
+		return "", true
+	}
+	name, ok := strings.CutPrefix(rest, ":")
+	return strings.TrimSpace(name), ok
+}
+
+// joinHostsBlocks 把区块按顺序追加到其他内容之后
+func joinHostsBlocks(base string, blocks []hostsBlock) string {
+	var sb strings.Builder
+	if base = strings.TrimRight(base, "\r\n"); base != "" {
+		sb.WriteString(base + "\n")
+	}
+	for _, b := range blocks {
+		start, end := switchhostsStart, switchhostsEnd
+		if b.Name != "" {
+			start, end = start+":"+b.Name, end+":"+b.Name
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%s\n%s\n%s\n", start, strings.TrimRight(b.Content, "\r\n"), end)
+	}
+	return sb.String()
+}
+
+// findBlock 返回指定配置在区块中的位置，未启用时返回 -1
+func findBlock(blocks []hostsBlock, name string) int {
+	for i, b := range blocks {
+		if b.Name != "" && b.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// blockOrder 返回区块的生效顺序
+func blockOrder(blocks []hostsBlock) string {
+	if len(blocks) == 0 {
+		return "无"
+	}
+	labels := make([]string, len(blocks))
+	for i, b := range blocks {
+		labels[i] = b.label()
+	}
+	return strings.Join(labels, " → ")
+}
+
+// hostsEntry hosts 文件中一个域名对应的地址
+type hostsEntry struct {
+	Host string
+	IP   string
+}
+
+// parseHostsEntries 解析 hosts 内容中的域名和地址，忽略注释
+func parseHostsEntries(content string) []hostsEntry {
+	var entries []hostsEntry
+	for _, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, host := range fields[1:] {
+			entries = append(entries, hostsEntry{Host: strings.ToLower(host), IP: fields[0]})
+		}
+	}
+	return entries
+}
+
+// countHostsLines 返回 hosts 内容中有效记录的行数
+func countHostsLines(content string) int {
+	n := 0
+	for _, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		if len(strings.Fields(line)) >= 2 {
+			n++
+		}
+	}
+	return n
+}
+
+// hostsConflicts 返回在多个来源中定义了不同地址的域名，系统按 hosts 文件中的顺序使用第一条匹配的记录
+//
+// IPv4 和 IPv6 地址分别比较，只有与 switchhosts 区块有关的冲突才会列出。
+func hostsConflicts(base string, blocks []hostsBlock) []string {
+	type definition struct {
+		source string
+		ip     string
+	}
+	sources := append([]hostsBlock{{Name: "hosts 文件其他内容", Content: base}}, blocks...)
+	defs := make(map[string][]definition)
+	var keys []string
+	for _, src := range sources {
+		seen := make(map[string]bool)
+		for _, e := range parseHostsEntries(src.Content) {
+			key := e.Host
+			if strings.Contains(e.IP, ":") {
+				key += " (IPv6)"
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := defs[key]; !ok {
+				keys = append(keys, key)
+			}
+			defs[key] = append(defs[key], definition{source: src.label(), ip: e.IP})
+		}
+	}
+
+	var conflicts []string
+	for _, key := range keys {
+		list := defs[key]
+		if len(list) < 2 {
+			continue
+		}
+		var overridden []string
+		for _, d := range list[1:] {
+			if d.ip != list[0].ip {
+				overridden = append(overridden, fmt.Sprintf("%s (%s)", d.source, d.ip))
+			}
+		}
+		if len(overridden) > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s (%s) 生效，%s 被覆盖", key, list[0].source, list[0].ip, strings.Join(overridden, "、")))
+		}
+	}
+	return conflicts
+}
+
+// updateHostsBlocks 读取系统 hosts 文件，用 fn 修改区块后备份并写回，返回修改后的区块和备份编号
+func updateHostsBlocks(fn func(blocks []hostsBlock) ([]hostsBlock, error)) ([]hostsBlock, string, error) {
+	hostsPath := getSystemHostsPath()
+	hostsContent, err := os.ReadFile(hostsPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("读取系统 hosts 文件失败: %w", err)
+	}
+	base, blocks := splitHostsBlocks(string(hostsContent))
+	if blocks, err = fn(blocks); err != nil {
+		return nil, "", err
+	}
+	backupID, err := writeHostsFile(hostsPath, []byte(joinHostsBlocks(base, blocks)))
+	if err != nil {
+		return nil, "", err
+	}
+	return blocks, backupID, nil
+}
+
+func enablehostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	confName := cast.ToString(args["conf_name"])
+	if confName == "" {
+		return mcp.NewToolResultError("conf_name 不能为空"), nil
+	}
+	confContent, err := os.ReadFile(getConfigFilePath(confName))
+	if os.IsNotExist(err) {
+		return mcp.NewToolResultError(fmt.Sprintf("配置 %s 不存在", confName)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("读取配置文件失败: %v", err)), nil
+	}
+	if strings.TrimSpace(string(confContent)) == "" {
+		return mcp.NewToolResultError(fmt.Sprintf("配置 %s 的内容为空", confName)), nil
+	}
+
+	block := hostsBlock{Name: confName, Content: string(confContent)}
+	first := cast.ToBool(args["first"])
+	blocks, backupID, err := updateHostsBlocks(func(blocks []hostsBlock) ([]hostsBlock, error) {
+		// 已启用的配置原位更新，指定 first 时移到最前
+		if idx := findBlock(blocks, confName); idx >= 0 {
+			if !first {
+				blocks[idx] = block
+				return blocks, nil
+			}
+			blocks = append(blocks[:idx], blocks[idx+1:]...)
+		}
+		if first {
+			return append([]hostsBlock{block}, blocks...), nil
+		}
+		return append(blocks, block), nil
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已启用 hosts 配置: %s\n生效顺序: %s\n启用前的 hosts 已备份为 %s", confName, blockOrder(blocks), backupID)), nil
+}
+
+func disablehostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	confName := cast.ToString(request.GetArguments()["conf_name"])
+	blocks, backupID, err := updateHostsBlocks(func(blocks []hostsBlock) ([]hostsBlock, error) {
+		idx := findBlock(blocks, confName)
+		if idx < 0 {
+			return nil, fmt.Errorf("配置 %s 未启用", confName)
+		}
+		return append(blocks[:idx], blocks[idx+1:]...), nil
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("已停用 hosts 配置: %s\n生效顺序: %s\n停用前的 hosts 已备份为 %s", confName, blockOrder(blocks), backupID)), nil
+}
+
+func statushostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	hostsContent, err := os.ReadFile(getSystemHostsPath())
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("读取系统 hosts 文件失败: %v", err)), nil
+	}
+	base, blocks := splitHostsBlocks(string(hostsContent))
+	if len(blocks) == 0 {
+		return mcp.NewToolResultText("当前没有启用任何 hosts 配置"), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("已启用的配置（按生效顺序，同一域名以靠前的记录为准）:\n")
+	for i, b := range blocks {
+		fmt.Fprintf(&sb, "%d. %s (%d 条记录", i+1, b.label(), countHostsLines(b.Content))
+		if b.Name != "" {
+			confContent, err := os.ReadFile(getConfigFilePath(b.Name))
+			switch {
+			case os.IsNotExist(err):
+				sb.WriteString("，配置文件已删除")
+			case err == nil && strings.TrimRight(string(confContent), "\r\n") != strings.TrimRight(b.Content, "\r\n"):
+				sb.WriteString("，配置文件已修改，重新启用以更新")
+			}
+		}
+		sb.WriteString(")\n")
+	}
+	if conflicts := hostsConflicts(base, blocks); len(conflicts) > 0 {
+		sb.WriteString("冲突:\n")
+		for _, c := range conflicts {
+			fmt.Fprintf(&sb, "  %s\n", c)
+		}
+	}
+	return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+}
@@ -0,0 +1,103 @@
+package switchhosts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitHostsBlocks(t *testing.T) {
+	content := "127.0.0.1 localhost\n\n#switchhosts_start\n10.0.0.1 old.local\n#switchhosts_end\n" +
+		"\n#switchhosts_start:staging-api\n10.0.0.2 api.example.com\n#switchhosts_end:staging-api\n" +
+		"#switchhosts_start:broken\n10.0.0.3 broken.local\n"
+
+	base, blocks := splitHostsBlocks(content)
+	assert.Equal(t, "127.0.0.1 localhost\n\n\n#switchhosts_start:broken\n10.0.0.3 broken.local\n", base)
+	assert.Equal(t, []hostsBlock{
+		{Name: "", Content: "10.0.0.1 old.local"},
+		{Name: "staging-api", Content: "10.0.0.2 api.example.com"},
+	}, blocks)
+
+	joined := joinHostsBlocks("127.0.0.1 localhost\n\n", blocks[1:])
+	assert.Equal(t, "127.0.0.1 localhost\n\n#switchhosts_start:staging-api\n10.0.0.2 api.example.com\n#switchhosts_end:staging-api\n", joined)
+
+	// 拆分后再合并内容不变
+	base, blocks = splitHostsBlocks(joined)
+	assert.Equal(t, joined, joinHostsBlocks(base, blocks))
+}
+
+func TestHostsConflicts(t *testing.T) {
+	conflicts := hostsConflicts("127.0.0.1 localhost\n::1 localhost\n10.0.0.9 db.local\n", []hostsBlock{
+		{Name: "staging-api", Content: "10.0.0.1 api.example.com\n10.0.0.8 db.local"},
+		{Name: "mock-payments", Content: "10.0.0.2 api.example.com pay.example.com # mock\n::1 localhost"},
+	})
+	assert.Equal(t, []string{
+		"db.local: hosts 文件其他内容 (10.0.0.9) 生效，staging-api (10.0.0.8) 被覆盖",
+		"api.example.com: staging-api (10.0.0.1) 生效，mock-payments (10.0.0.2) 被覆盖",
+	}, conflicts)
+}
+
+func TestEnableDisableHosts(t *testing.T) {
+	hostsPath := setupBackup(t, "127.0.0.1 localhost\n#switchhosts_start\n10.0.0.9 legacy.local\n#switchhosts_end\n", 20)
+	configDir := t.TempDir()
+	oldGetConfigFilePath := getConfigFilePath
+	t.Cleanup(func() { getConfigFilePath = oldGetConfigFilePath })
+	getConfigFilePath = func(name string) string { return filepath.Join(configDir, getHostFileName(name)) }
+	writeConf := func(name, content string) {
+		require.NoError(t, os.WriteFile(getConfigFilePath(name), []byte(content), 0644))
+	}
+	writeConf("staging-api", "10.0.0.1 api.example.com\n")
+	writeConf("mock-payments", "10.0.0.2 pay.example.com\n10.0.0.3 api.example.com\n")
+
+	text, isErr := callHostsTool(t, enablehostsHandler, map[string]any{"conf_name": "staging-api"})
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "生效顺序: (未命名) → staging-api\n")
+	text, isErr = callHostsTool(t, enablehostsHandler, map[string]any{"conf_name": "mock-payments", "first": true})
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "生效顺序: mock-payments → (未命名) → staging-api\n")
+
+	data, _ := os.ReadFile(hostsPath)
+	assert.Equal(t, "127.0.0.1 localhost\n"+
+		"\n#switchhosts_start:mock-payments\n10.0.0.2 pay.example.com\n10.0.0.3 api.example.com\n#switchhosts_end:mock-payments\n"+
+		"\n#switchhosts_start\n10.0.0.9 legacy.local\n#switchhosts_end\n"+
+		"\n#switchhosts_start:staging-api\n10.0.0.1 api.example.com\n#switchhosts_end:staging-api\n", string(data))
+
+	// 配置文件修改后状态中提示，重新启用时原位更新
+	writeConf("staging-api", "10.0.0.5 api.example.com\n10.0.0.6 web.example.com\n")
+	text, _ = callHostsTool(t, statushostsHandler, nil)
+	assert.Equal(t, "已启用的配置（按生效顺序，同一域名以靠前的记录为准）:\n"+
+		"1. mock-payments (2 条记录)\n"+
+		"2. (未命名) (1 条记录)\n"+
+		"3. staging-api (1 条记录，配置文件已修改，重新启用以更新)\n"+
+		"冲突:\n"+
+		"  api.example.com: mock-payments (10.0.0.3) 生效，staging-api (10.0.0.1) 被覆盖", text)
+	text, _ = callHostsTool(t, enablehostsHandler, map[string]any{"conf_name": "staging-api"})
+	assert.Contains(t, text, "生效顺序: mock-payments → (未命名) → staging-api\n")
+	text, _ = callHostsTool(t, statushostsHandler, nil)
+	assert.Contains(t, text, "3. staging-api (2 条记录)\n")
+
+	text, isErr = callHostsTool(t, disablehostsHandler, map[string]any{"conf_name": "mock-payments"})
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "生效顺序: (未命名) → staging-api\n")
+	text, isErr = callHostsTool(t, disablehostsHandler, map[string]any{"conf_name": "mock-payments"})
+	assert.True(t, isErr)
+	assert.Equal(t, "配置 mock-payments 未启用", text)
+
+	text, isErr = callHostsTool(t, enablehostsHandler, map[string]any{"conf_name": "missing"})
+	assert.True(t, isErr)
+	assert.Equal(t, "配置 missing 不存在", text)
+
+	// switchhosts 只保留指定的配置
+	text, isErr = callHostsTool(t, switchhostsHandler, map[string]any{"conf_name": "mock-payments"})
+	require.False(t, isErr, text)
+	text, _ = callHostsTool(t, statushostsHandler, nil)
+	assert.Equal(t, "已启用的配置（按生效顺序，同一域名以靠前的记录为准）:\n1. mock-payments (2 条记录)", text)
+
+	// 每次修改前都有备份
+	backups, err := listBackups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 5)
+}
@@ -24,11 +24,16 @@ func RegisterTool(s *server.MCPServer) {
 	s.AddTool(switchhostsTool, switchhostsHandler)
 	s.AddTool(listhostsTool, listhostsHandler)
 	s.AddTool(viewhostsTool, viewhostsHandler)
+	s.AddTool(historyhostsTool, historyhostsHandler)
+	s.AddTool(rollbackhostsTool, rollbackhostsHandler)
+	s.AddTool(enablehostsTool, enablehostsHandler)
+	s.AddTool(disablehostsTool, disablehostsHandler)
+	s.AddTool(statushostsTool, statushostsHandler)
 }
 
 var switchhostsTool = mcp.NewTool(
 	"switchhosts",
-	mcp.WithDescription("本地 Hosts 管理工具，用于快速切换不同环境下的网络配置。"),
+	mcp.WithDescription("本地 Hosts 管理工具，用于快速切换不同环境下的网络配置。切换后只保留指定的配置，需要同时启用多个配置时使用 switchhosts_enable。"),
 	mcp.WithString("conf_name",
 		mcp.Required(),
 		mcp.Description("配置名称，用于标识配置目录下不同的Hosts文件。"),
@@ -99,17 +104,6 @@ var listConfigNames = func() []string {
 	return confNames
 }
 
-// removeSwitchhostsBlock 移除 hosts 文件中的 switchhosts 区块
-func removeSwitchhostsBlock(content string) string {
-	startIdx := strings.Index(content, switchhostsStart)
-	endIdx := strings.Index(content, switchhostsEnd)
-	if startIdx == -1 || endIdx == -1 || endIdx < startIdx {
-		return content
-	}
-	// 保留 end 后的内容
-	return content[:startIdx] + content[endIdx+len(switchhostsEnd):]
-}
-
 // switchhostsHandler 处理 hosts 切换请求
 func switchhostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	confName := cast.ToString(request.GetArguments()["conf_name"])
@@ -138,26 +132,25 @@ func switchhostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 
 	debug += fmt.Sprintf("HostsContent: \n%s\n\n", string(hostsContent))
 
-	// 移除系统 hosts 文件中的 switchhosts 区块
-	newContent := removeSwitchhostsBlock(string(hostsContent))
-
-	// 追加分隔符和配置内容
+	// 移除系统 hosts 文件中所有的 switchhosts 区块，再追加配置内容
+	base, _ := splitHostsBlocks(string(hostsContent))
+	var blocks []hostsBlock
 	if len(confContent) > 0 {
-		block := "\n" + switchhostsStart + "\n" + string(confContent) + "\n" + switchhostsEnd + "\n"
-		newContent += block
+		blocks = append(blocks, hostsBlock{Name: confName, Content: string(confContent)})
 	}
+	newContent := joinHostsBlocks(base, blocks)
 
 	debug += fmt.Sprintf("NewContent: \n%s\n\n", newContent)
 
-	// 写回系统 hosts 文件
-	err = os.WriteFile(hostsPath, []byte(newContent), 0644)
+	// 备份后写回系统 hosts 文件
+	backupID, err := writeHostsFile(hostsPath, []byte(newContent))
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("写入系统 hosts 文件失败: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	log.Printf("Debug message: %v", debug)
 
-	return mcp.NewToolResultText(fmt.Sprintf("已成功切换 hosts 配置为: %s\n\n%v", confName, debug)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("已成功切换 hosts 配置为: %s（切换前的 hosts 已备份为 %s，可通过 switchhosts_rollback 恢复）\n\n%v", confName, backupID, debug)), nil
 }
 
 func listhostsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -29,11 +29,14 @@ func TestSwitchhostsHandler(t *testing.T) {
 	// 覆盖 getSystemHostsPath/getConfigFilePath 以便测试
 	oldGetSystemHostsPath := getSystemHostsPath
 	oldGetConfigFilePath := getConfigFilePath
+	oldGetBackupDir := getBackupDir
 	getSystemHostsPath = func() string { return hostsPath }
 	getConfigFilePath = func(name string) string { return confFile }
+	getBackupDir = func() string { return filepath.Join(configDir, "backups") }
 	defer func() {
 		getSystemHostsPath = oldGetSystemHostsPath
 		getConfigFilePath = oldGetConfigFilePath
+		getBackupDir = oldGetBackupDir
 	}()
 
 	// 构造请求
@@ -59,4 +62,12 @@ func TestSwitchhostsHandler(t *testing.T) {
 	assert.Contains(t, string(finalContent), hostsContent)
 	assert.Contains(t, string(finalContent), "#switchhosts_start")
 	assert.Contains(t, string(finalContent), "#switchhosts_end")
+
+	// 切换前的内容已备份
+	backups, err := listBackups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		backupContent, _ := ioutil.ReadFile(backups[0].Path)
+		assert.Equal(t, originHosts, string(backupContent))
+	}
 }
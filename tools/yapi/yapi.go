@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/kugouming/mcpservers/helper"
 )
 
 // YapiClient YAPI客户端接口
@@ -126,22 +128,33 @@ func NewYapiClient(baseURL, token string) YapiClient {
 		Token:   token,
 		Timeout: 30,
 	}
-	return &yapiClientImpl{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second, // 使用配置的超时时间
-		},
-	}
+	return NewYapiClientFromConfig(config)
 }
 
 // NewYapiClientFromConfig 从配置创建YAPI客户端
 func NewYapiClientFromConfig(config *Config) YapiClient {
 	return &yapiClientImpl{
-		config: config,
-		httpClient: &http.Client{
+		config:     config,
+		httpClient: newHttpClient(config),
+	}
+}
+
+// newHttpClient 基于共享的 HTTP 客户端配置创建客户端，超时和重试次数使用 YAPI 配置
+func newHttpClient(config *Config) *http.Client {
+	clientConfig, err := helper.LoadHttpClientConfig()
+	if err != nil {
+		clientConfig = helper.DefaultHttpClientConfig()
+	}
+	clientConfig.Timeout = config.Timeout
+	clientConfig.RetryCount = config.RetryCount
+
+	client, err := helper.NewHttpClient(clientConfig)
+	if err != nil {
+		return &http.Client{
 			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
+		}
 	}
+	return client
 }
 
 // GetInterfaces 获取项目接口列表
@@ -150,7 +163,7 @@ func (c *yapiClientImpl) GetInterfaces(projectID int) (*YapiInterfaceListRespons
 
 	resp, err := c.httpClient.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, fmt.Errorf("请求失败: %w", helper.RedactError(err))
 	}
 	defer resp.Body.Close()
 
@@ -161,7 +174,7 @@ func (c *yapiClientImpl) GetInterfaces(projectID int) (*YapiInterfaceListRespons
 	var response YapiInterfaceListResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("解析响应失败: %w\n\nDebug:\n%s\n\n%v", err, url, string(body))
+		return nil, fmt.Errorf("解析响应失败: %w\n\nDebug:\n%s\n\n%v", err, helper.RedactURL(url), string(body))
 	}
 
 	if response.ErrCode != 0 {
@@ -177,7 +190,7 @@ func (c *yapiClientImpl) GetInterfaceDetail(interfaceID int) (*YapiInterfaceDeta
 
 	resp, err := c.httpClient.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, fmt.Errorf("请求失败: %w", helper.RedactError(err))
 	}
 	defer resp.Body.Close()
 
@@ -188,7 +201,7 @@ func (c *yapiClientImpl) GetInterfaceDetail(interfaceID int) (*YapiInterfaceDeta
 	var response YapiInterfaceDetailResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("解析响应失败: %w\n\nDebug:\n%s\n\n%v", err, url, string(body))
+		return nil, fmt.Errorf("解析响应失败: %w\n\nDebug:\n%s\n\n%v", err, helper.RedactURL(url), string(body))
 	}
 
 	if response.ErrCode != 0 {
@@ -204,7 +217,7 @@ func (c *yapiClientImpl) GetProjectInfo(projectID int) (*YapiProjectResponse, er
 
 	resp, err := c.httpClient.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, fmt.Errorf("请求失败: %w", helper.RedactError(err))
 	}
 	defer resp.Body.Close()
 
@@ -215,7 +228,7 @@ func (c *yapiClientImpl) GetProjectInfo(projectID int) (*YapiProjectResponse, er
 	var response YapiProjectResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("解析响应失败: %w\n\nDebug:\n%s\n\n%v", err, url, string(body))
+		return nil, fmt.Errorf("解析响应失败: %w\n\nDebug:\n%s\n\n%v", err, helper.RedactURL(url), string(body))
 	}
 
 	if response.ErrCode != 0 {